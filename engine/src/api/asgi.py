@@ -6,6 +6,7 @@ from fastapi import FastAPI
 
 from .config import close_db_connection, init_db, settings
 from .endpoints import api_router
+from .endpoints.health import probe_router
 from .middleware import setup_middleware
 from .services.checkpointer import close_graph_checkpointer, init_graph_checkpointer
 from .services.limiter import close_limiter, init_limiter
@@ -78,6 +79,7 @@ def create_application() -> FastAPI:
     setup_middleware(application)
 
     application.include_router(api_router, prefix=settings.API_V1_STR)
+    application.include_router(probe_router)
 
     return application
 
@@ -1,5 +1,6 @@
 import logging
 from typing import Any, Dict
+from urllib.parse import parse_qsl, urlencode, urlsplit, urlunsplit
 
 from tortoise import Tortoise
 
@@ -7,8 +8,27 @@ from .settings import settings
 
 logger = logging.getLogger(__name__)
 
+
+def _with_pool_params(url: str) -> str:
+    """Add the DB_POOL_* settings to the URL as asyncpg pool parameters.
+
+    Parameters already present in the URL take precedence.
+    """
+    pool_params = {
+        "maxsize": settings.DB_POOL_MAX,
+        "minsize": settings.DB_POOL_MIN,
+        "max_inactive_connection_lifetime": settings.DB_POOL_MAX_IDLE_TIME,
+    }
+    parts = urlsplit(url)
+    query = dict(parse_qsl(parts.query))
+    for name, value in pool_params.items():
+        if value is not None and name not in query:
+            query[name] = str(value)
+    return urlunsplit(parts._replace(query=urlencode(query)))
+
+
 TORTOISE_ORM_CONFIG = {
-    "connections": {"default": str(settings.POSTGRES_DATABASE_URL)},
+    "connections": {"default": _with_pool_params(str(settings.POSTGRES_DATABASE_URL))},
     "apps": {
         "models": {
             "models": [
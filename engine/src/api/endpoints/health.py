@@ -1,13 +1,17 @@
 import logging
 from typing import Any, Dict
 
-from fastapi import APIRouter
+from fastapi import APIRouter, Response, status
 from tortoise import Tortoise
 
+from ..services.limiter import get_redis_client
+
 logger = logging.getLogger(__name__)
 
 router = APIRouter()
 
+probe_router = APIRouter()
+
 
 @router.get("/", tags=["health"])
 async def health_check() -> Dict[str, Any]:
@@ -34,3 +38,35 @@ async def database_health_check() -> Dict[str, Any]:
             "database": "disconnected",
             "error": str(e),
         }
+
+
+@probe_router.get("/healthz", tags=["health"])
+async def liveness() -> Dict[str, Any]:
+    return {"status": "ok"}
+
+
+@probe_router.get("/ready", tags=["health"])
+async def readiness(response: Response) -> Dict[str, Any]:
+    checks: Dict[str, str] = {}
+
+    try:
+        conn = Tortoise.get_connection("default")
+        await conn.execute_query("SELECT 1")
+        checks["database"] = "connected"
+    except Exception:
+        logger.exception("Readiness check failed to reach the database")
+        checks["database"] = "disconnected"
+
+    try:
+        redis_client = await get_redis_client()
+        if redis_client is not None:
+            await redis_client.ping()
+            checks["redis"] = "connected"
+    except Exception:
+        logger.exception("Readiness check failed to reach Redis")
+        checks["redis"] = "disconnected"
+
+    if any(value == "disconnected" for value in checks.values()):
+        response.status_code = status.HTTP_503_SERVICE_UNAVAILABLE
+        return {"status": "error", **checks}
+    return {"status": "ok", **checks}
@@ -1,18 +1,35 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/skyflo-ai/skyflo/kubernetes-controller/controllers"
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
 )
 
 var (
@@ -22,6 +39,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(skyflov1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -29,12 +47,74 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var validateScheduling bool
+	var enableWebhooks bool
+	var cacheSyncTimeout time.Duration
+	var imageDigestPollInterval time.Duration
+	var otlpEndpoint string
+	var maxReplicasPerComponent int
+	var fieldOwner string
+	var reconcileDebounce time.Duration
+	var freezeUntil string
+	var enablePruning bool
+	var selector string
+	var checkKubeconfigReachability bool
+	var requiredMetadata string
+	var mcpClusterRBACNamespaces string
+	var serverSideApply bool
+	var forceApplyFields []string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&cacheSyncTimeout, "cache-sync-timeout", 5*time.Minute,
+		"How long controllers wait for the initial informer cache sync before giving up.")
+	flag.DurationVar(&imageDigestPollInterval, "image-digest-poll-interval", 5*time.Minute,
+		"How often image tags of components with trackTag are resolved to detect pushed digests. 0 disables tag tracking.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"OTLP/HTTP collector base URL reconcile traces are exported to, e.g. http://otel-collector:4318. "+
+			"Defaults to $OTEL_EXPORTER_OTLP_ENDPOINT; tracing is disabled when empty.")
+	flag.IntVar(&maxReplicasPerComponent, "max-replicas-per-component", 0,
+		"Clamp the replicas of every component of every SkyfloAI to this value. 0 disables the cap.")
+	flag.DurationVar(&reconcileDebounce, "reconcile-debounce", 0,
+		"Delay before reconciling an edited SkyfloAI, collapsing rapid successive edits into one reconcile. 0 disables debouncing.")
+	flag.StringVar(&freezeUntil, "freeze-until", "",
+		"RFC3339 time until which a change freeze holds: SkyfloAIs get a ChangeFreeze condition but no resources are changed. "+
+			"Reconciling resumes automatically afterwards.")
+	flag.BoolVar(&enablePruning, "enable-pruning", true,
+		"Delete resources of removed components and disabled features. When false they are only orphaned, "+
+			"whatever the SkyfloAI pruningPolicy.")
+	flag.StringVar(&fieldOwner, "field-owner", "skyflo-controller",
+		"Field manager name recorded for the controller's writes.")
+	flag.StringVar(&selector, "selector", "",
+		"Label selector restricting the SkyfloAIs this instance watches and reconciles, e.g. shard=a, "+
+			"to shard reconciliation across several controller deployments. Empty selects every SkyfloAI.")
+	flag.BoolVar(&serverSideApply, "server-side-apply", false,
+		"Write child resources with server-side apply without forcing ownership. Fields owned by other field managers "+
+			"are left to them and reported in a FieldManagerConflict condition.")
+	flag.Func("force-apply-field",
+		"Field path, e.g. .spec.replicas, taken over from other field managers with --server-side-apply, "+
+			"including the fields nested under it. May be repeated.",
+		func(path string) error {
+			forceApplyFields = append(forceApplyFields, path)
+			return nil
+		})
+	flag.BoolVar(&checkKubeconfigReachability, "check-kubeconfig-reachability", false,
+		"Before rolling out an MCP with a kubeconfigSecret, check that the kubeconfig's API server answers /version.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the SkyfloAI validating webhook. Requires serving certificates in the webhook cert directory.")
+	flag.StringVar(&requiredMetadata, "required-metadata", "",
+		"Comma-separated keys, e.g. owner,cost-center, every SkyfloAI must carry as a label or annotation. "+
+			"Enforced by the validating webhook; empty requires none.")
+	flag.StringVar(&mcpClusterRBACNamespaces, "mcp-cluster-rbac-namespaces", "",
+		"Comma-separated namespaces whose SkyfloAIs may have an MCP ClusterRole generated through "+
+			"spec.mcp.clusterRBAC or spec.mcp.rbac, or * for all. Such a SkyfloAI grants cluster-wide "+
+			"permissions to pods it defines, so only list namespaces whose SkyfloAI authors you trust; empty allows none.")
+	flag.BoolVar(&validateScheduling, "validate-scheduling", false,
+		"Check that at least one node matches each component's node selector, affinity and tolerations, "+
+			"and report an Unschedulable condition when none does.")
 
 	opts := zap.Options{
 		Development: true,
@@ -44,19 +124,83 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "skyflo-controller.skyflo.ai",
-		WebhookServer:          nil, // We'll configure webhooks later when needed
-	})
+	var freezeEnd time.Time
+	if freezeUntil != "" {
+		parsed, err := time.Parse(time.RFC3339, freezeUntil)
+		if err != nil {
+			setupLog.Error(err, "invalid --freeze-until")
+			os.Exit(1)
+		}
+		freezeEnd = parsed
+	}
+
+	skyfloSelector, err := labels.Parse(selector)
+	if err != nil {
+		setupLog.Error(err, "invalid --selector")
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := setupTracing(context.Background(), otlpEndpoint)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(),
+		managerOptions(probeAddr, enableLeaderElection, skyfloSelector, cacheSyncTimeout))
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	// TODO: Register controllers here once we create them
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		setupLog.Error(err, "unable to detect the API server version; assuming all Service fields are supported")
+	}
+
+	var digestResolver controllers.DigestResolver
+	if imageDigestPollInterval > 0 {
+		digestResolver = controllers.NewRegistryResolver(imageDigestPollInterval)
+	}
+
+	var kubeconfigChecker controllers.KubeconfigChecker
+	if checkKubeconfigReachability {
+		kubeconfigChecker = controllers.CheckServerVersion
+	}
+
+	if err = (&controllers.SkyfloAIReconciler{
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		Recorder:                 mgr.GetEventRecorderFor("skyflo-controller"),
+		APIReader:                mgr.GetAPIReader(),
+		ValidateScheduling:       validateScheduling,
+		ServerVersion:            serverVersion,
+		DigestResolver:           digestResolver,
+		DigestPollInterval:       imageDigestPollInterval,
+		KubeconfigChecker:        kubeconfigChecker,
+		MaxReplicasPerComponent:  int32(maxReplicasPerComponent),
+		FieldOwner:               fieldOwner,
+		ReconcileDebounce:        reconcileDebounce,
+		FreezeUntil:              freezeEnd,
+		DisablePruning:           !enablePruning,
+		ServerSideApply:          serverSideApply,
+		ForceApplyFields:         forceApplyFields,
+		MCPClusterRBACNamespaces: splitKeys(mcpClusterRBACNamespaces),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SkyfloAI")
+		os.Exit(1)
+	}
+	if enableWebhooks {
+		if err = (&skyflov1.SkyfloAI{}).SetupWebhookWithManager(mgr, splitKeys(requiredMetadata)); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SkyfloAI")
+			os.Exit(1)
+		}
+	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -67,9 +211,121 @@ func main() {
 		os.Exit(1)
 	}
 
+	var cacheSynced atomic.Bool
+	if err := mgr.Add(&cacheSyncWatcher{cache: mgr.GetCache(), synced: &cacheSynced}); err != nil {
+		setupLog.Error(err, "unable to set up cache sync watcher")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("cache-sync", func(_ *http.Request) error {
+		if !cacheSynced.Load() {
+			return errCacheNotSynced
+		}
+		return nil
+	}); err != nil {
+		setupLog.Error(err, "unable to set up cache sync check")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		setupLog.Error(err, "failed to flush traces")
+	}
+}
+
+// managerOptions returns the options the manager is created with.
+func managerOptions(probeAddr string, leaderElection bool, selector labels.Selector, cacheSyncTimeout time.Duration) ctrl.Options {
+	return ctrl.Options{
+		Scheme:                 scheme,
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         leaderElection,
+		LeaderElectionID:       leaderElectionID(selector),
+		// SkyfloAIs outside the selector never reach the cache, so they are
+		// neither watched nor reconciled.
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&skyflov1.SkyfloAI{}: {Label: selector},
+			},
+		},
+		Controller: config.Controller{
+			CacheSyncTimeout: cacheSyncTimeout,
+		},
+	}
+}
+
+// leaderElectionID returns the leader election lease name. Instances
+// sharding by --selector each elect their own leader, so the lease is keyed
+// by the selector.
+func leaderElectionID(selector labels.Selector) string {
+	if selector.Empty() {
+		return "skyflo-controller.skyflo.ai"
+	}
+	sum := sha256.Sum256([]byte(selector.String()))
+	return "skyflo-controller-" + hex.EncodeToString(sum[:])[:10] + ".skyflo.ai"
+}
+
+var errCacheNotSynced = errors.New("informer caches have not synced yet")
+
+// cacheSyncWatcher runs watchCacheSync on every replica, not only the
+// leader: standby replicas sync their caches too and must become ready, or a
+// rolling update of the manager waits on a pod that cannot get the lease.
+type cacheSyncWatcher struct {
+	cache  cache.Cache
+	synced *atomic.Bool
+}
+
+var _ manager.LeaderElectionRunnable = &cacheSyncWatcher{}
+
+func (w *cacheSyncWatcher) Start(ctx context.Context) error {
+	watchCacheSync(ctx, w.cache, w.synced)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (w *cacheSyncWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// watchCacheSync logs progress while the informer caches perform their
+// initial sync, so a slow sync on a large cluster shows up as an unready
+// manager instead of a silent hang.
+func watchCacheSync(ctx context.Context, c cache.Cache, synced *atomic.Bool) {
+	done := make(chan bool, 1)
+	go func() {
+		done <- c.WaitForCacheSync(ctx)
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ok := <-done:
+			if ok {
+				synced.Store(true)
+				setupLog.Info("informer caches synced", "elapsed", time.Since(start).Round(time.Second))
+			}
+			return
+		case <-ticker.C:
+			setupLog.Info("waiting for informer caches to sync", "elapsed", time.Since(start).Round(time.Second))
+		}
+	}
+}
+
+// splitKeys splits a comma-separated flag value, dropping blank entries.
+func splitKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestManagerOptionsCacheSyncTimeout(t *testing.T) {
+	opts := managerOptions(":8081", false, labels.Everything(), 12*time.Minute)
+	if opts.Controller.CacheSyncTimeout != 12*time.Minute {
+		t.Errorf("CacheSyncTimeout = %v, want 12m", opts.Controller.CacheSyncTimeout)
+	}
+}
+
+func TestWatchCacheSync(t *testing.T) {
+	for _, synced := range []bool{true, false} {
+		var ready atomic.Bool
+		watchCacheSync(context.Background(), &informertest.FakeInformers{Synced: ptr.To(synced)}, &ready)
+		if ready.Load() != synced {
+			t.Errorf("cache synced %v: ready = %v", synced, ready.Load())
+		}
+	}
+}
+
+func TestManagerOptionsSelector(t *testing.T) {
+	selector, err := labels.Parse("shard=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := managerOptions(":8081", false, selector, time.Minute)
+
+	var cached labels.Selector
+	for obj, byObject := range opts.Cache.ByObject {
+		if _, ok := obj.(*skyflov1.SkyfloAI); ok {
+			cached = byObject.Label
+		}
+	}
+	if cached == nil {
+		t.Fatal("the cache does not restrict SkyfloAIs")
+	}
+	for set, want := range map[string]bool{"shard=a": true, "shard=a,team=x": true, "shard=b": false, "": false} {
+		objLabels, err := labels.ConvertSelectorToLabelsMap(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cached.Matches(objLabels); got != want {
+			t.Errorf("SkyfloAI labeled %q cached = %v, want %v", set, got, want)
+		}
+	}
+}
+
+func TestLeaderElectionID(t *testing.T) {
+	everything := leaderElectionID(labels.Everything())
+	if everything != "skyflo-controller.skyflo.ai" {
+		t.Errorf("lease without a selector = %q, want the unsharded lease", everything)
+	}
+	shardA := leaderElectionID(labels.SelectorFromSet(labels.Set{"shard": "a"}))
+	shardB := leaderElectionID(labels.SelectorFromSet(labels.Set{"shard": "b"}))
+	if shardA == everything || shardA == shardB {
+		t.Errorf("leases %q and %q, want one per selector distinct from %q", shardA, shardB, everything)
+	}
+	if again := leaderElectionID(labels.SelectorFromSet(labels.Set{"shard": "a"})); again != shardA {
+		t.Errorf("lease for the same selector = %q, then %q", shardA, again)
+	}
+	for _, id := range []string{shardA, shardB} {
+		if errs := validation.IsDNS1123Subdomain(id); len(errs) > 0 {
+			t.Errorf("lease %q: %v", id, errs)
+		}
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: ""},
+		{value: "owner", want: []string{"owner"}},
+		{value: "owner, cost-center,", want: []string{"owner", "cost-center"}},
+		{value: " , ", want: nil},
+	}
+	for _, tt := range tests {
+		if got := splitKeys(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeys(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
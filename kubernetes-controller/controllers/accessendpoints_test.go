@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAccessEndpoints(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"}
+	loadBalancer := func(points ...networkingv1.IngressLoadBalancerIngress) networkingv1.IngressStatus {
+		return networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{Ingress: points}}
+	}
+	tests := []struct {
+		name string
+		objs []client.Object
+		want []string
+	}{
+		{name: "nothing exposed"},
+		{
+			name: "ingress hosts once an address is assigned",
+			objs: []client.Object{&networkingv1.Ingress{
+				ObjectMeta: meta,
+				Spec: networkingv1.IngressSpec{
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"skyflo.example.com"}}},
+					Rules: []networkingv1.IngressRule{{Host: "skyflo.example.com"}},
+				},
+				Status: loadBalancer(networkingv1.IngressLoadBalancerIngress{IP: "203.0.113.10"}),
+			}},
+			want: []string{"https://skyflo.example.com"},
+		},
+		{
+			name: "ingress pending",
+			objs: []client.Object{&networkingv1.Ingress{
+				ObjectMeta: meta,
+				Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "skyflo.example.com"}}},
+			}},
+		},
+		{
+			name: "ingress without hosts uses its addresses",
+			objs: []client.Object{&networkingv1.Ingress{
+				ObjectMeta: meta,
+				Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{}}},
+				Status: loadBalancer(
+					networkingv1.IngressLoadBalancerIngress{Hostname: "lb.example.com", IP: "203.0.113.10"},
+					networkingv1.IngressLoadBalancerIngress{IP: "203.0.113.11"},
+				),
+			}},
+			want: []string{"http://lb.example.com", "http://203.0.113.11"},
+		},
+		{
+			name: "load balancer service",
+			objs: []client.Object{&corev1.Service{
+				ObjectMeta: meta,
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeLoadBalancer,
+					Ports: []corev1.ServicePort{{Port: 80}, {Port: 443}, {Port: 8080}},
+				},
+				Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.20"}},
+				}},
+			}},
+			want: []string{"http://203.0.113.20", "https://203.0.113.20", "http://203.0.113.20:8080"},
+		},
+		{
+			name: "load balancer pending",
+			objs: []client.Object{&corev1.Service{
+				ObjectMeta: meta,
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{{Port: 80}}},
+			}},
+		},
+		{
+			name: "cluster IP service",
+			objs: []client.Object{&corev1.Service{
+				ObjectMeta: meta,
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Ports: []corev1.ServicePort{{Port: 80}}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReconciler(tt.objs)
+			got, err := r.accessEndpoints(context.Background(), testSkyfloAI())
+			if err != nil {
+				t.Fatalf("accessEndpoints: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("accessEndpoints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
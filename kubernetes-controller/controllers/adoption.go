@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// adoptAnnotation, set to "true" on a pre-existing object, lets the
+// controller take over an object it did not create. Without it such objects
+// are left untouched.
+const adoptAnnotation = "skyflo.ai/adopt"
+
+// foreignResourceError reports an existing object the SkyfloAI does not
+// control and may not adopt.
+type foreignResourceError struct {
+	kind string
+	name string
+}
+
+func (e *foreignResourceError) Error() string {
+	return fmt.Sprintf("%s %s exists and is not owned by this SkyfloAI; annotate it with %s: \"true\" to adopt it",
+		e.kind, e.name, adoptAnnotation)
+}
+
+// checkAdoptable refuses to overwrite an existing object unless it is already
+// controlled by the owner of the desired object or has opted into adoption.
+func checkAdoptable(found, desired client.Object, kind string) error {
+	owner := metav1.GetControllerOf(desired)
+	if owner == nil {
+		return nil
+	}
+	if current := metav1.GetControllerOf(found); current != nil && current.UID == owner.UID {
+		return nil
+	}
+	if found.GetAnnotations()[adoptAnnotation] == "true" {
+		return nil
+	}
+	return &foreignResourceError{kind: kind, name: found.GetName()}
+}
+
+// setForeignResourceCondition reports the objects the controller refused to
+// adopt, if any.
+func setForeignResourceCondition(skyflo *skyflov1.SkyfloAI, errs []error) {
+	var conflicts []string
+	for _, err := range errs {
+		var foreign *foreignResourceError
+		if errors.As(err, &foreign) {
+			conflicts = append(conflicts, foreign.kind+" "+foreign.name)
+		}
+	}
+	if len(conflicts) == 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "ForeignResourceConflict")
+		return
+	}
+
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "ForeignResourceConflict",
+		Status: metav1.ConditionTrue,
+		Reason: "ResourceNotOwned",
+		Message: fmt.Sprintf("refusing to adopt %s; annotate with %s: \"true\" to adopt",
+			strings.Join(conflicts, ", "), adoptAnnotation),
+		ObservedGeneration: skyflo.Generation,
+	})
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestForeignResourceConflict(t *testing.T) {
+	ctx := context.Background()
+	foreign := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "other"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "other", Image: "other/app:1"}}},
+			},
+		},
+	}
+	r := newTestReconciler([]client.Object{testSkyfloAI(), foreign})
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("Reconcile adopted a foreign Deployment without error")
+	}
+
+	engine := &appsv1.Deployment{}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	if err := r.Get(ctx, key, engine); err != nil {
+		t.Fatal(err)
+	}
+	if image := engine.Spec.Template.Spec.Containers[0].Image; image != "other/app:1" {
+		t.Errorf("foreign Deployment image = %q, want it untouched", image)
+	}
+	if owner := metav1.GetControllerOf(engine); owner != nil {
+		t.Errorf("foreign Deployment gained controller %s", owner.Name)
+	}
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, req.NamespacedName, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(skyflo.Status.Conditions, "ForeignResourceConflict")
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Message != `refusing to adopt Deployment skyflo-engine; annotate with skyflo.ai/adopt: "true" to adopt` {
+		t.Errorf("ForeignResourceConflict = %+v, want True naming the Engine Deployment", condition)
+	}
+
+	engine.Annotations = map[string]string{adoptAnnotation: "true"}
+	if err := r.Update(ctx, engine); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile after opting into adoption: %v", err)
+	}
+	if err := r.Get(ctx, key, engine); err != nil {
+		t.Fatal(err)
+	}
+	if image := engine.Spec.Template.Spec.Containers[0].Image; image != "skyflo/engine:test" {
+		t.Errorf("adopted Deployment image = %q, want skyflo/engine:test", image)
+	}
+	if owner := metav1.GetControllerOf(engine); owner == nil || owner.UID != "skyflo-uid" {
+		t.Errorf("adopted Deployment controller = %v, want the SkyfloAI", owner)
+	}
+	if err := r.Get(ctx, req.NamespacedName, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(skyflo.Status.Conditions, "ForeignResourceConflict"); condition != nil {
+		t.Errorf("ForeignResourceConflict = %+v after adoption, want it removed", condition)
+	}
+}
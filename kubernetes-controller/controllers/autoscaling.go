@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	defaultTargetCPUUtilization = int32(80)
+
+	// defaultScaleDownStabilization keeps the autoscaler from scaling down
+	// on short traffic dips.
+	defaultScaleDownStabilization = int32(300)
+)
+
+// autoscaling returns the autoscaling settings of a component, or nil when
+// autoscaling is not configured or disabled.
+func autoscaling(c component) *skyflov1.AutoscalingSpec {
+	spec := c.spec.Autoscaling
+	if spec == nil || (spec.Enabled != nil && !*spec.Enabled) {
+		return nil
+	}
+	return spec
+}
+
+// reconcileAutoscaler maintains the HorizontalPodAutoscaler of a component,
+// removing it once autoscaling is disabled.
+func (r *SkyfloAIReconciler) reconcileAutoscaler(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component) error {
+	name := skyflo.Name + "-" + c.name
+	if autoscaling(c) == nil {
+		return r.deleteIfOwned(ctx, skyflo, &autoscalingv2.HorizontalPodAutoscaler{}, name)
+	}
+
+	hpa := r.autoscaler(skyflo, c)
+	if err := r.own(skyflo, hpa); err != nil {
+		return err
+	}
+	return r.createOrUpdateAutoscaler(ctx, hpa)
+}
+
+// autoscaler builds the HorizontalPodAutoscaler of a component. The
+// replica bounds respect the cluster-wide replica cap.
+func (r *SkyfloAIReconciler) autoscaler(skyflo *skyflov1.SkyfloAI, c component) *autoscalingv2.HorizontalPodAutoscaler {
+	spec := autoscaling(c)
+	maxReplicas, _ := r.maxReplicas(spec)
+	minReplicas := spec.MinReplicas
+	if minReplicas != nil && *minReplicas > maxReplicas {
+		minReplicas = &maxReplicas
+	}
+
+	behavior := spec.Behavior
+	if behavior == nil {
+		behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &autoscalingv2.HPAScalingRules{
+				StabilizationWindowSeconds: ptr.To(defaultScaleDownStabilization),
+			},
+		}
+	}
+
+	target := defaultTargetCPUUtilization
+	if spec.TargetCPUUtilizationPercentage != nil {
+		target = *spec.TargetCPUUtilizationPercentage
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      skyflo.Name + "-" + c.name,
+			Namespace: skyflo.Namespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       deploymentName(skyflo, c.name, selectorVersion(skyflo)),
+			},
+			MinReplicas: minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: &target,
+						},
+					},
+				},
+			},
+			Behavior: behavior,
+		},
+	}
+}
+
+// maxReplicas returns the autoscaler's upper replica bound under the
+// cluster-wide replica cap, and whether the cap lowered it.
+func (r *SkyfloAIReconciler) maxReplicas(spec *skyflov1.AutoscalingSpec) (int32, bool) {
+	return r.capReplicas(spec.MaxReplicas)
+}
+
+// keepScaledReplicas leaves the replica count of an autoscaled component's
+// Deployment to the autoscaler, and that of a component ignoring external
+// scaling to whoever scaled it, so updates do not undo scaling. Either count
+// stays within the cluster-wide replica cap.
+func (r *SkyfloAIReconciler) keepScaledReplicas(ctx context.Context, c component, deployment *appsv1.Deployment) error {
+	spec := autoscaling(c)
+	if spec == nil && c.spec.ReplicaReconcilePolicy != skyflov1.ReplicaReconcilePolicyIgnoreExternal {
+		return nil
+	}
+
+	current := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, current)
+	if errors.IsNotFound(err) {
+		if spec == nil {
+			return nil
+		}
+		if minReplicas := spec.MinReplicas; minReplicas != nil {
+			seeded, _ := r.capReplicas(*minReplicas)
+			deployment.Spec.Replicas = ptr.To(seeded)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Spec.Replicas == nil {
+		deployment.Spec.Replicas = nil
+		return nil
+	}
+	replicas, clamped := r.capReplicas(*current.Spec.Replicas)
+	if clamped {
+		log.FromContext(ctx).Info("scaling back to the cluster replica cap", "deployment", deployment.Name,
+			"replicas", *current.Spec.Replicas, "cap", replicas)
+	}
+	deployment.Spec.Replicas = ptr.To(replicas)
+	return nil
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateAutoscaler(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	found := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.Get(ctx, types.NamespacedName{Name: hpa.Name, Namespace: hpa.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, hpa)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, hpa, "HorizontalPodAutoscaler"); err != nil {
+		return err
+	}
+
+	hpa.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, hpa)
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestReplicaCap(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.Replicas = ptr.To[int32](5)
+	skyflo.Spec.Engine.Autoscaling = &skyflov1.AutoscalingSpec{MinReplicas: ptr.To[int32](4), MaxReplicas: 10}
+	skyflo.Spec.MCP.Replicas = ptr.To[int32](2)
+	r := newTestReconciler([]client.Object{skyflo})
+	r.MaxReplicasPerComponent = 3
+	reconcileOnce(t, r)
+
+	want := map[string]int32{"skyflo-ui": 3, "skyflo-engine": 3, "skyflo-mcp": 2}
+	for name, replicas := range want {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, deployment); err != nil {
+			t.Fatal(err)
+		}
+		if got := ptr.Deref(deployment.Spec.Replicas, 0); got != replicas {
+			t.Errorf("%s replicas = %d, want %d", name, got, replicas)
+		}
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, hpa); err != nil {
+		t.Fatal(err)
+	}
+	if hpa.Spec.MaxReplicas != 3 || ptr.Deref(hpa.Spec.MinReplicas, 0) != 3 {
+		t.Errorf("autoscaler bounds = %d-%d, want 3-3", ptr.Deref(hpa.Spec.MinReplicas, 0), hpa.Spec.MaxReplicas)
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, "ReplicaCapApplied")
+	if condition == nil || condition.Status != metav1.ConditionTrue ||
+		condition.Message != "replicas clamped to the cluster cap of 3 for: UI, Engine" {
+		t.Errorf("ReplicaCapApplied = %+v, want True naming the UI and Engine", condition)
+	}
+}
+
+func TestReplicaCapExternalScale(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.MCP.ReplicaReconcilePolicy = skyflov1.ReplicaReconcilePolicyIgnoreExternal
+	r := newTestReconciler([]client.Object{skyflo})
+	r.MaxReplicasPerComponent = 3
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-mcp"}
+	mcp := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, mcp); err != nil {
+		t.Fatal(err)
+	}
+	mcp.Spec.Replicas = ptr.To[int32](8)
+	if err := r.Update(ctx, mcp); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	if err := r.Get(ctx, key, mcp); err != nil {
+		t.Fatal(err)
+	}
+	if got := ptr.Deref(mcp.Spec.Replicas, 0); got != 3 {
+		t.Errorf("externally scaled MCP replicas = %d, want the cap of 3", got)
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(got.Status.Conditions, "ReplicaCapApplied"); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("ReplicaCapApplied = %+v, want False when the spec is within the cap", condition)
+	}
+}
+
+func TestAutoscalerBehavior(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Autoscaling = &skyflov1.AutoscalingSpec{MaxReplicas: 5}
+
+	hpa := r.autoscaler(skyflo, components(skyflo)[1])
+	if hpa.Spec.Behavior == nil || hpa.Spec.Behavior.ScaleDown == nil ||
+		ptr.Deref(hpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds, 0) != defaultScaleDownStabilization {
+		t.Errorf("default behavior = %+v, want a %ds scale-down stabilization window", hpa.Spec.Behavior, defaultScaleDownStabilization)
+	}
+	if hpa.Spec.Behavior != nil && hpa.Spec.Behavior.ScaleUp != nil {
+		t.Errorf("default scale-up = %+v, want the autoscaler's own default", hpa.Spec.Behavior.ScaleUp)
+	}
+	if target := hpa.Spec.Metrics[0].Resource.Target.AverageUtilization; ptr.Deref(target, 0) != defaultTargetCPUUtilization {
+		t.Errorf("CPU target = %v, want %d", target, defaultTargetCPUUtilization)
+	}
+
+	behavior := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp: &autoscalingv2.HPAScalingRules{
+			StabilizationWindowSeconds: ptr.To[int32](60),
+			Policies: []autoscalingv2.HPAScalingPolicy{
+				{Type: autoscalingv2.PodsScalingPolicy, Value: 2, PeriodSeconds: 60},
+			},
+		},
+	}
+	skyflo.Spec.Engine.Autoscaling.Behavior = behavior
+	hpa = r.autoscaler(skyflo, components(skyflo)[1])
+	if !equality.Semantic.DeepEqual(hpa.Spec.Behavior, behavior) {
+		t.Errorf("behavior = %+v, want %+v", hpa.Spec.Behavior, behavior)
+	}
+}
+
+func TestReplicaReconcilePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy skyflov1.ReplicaReconcilePolicy
+		want   int32
+	}{
+		{name: "default", want: 2},
+		{name: "enforce", policy: skyflov1.ReplicaReconcilePolicyEnforce, want: 2},
+		{name: "ignore external", policy: skyflov1.ReplicaReconcilePolicyIgnoreExternal, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.Replicas = ptr.To[int32](2)
+			skyflo.Spec.Engine.ReplicaReconcilePolicy = tt.policy
+			r := newTestReconciler([]client.Object{skyflo})
+			reconcileOnce(t, r)
+
+			key := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+			engine := &appsv1.Deployment{}
+			if err := r.Get(ctx, key, engine); err != nil {
+				t.Fatal(err)
+			}
+			if got := ptr.Deref(engine.Spec.Replicas, 0); got != 2 {
+				t.Fatalf("created Engine replicas = %d, want the spec's 2", got)
+			}
+
+			engine.Spec.Replicas = ptr.To[int32](5)
+			if err := r.Update(ctx, engine); err != nil {
+				t.Fatal(err)
+			}
+			reconcileOnce(t, r)
+			if err := r.Get(ctx, key, engine); err != nil {
+				t.Fatal(err)
+			}
+			if got := ptr.Deref(engine.Spec.Replicas, 0); got != tt.want {
+				t.Errorf("externally scaled Engine replicas = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoscalingToggle(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Replicas = ptr.To[int32](2)
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	engine := &appsv1.Deployment{}
+	scale := func(replicas int32) {
+		t.Helper()
+		if err := r.Get(ctx, engineKey, engine); err != nil {
+			t.Fatal(err)
+		}
+		engine.Spec.Replicas = ptr.To(replicas)
+		if err := r.Update(ctx, engine); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engineReplicas := func() int32 {
+		t.Helper()
+		if err := r.Get(ctx, engineKey, engine); err != nil {
+			t.Fatal(err)
+		}
+		return ptr.Deref(engine.Spec.Replicas, 0)
+	}
+	update := func(mutate func(*skyflov1.EngineSpec)) {
+		t.Helper()
+		got := &skyflov1.SkyfloAI{}
+		if err := r.Get(ctx, key, got); err != nil {
+			t.Fatal(err)
+		}
+		mutate(&got.Spec.Engine)
+		if err := r.Update(ctx, got); err != nil {
+			t.Fatal(err)
+		}
+		reconcileOnce(t, r)
+	}
+
+	if err := r.Get(ctx, engineKey, &autoscalingv2.HorizontalPodAutoscaler{}); !errors.IsNotFound(err) {
+		t.Fatalf("Engine HorizontalPodAutoscaler before enabling autoscaling: %v", err)
+	}
+
+	// Enabled, the autoscaler owns the replica count.
+	update(func(spec *skyflov1.EngineSpec) {
+		spec.Replicas = nil
+		spec.Autoscaling = &skyflov1.AutoscalingSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 6}
+	})
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, engineKey, hpa); err != nil {
+		t.Fatalf("Engine HorizontalPodAutoscaler: %v", err)
+	}
+	if hpa.Spec.MaxReplicas != 6 || hpa.Spec.ScaleTargetRef.Name != "skyflo-engine" {
+		t.Errorf("Engine HPA = max %d targeting %s, want max 6 targeting skyflo-engine", hpa.Spec.MaxReplicas, hpa.Spec.ScaleTargetRef.Name)
+	}
+	scale(4)
+	reconcileOnce(t, r)
+	if got := engineReplicas(); got != 4 {
+		t.Errorf("autoscaled Engine replicas = %d, want the autoscaler's 4 kept", got)
+	}
+
+	// Disabled, the HPA goes and the controller manages replicas again.
+	update(func(spec *skyflov1.EngineSpec) {
+		spec.Replicas = ptr.To[int32](3)
+		spec.Autoscaling.Enabled = ptr.To(false)
+	})
+	if err := r.Get(ctx, engineKey, &autoscalingv2.HorizontalPodAutoscaler{}); !errors.IsNotFound(err) {
+		t.Errorf("Engine HorizontalPodAutoscaler kept after disabling autoscaling: %v", err)
+	}
+	if got := engineReplicas(); got != 3 {
+		t.Errorf("Engine replicas = %d after disabling autoscaling, want the spec's 3", got)
+	}
+	scale(5)
+	reconcileOnce(t, r)
+	if got := engineReplicas(); got != 3 {
+		t.Errorf("externally scaled Engine replicas = %d, want them managed back to 3", got)
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// migrateToAutoscalingAnnotation, set to "true" on a SkyfloAI, seeds an
+// autoscaling block on every component without one and is then removed, so
+// the migration runs once.
+const migrateToAutoscalingAnnotation = "skyflo.ai/migrate-to-autoscaling"
+
+// migratedTargetCPUUtilization is the CPU target of seeded autoscaling
+// blocks.
+const migratedTargetCPUUtilization = int32(70)
+
+// migrateToAutoscaling converts the fixed replica counts of a SkyfloAI
+// annotated for migration into autoscaling blocks: minReplicas is the
+// current replica count, maxReplicas twice that and the CPU target 70%, and
+// replicas is cleared so it does not compete with the autoscaler.
+// Components that already have an autoscaling block are left as they are.
+// The seeded spec and the removal of the annotation are written in a single
+// patch, and skyflo is updated so the rest of the reconcile applies them.
+func (r *SkyfloAIReconciler) migrateToAutoscaling(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	if skyflo.Annotations[migrateToAutoscalingAnnotation] != "true" {
+		return nil
+	}
+
+	patched := skyflo.DeepCopy()
+	var migrated []string
+	for _, c := range components(patched) {
+		if c.spec.Autoscaling != nil {
+			continue
+		}
+		minReplicas := ptr.Deref(c.spec.Replicas, 1)
+		if minReplicas < 1 {
+			minReplicas = 1
+		}
+		c.spec.Autoscaling = &skyflov1.AutoscalingSpec{
+			MinReplicas:                    ptr.To(minReplicas),
+			MaxReplicas:                    2 * minReplicas,
+			TargetCPUUtilizationPercentage: ptr.To(migratedTargetCPUUtilization),
+		}
+		c.spec.Replicas = nil
+		migrated = append(migrated, c.displayName)
+	}
+	delete(patched.Annotations, migrateToAutoscalingAnnotation)
+
+	if err := r.Patch(ctx, patched, client.MergeFrom(skyflo), r.fieldOwner()); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("migrated components to autoscaling", "components", migrated)
+	skyflo.Annotations = patched.Annotations
+	skyflo.Spec = patched.Spec
+	skyflo.Generation = patched.Generation
+	skyflo.ResourceVersion = patched.ResourceVersion
+	return nil
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestMigrateToAutoscaling(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Annotations = map[string]string{migrateToAutoscalingAnnotation: "true"}
+	skyflo.Spec.Engine.Replicas = ptr.To[int32](3)
+	skyflo.Spec.UI.Autoscaling = &skyflov1.AutoscalingSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 4}
+	// patches counts the migration patches, leaving out the finalizer one.
+	var patches int
+	r := newTestReconciler([]client.Object{skyflo}, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*skyflov1.SkyfloAI); ok {
+				data, err := patch.Data(obj)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(string(data), "autoscaling") {
+					patches++
+				}
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	reconcileOnce(t, r)
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Annotations[migrateToAutoscalingAnnotation]; ok {
+		t.Errorf("%s kept after the migration", migrateToAutoscalingAnnotation)
+	}
+	seeded := map[string]struct {
+		spec     skyflov1.ComponentSpec
+		min, max int32
+	}{
+		"engine": {got.Spec.Engine.ComponentSpec, 3, 6},
+		"mcp":    {got.Spec.MCP.ComponentSpec, 1, 2},
+	}
+	for name, s := range seeded {
+		as := s.spec.Autoscaling
+		if as == nil {
+			t.Errorf("%s: no autoscaling seeded", name)
+			continue
+		}
+		if ptr.Deref(as.MinReplicas, 0) != s.min || as.MaxReplicas != s.max || ptr.Deref(as.TargetCPUUtilizationPercentage, 0) != 70 {
+			t.Errorf("%s: autoscaling = min %v max %d cpu %v, want min %d max %d cpu 70",
+				name, as.MinReplicas, as.MaxReplicas, as.TargetCPUUtilizationPercentage, s.min, s.max)
+		}
+		if s.spec.Replicas != nil {
+			t.Errorf("%s: replicas = %d, want it cleared", name, *s.spec.Replicas)
+		}
+	}
+	if ui := got.Spec.UI.Autoscaling; ptr.Deref(ui.MinReplicas, 0) != 2 || ui.MaxReplicas != 4 || ui.TargetCPUUtilizationPercentage != nil {
+		t.Errorf("UI autoscaling = %+v, want it left as configured", ui)
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, hpa); err != nil {
+		t.Fatalf("Engine HorizontalPodAutoscaler not created by the migrating reconcile: %v", err)
+	}
+	if ptr.Deref(hpa.Spec.MinReplicas, 0) != 3 || hpa.Spec.MaxReplicas != 6 {
+		t.Errorf("Engine HPA = min %v max %d, want 3 and 6", hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+
+	// The seeded spec is the user's from now on: a later edit is kept.
+	got.Spec.Engine.Autoscaling.MaxReplicas = 10
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	reconcileOnce(t, r)
+	if patches != 1 {
+		t.Errorf("SkyfloAI patched %d times, want the migration once", patches)
+	}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Engine.Autoscaling.MaxReplicas != 10 {
+		t.Errorf("Engine maxReplicas = %d, want the edited 10", got.Spec.Engine.Autoscaling.MaxReplicas)
+	}
+
+	// Annotating again once every component autoscales changes nothing.
+	before := got.Spec.DeepCopy()
+	got.Annotations = map[string]string{migrateToAutoscalingAnnotation: "true"}
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Annotations[migrateToAutoscalingAnnotation]; ok {
+		t.Errorf("%s kept after the second migration", migrateToAutoscalingAnnotation)
+	}
+	for name, as := range map[string][2]*skyflov1.AutoscalingSpec{
+		"ui":     {before.UI.Autoscaling, got.Spec.UI.Autoscaling},
+		"engine": {before.Engine.Autoscaling, got.Spec.Engine.Autoscaling},
+		"mcp":    {before.MCP.Autoscaling, got.Spec.MCP.Autoscaling},
+	} {
+		if as[0].MaxReplicas != as[1].MaxReplicas || ptr.Deref(as[0].MinReplicas, 0) != ptr.Deref(as[1].MinReplicas, 0) {
+			t.Errorf("%s: autoscaling changed by a repeated migration: %+v to %+v", name, as[0], as[1])
+		}
+	}
+}
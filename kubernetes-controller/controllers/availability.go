@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// setAvailabilityConditions summarizes the component statuses in the
+// standard Available, Progressing and Degraded conditions. failed lists the
+// components that failed to reconcile.
+//
+// A component is degraded when it failed to reconcile, or when it has no
+// ready replica left after having rolled out before. Every other component
+// short of Ready is progressing.
+func setAvailabilityConditions(skyflo *skyflov1.SkyfloAI, failed []string) {
+	var notReady, progressing, degraded []string
+	for _, c := range components(skyflo) {
+		status, _ := progressStatuses(skyflo, &skyflo.Status, c)
+		if status.Phase == "Ready" {
+			continue
+		}
+		notReady = append(notReady, c.displayName)
+		if status.Phase == "WarmupFailed" {
+			degraded = append(degraded, fmt.Sprintf("%s %s", c.displayName, status.Message))
+			continue
+		}
+		if status.RolledOutImage != "" && status.ReadyReplicas == 0 && status.DesiredReplicas > 0 {
+			degraded = append(degraded, fmt.Sprintf("%s has no ready replicas", c.displayName))
+			continue
+		}
+		progressing = append(progressing, fmt.Sprintf("%s %d/%d", c.displayName, status.ReadyReplicas, status.DesiredReplicas))
+	}
+	for _, name := range failed {
+		degraded = append(degraded, fmt.Sprintf("%s failed to reconcile", name))
+	}
+
+	available := metav1.Condition{
+		Type:               "Available",
+		Status:             metav1.ConditionTrue,
+		Reason:             "ComponentsReady",
+		Message:            "every component is ready",
+		ObservedGeneration: skyflo.Generation,
+	}
+	if len(notReady) > 0 {
+		available.Status = metav1.ConditionFalse
+		available.Reason = "ComponentsNotReady"
+		available.Message = fmt.Sprintf("not ready: %s", strings.Join(notReady, ", "))
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, available)
+
+	rollout := metav1.Condition{
+		Type:               "Progressing",
+		Status:             metav1.ConditionFalse,
+		Reason:             "RolloutComplete",
+		Message:            "no component is rolling out",
+		ObservedGeneration: skyflo.Generation,
+	}
+	if len(progressing) > 0 {
+		rollout.Status = metav1.ConditionTrue
+		rollout.Reason = "RollingOut"
+		rollout.Message = fmt.Sprintf("rolling out: %s", strings.Join(progressing, ", "))
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, rollout)
+
+	health := metav1.Condition{
+		Type:               "Degraded",
+		Status:             metav1.ConditionFalse,
+		Reason:             "ComponentsHealthy",
+		Message:            "no component is degraded",
+		ObservedGeneration: skyflo.Generation,
+	}
+	if len(degraded) > 0 {
+		health.Status = metav1.ConditionTrue
+		health.Reason = "ComponentDegraded"
+		if len(failed) > 0 {
+			health.Reason = "ReconcileFailed"
+		}
+		health.Message = strings.Join(degraded, "; ")
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, health)
+}
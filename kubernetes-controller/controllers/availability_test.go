@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// availability returns the Available, Progressing and Degraded conditions
+// of the test SkyfloAI.
+func availability(t *testing.T, r *SkyfloAIReconciler) (available, progressing, degraded *metav1.Condition) {
+	t.Helper()
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo"}, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	conditions := skyflo.Status.Conditions
+	available = meta.FindStatusCondition(conditions, "Available")
+	progressing = meta.FindStatusCondition(conditions, "Progressing")
+	degraded = meta.FindStatusCondition(conditions, "Degraded")
+	if available == nil || progressing == nil || degraded == nil {
+		t.Fatalf("conditions = %+v, want Available, Progressing and Degraded", conditions)
+	}
+	return available, progressing, degraded
+}
+
+// wantCondition reports a condition whose status or reason differs.
+func wantCondition(t *testing.T, cond *metav1.Condition, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	if cond.Status != status || cond.Reason != reason {
+		t.Errorf("%s = %s/%s (%s), want %s/%s", cond.Type, cond.Status, cond.Reason, cond.Message, status, reason)
+	}
+}
+
+func TestAvailabilityConditions(t *testing.T) {
+	ctx := context.Background()
+	failEngine := true
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*appsv1.Deployment); ok && obj.GetName() == "skyflo-engine" && failEngine {
+				return apierrors.NewInternalError(fmt.Errorf("injected"))
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+
+	// A component that fails to reconcile degrades the stack.
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Fatal("Reconcile succeeded despite the failed Engine Deployment")
+	}
+	available, _, degraded := availability(t, r)
+	wantCondition(t, available, metav1.ConditionFalse, "ComponentsNotReady")
+	wantCondition(t, degraded, metav1.ConditionTrue, "ReconcileFailed")
+	if !strings.Contains(degraded.Message, "Engine failed to reconcile") {
+		t.Errorf("Degraded message = %q, want it to name the Engine", degraded.Message)
+	}
+
+	// Created but not yet ready components are progressing.
+	failEngine = false
+	reconcileOnce(t, r)
+	available, progressing, degraded := availability(t, r)
+	wantCondition(t, available, metav1.ConditionFalse, "ComponentsNotReady")
+	wantCondition(t, progressing, metav1.ConditionTrue, "RollingOut")
+	wantCondition(t, degraded, metav1.ConditionFalse, "ComponentsHealthy")
+
+	markReady(t, r, "skyflo-ui", "skyflo-engine", "skyflo-mcp")
+	reconcileOnce(t, r)
+	available, progressing, degraded = availability(t, r)
+	wantCondition(t, available, metav1.ConditionTrue, "ComponentsReady")
+	wantCondition(t, progressing, metav1.ConditionFalse, "RolloutComplete")
+	wantCondition(t, degraded, metav1.ConditionFalse, "ComponentsHealthy")
+
+	// Repeated reconciles keep the transition time of unchanged conditions.
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	since := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	meta.FindStatusCondition(skyflo.Status.Conditions, "Available").LastTransitionTime = since
+	if err := r.Status().Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	reconcileOnce(t, r)
+	available, _, _ = availability(t, r)
+	if !available.LastTransitionTime.Equal(&since) {
+		t.Errorf("Available lastTransitionTime = %v after unchanged reconciles, want %v", available.LastTransitionTime, since)
+	}
+
+	// A rolled out component left without ready replicas is degraded.
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	engine.Status.ReadyReplicas = 0
+	engine.Status.AvailableReplicas = 0
+	if err := r.Status().Update(ctx, engine); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	available, _, degraded = availability(t, r)
+	wantCondition(t, available, metav1.ConditionFalse, "ComponentsNotReady")
+	wantCondition(t, degraded, metav1.ConditionTrue, "ComponentDegraded")
+	if !strings.Contains(degraded.Message, "Engine has no ready replicas") {
+		t.Errorf("Degraded message = %q, want it to name the Engine", degraded.Message)
+	}
+	if available.LastTransitionTime.Equal(&since) {
+		t.Errorf("Available lastTransitionTime kept at %v after turning False", since)
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	boundTokenVolume            = "bound-token"
+	defaultBoundTokenMountPath  = "/var/run/secrets/skyflo.ai/serviceaccount"
+	defaultBoundTokenExpiration = int64(3600)
+)
+
+// addBoundToken disables the default service account token mount and mounts
+// a projected token bound to the configured audience instead.
+func addBoundToken(token *skyflov1.BoundTokenSpec, deployment *appsv1.Deployment) {
+	mountPath := token.MountPath
+	if mountPath == "" {
+		mountPath = defaultBoundTokenMountPath
+	}
+	expiration := defaultBoundTokenExpiration
+	if token.ExpirationSeconds != nil {
+		expiration = *token.ExpirationSeconds
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.AutomountServiceAccountToken = ptr.To(false)
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: boundTokenVolume,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          token.Audience,
+							ExpirationSeconds: &expiration,
+							Path:              "token",
+						},
+					},
+				},
+			},
+		},
+	})
+	container := &podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      boundTokenVolume,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	})
+}
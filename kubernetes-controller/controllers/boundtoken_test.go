@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestBoundToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          *skyflov1.BoundTokenSpec
+		wantExpiration int64
+		wantMountPath  string
+	}{
+		{
+			name:           "defaults",
+			token:          &skyflov1.BoundTokenSpec{Audience: "skyflo-engine"},
+			wantExpiration: 3600,
+			wantMountPath:  "/var/run/secrets/skyflo.ai/serviceaccount",
+		},
+		{
+			name:           "configured",
+			token:          &skyflov1.BoundTokenSpec{Audience: "skyflo-engine", ExpirationSeconds: ptr.To[int64](7200), MountPath: "/var/run/token"},
+			wantExpiration: 7200,
+			wantMountPath:  "/var/run/token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.BoundToken = tt.token
+			r := newTestReconciler(nil)
+
+			podSpec := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec
+			if podSpec.AutomountServiceAccountToken == nil || *podSpec.AutomountServiceAccountToken {
+				t.Errorf("automountServiceAccountToken = %v, want false", podSpec.AutomountServiceAccountToken)
+			}
+
+			var projection *corev1.ServiceAccountTokenProjection
+			for _, volume := range podSpec.Volumes {
+				if volume.Name == boundTokenVolume && volume.Projected != nil && len(volume.Projected.Sources) == 1 {
+					projection = volume.Projected.Sources[0].ServiceAccountToken
+				}
+			}
+			if projection == nil {
+				t.Fatalf("no projected service account token volume in %v", podSpec.Volumes)
+			}
+			if projection.Audience != "skyflo-engine" || ptr.Deref(projection.ExpirationSeconds, 0) != tt.wantExpiration || projection.Path != "token" {
+				t.Errorf("token projection = %s %d %s, want skyflo-engine %d token",
+					projection.Audience, ptr.Deref(projection.ExpirationSeconds, 0), projection.Path, tt.wantExpiration)
+			}
+
+			var mount *corev1.VolumeMount
+			for i, m := range podSpec.Containers[0].VolumeMounts {
+				if m.Name == boundTokenVolume {
+					mount = &podSpec.Containers[0].VolumeMounts[i]
+				}
+			}
+			if mount == nil || mount.MountPath != tt.wantMountPath || !mount.ReadOnly {
+				t.Errorf("token mount = %+v, want %s read-only", mount, tt.wantMountPath)
+			}
+		})
+	}
+}
+
+func TestBoundTokenEngineOnly(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.BoundToken = &skyflov1.BoundTokenSpec{Audience: "skyflo-engine"}
+	r := newTestReconciler(nil)
+	for _, c := range components(skyflo) {
+		if c.name == "engine" {
+			continue
+		}
+		podSpec := r.deployment(skyflo, c).Spec.Template.Spec
+		for _, volume := range podSpec.Volumes {
+			if volume.Name == boundTokenVolume {
+				t.Errorf("%s has the Engine bound token volume", c.name)
+			}
+		}
+		if !ptr.Deref(podSpec.AutomountServiceAccountToken, true) {
+			t.Errorf("%s has the service account token mount disabled", c.name)
+		}
+	}
+}
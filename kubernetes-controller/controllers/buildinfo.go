@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// buildInfoLabelPrefix prefixes the pod labels carrying build info, e.g.
+// GIT_SHA becomes build.skyflo.ai/git-sha.
+const buildInfoLabelPrefix = "build.skyflo.ai/"
+
+// invalidLabelChars matches the characters label values may not contain.
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// buildInfoEnv returns the build info as environment variables, in name
+// order.
+func buildInfoEnv(info map[string]string) []corev1.EnvVar {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]corev1.EnvVar, 0, len(names))
+	for _, name := range names {
+		env = append(env, corev1.EnvVar{Name: name, Value: info[name]})
+	}
+	return env
+}
+
+// addBuildInfoLabels labels the pod template with the build info. The
+// labels are not part of the selector, so build info changes never orphan
+// pods.
+func addBuildInfoLabels(info map[string]string, deployment *appsv1.Deployment) {
+	if len(info) == 0 {
+		return
+	}
+	labels := deployment.Spec.Template.Labels
+	for name, value := range info {
+		labels[buildInfoLabelPrefix+strings.ToLower(strings.ReplaceAll(name, "_", "-"))] = sanitizeLabelValue(value)
+	}
+}
+
+// sanitizeLabelValue turns an arbitrary value into a valid label value by
+// replacing invalid characters, truncating it to 63 characters and trimming
+// leading and trailing non-alphanumerics.
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(value, "._-")
+}
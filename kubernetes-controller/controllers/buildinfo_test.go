@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestBuildInfo(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.BuildInfo = map[string]string{
+		"GIT_SHA":  "3f2a9c1",
+		"BUILD_ID": "ci/build #42",
+	}
+	deployment := r.deployment(skyflo, components(skyflo)[1])
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	if got := envValue(env, "GIT_SHA"); got != "3f2a9c1" {
+		t.Errorf("GIT_SHA = %q, want 3f2a9c1", got)
+	}
+	if got := envValue(env, "BUILD_ID"); got != "ci/build #42" {
+		t.Errorf("BUILD_ID = %q, want the raw value", got)
+	}
+
+	labels := deployment.Spec.Template.Labels
+	want := map[string]string{"build.skyflo.ai/git-sha": "3f2a9c1", "build.skyflo.ai/build-id": "ci-build--42"}
+	for key, value := range want {
+		if labels[key] != value {
+			t.Errorf("label %s = %q, want %q", key, labels[key], value)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			t.Errorf("label name %s: %v", key, errs)
+		}
+	}
+	for key := range deployment.Spec.Selector.MatchLabels {
+		if strings.HasPrefix(key, buildInfoLabelPrefix) {
+			t.Errorf("selector includes build info label %s", key)
+		}
+	}
+}
+
+func TestSanitizeLabelValue(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{value: "v1.2.3", want: "v1.2.3"},
+		{value: "ci/build #42", want: "ci-build--42"},
+		{value: "-release_", want: "release"},
+		{value: strings.Repeat("a", 70), want: strings.Repeat("a", 63)},
+		{value: strings.Repeat("a", 62) + "/b", want: strings.Repeat("a", 62)},
+		{value: "///", want: ""},
+	}
+	for _, tt := range tests {
+		got := sanitizeLabelValue(tt.value)
+		if got != tt.want {
+			t.Errorf("sanitizeLabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if errs := validation.IsValidLabelValue(got); len(errs) > 0 {
+			t.Errorf("sanitizeLabelValue(%q) = %q is not a label value: %v", tt.value, got, errs)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// cleanupFinalizer holds a SkyfloAI's deletion until the cluster-scoped
+// objects created for it, which cannot carry an owner reference to a
+// namespaced object, have been deleted. Namespaced children are left to
+// garbage collection.
+const cleanupFinalizer = "skyflo.ai/cleanup"
+
+// legacyMCPClusterRBACFinalizer was only set while cluster-scoped MCP RBAC
+// was enabled. It is replaced by cleanupFinalizer on the next reconcile.
+const legacyMCPClusterRBACFinalizer = "skyflo.ai/mcp-cluster-rbac"
+
+// ensureCleanupFinalizer sets the cleanup finalizer on a SkyfloAI seen for
+// the first time, before anything cluster-scoped is created for it.
+func (r *SkyfloAIReconciler) ensureCleanupFinalizer(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	return r.setFinalizer(ctx, skyflo, true)
+}
+
+// finalize deletes the cluster-scoped objects of a SkyfloAI being deleted and
+// then releases its finalizer. Objects already gone are skipped, so a
+// finalization interrupted by an error is safely retried.
+func (r *SkyfloAIReconciler) finalize(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	if !controllerutil.ContainsFinalizer(skyflo, cleanupFinalizer) &&
+		!controllerutil.ContainsFinalizer(skyflo, legacyMCPClusterRBACFinalizer) {
+		return nil
+	}
+	if err := r.pruneClusterObjects(ctx, skyflo, skyflov1.PruningPolicyDelete); err != nil {
+		return err
+	}
+	return r.setFinalizer(ctx, skyflo, false)
+}
+
+// setFinalizer adds or removes the cleanup finalizer, dropping the legacy
+// one either way. It patches a copy so status recorded on skyflo so far is
+// kept for the status write.
+func (r *SkyfloAIReconciler) setFinalizer(ctx context.Context, skyflo *skyflov1.SkyfloAI, present bool) error {
+	patched := skyflo.DeepCopy()
+	changed := controllerutil.RemoveFinalizer(patched, legacyMCPClusterRBACFinalizer)
+	if present {
+		changed = controllerutil.AddFinalizer(patched, cleanupFinalizer) || changed
+	} else {
+		changed = controllerutil.RemoveFinalizer(patched, cleanupFinalizer) || changed
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.Patch(ctx, patched, client.MergeFrom(skyflo), r.fieldOwner()); err != nil {
+		return err
+	}
+	skyflo.Finalizers = patched.Finalizers
+	skyflo.ResourceVersion = patched.ResourceVersion
+	return nil
+}
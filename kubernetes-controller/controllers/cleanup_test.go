@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestCleanupFinalizer(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.MCP.ClusterRBAC = &skyflov1.MCPClusterRBACSpec{}
+	failRoleDelete := false
+	r := newTestReconciler([]client.Object{skyflo}, interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*rbacv1.ClusterRole); ok && failRoleDelete {
+				return apierrors.NewInternalError(fmt.Errorf("injected"))
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+	r.MCPClusterRBACNamespaces = []string{"default"}
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if !controllerutil.ContainsFinalizer(got, cleanupFinalizer) {
+		t.Fatalf("finalizers = %v after the first reconcile, want %s", got.Finalizers, cleanupFinalizer)
+	}
+	roleKey := types.NamespacedName{Name: "skyflo:default:skyflo-mcp"}
+	if err := r.Get(ctx, roleKey, &rbacv1.ClusterRole{}); err != nil {
+		t.Fatalf("MCP ClusterRole: %v", err)
+	}
+	if err := r.Get(ctx, roleKey, &rbacv1.ClusterRoleBinding{}); err != nil {
+		t.Fatalf("MCP ClusterRoleBinding: %v", err)
+	}
+
+	if err := r.Delete(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed delete keeps the finalizer, so the SkyfloAI stays.
+	failRoleDelete = true
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Fatal("Reconcile succeeded despite the failed ClusterRole delete")
+	}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatalf("SkyfloAI released after a failed cleanup: %v", err)
+	}
+	if got.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(got, cleanupFinalizer) {
+		t.Errorf("SkyfloAI deleting %v with finalizers %v, want it held by %s", got.DeletionTimestamp, got.Finalizers, cleanupFinalizer)
+	}
+	if err := r.Get(ctx, roleKey, &rbacv1.ClusterRoleBinding{}); !apierrors.IsNotFound(err) {
+		t.Errorf("MCP ClusterRoleBinding kept by the failed cleanup: %v", err)
+	}
+
+	// The retry skips the binding already gone and finishes.
+	failRoleDelete = false
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, roleKey, &rbacv1.ClusterRole{}); !apierrors.IsNotFound(err) {
+		t.Errorf("MCP ClusterRole kept after cleanup: %v", err)
+	}
+	if err := r.Get(ctx, key, got); !apierrors.IsNotFound(err) {
+		t.Errorf("SkyfloAI kept after cleanup, finalizers %v: %v", got.Finalizers, err)
+	}
+}
+
+func TestCleanupFinalizerReplacesLegacy(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Finalizers = []string{legacyMCPClusterRBACFinalizer}
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Finalizers) != 1 || got.Finalizers[0] != cleanupFinalizer {
+		t.Errorf("finalizers = %v, want only %s", got.Finalizers, cleanupFinalizer)
+	}
+}
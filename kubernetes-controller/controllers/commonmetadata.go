@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// managedBy is the app.kubernetes.io/managed-by value of every object the
+// controller creates.
+const managedBy = "skyflo-operator"
+
+// recommendedLabels returns the Kubernetes recommended labels of the objects
+// of a SkyfloAI, with the component label when component is set.
+func recommendedLabels(skyflo *skyflov1.SkyfloAI, component string) map[string]string {
+	labels := map[string]string{
+		"app.kubernetes.io/name":       "skyflo",
+		"app.kubernetes.io/instance":   skyflo.Name,
+		"app.kubernetes.io/managed-by": managedBy,
+	}
+	if component != "" {
+		labels["app.kubernetes.io/component"] = component
+	}
+	return labels
+}
+
+// commonLabels returns the labels every object of the SkyfloAI carries:
+// spec.commonLabels overlaid with the recommended labels, which the common
+// labels cannot override.
+func commonLabels(skyflo *skyflov1.SkyfloAI, component string) map[string]string {
+	return mergeMaps(skyflo.Spec.CommonLabels, recommendedLabels(skyflo, component))
+}
+
+// addCommonMetadata merges the common labels and spec.commonAnnotations onto
+// obj. Labels and annotations obj already carries take precedence, so
+// selector labels are never changed.
+func addCommonMetadata(skyflo *skyflov1.SkyfloAI, obj metav1.Object, component string) {
+	obj.SetLabels(mergeMaps(commonLabels(skyflo, component), obj.GetLabels()))
+	obj.SetAnnotations(mergeMaps(skyflo.Spec.CommonAnnotations, obj.GetAnnotations()))
+}
+
+// own makes the SkyfloAI the controller of obj and adds the common metadata
+// to it.
+func (r *SkyfloAIReconciler) own(skyflo *skyflov1.SkyfloAI, obj client.Object) error {
+	addCommonMetadata(skyflo, obj, "")
+	return controllerutil.SetControllerReference(skyflo, obj, r.Scheme)
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// ownedObjects returns every object of the listed kinds that the test
+// SkyfloAI controls or, for cluster-scoped ones, owns through labels.
+func ownedObjects(t *testing.T, r *SkyfloAIReconciler, skyflo *skyflov1.SkyfloAI) []client.Object {
+	t.Helper()
+	var owned []client.Object
+	lists := []client.ObjectList{
+		&appsv1.DeploymentList{}, &corev1.ServiceList{}, &corev1.ServiceAccountList{},
+		&autoscalingv2.HorizontalPodAutoscalerList{}, &policyv1.PodDisruptionBudgetList{},
+		&rbacv1.ClusterRoleList{}, &rbacv1.ClusterRoleBindingList{},
+	}
+	for _, list := range lists {
+		if err := r.List(context.Background(), list); err != nil {
+			t.Fatal(err)
+		}
+		err := meta.EachListItem(list, func(item runtime.Object) error {
+			obj := item.(client.Object)
+			if metav1.IsControlledBy(obj, skyflo) || ownsClusterObject(skyflo, obj) {
+				owned = append(owned, obj)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return owned
+}
+
+// deploymentSelectors returns the selector of each component Deployment.
+func deploymentSelectors(t *testing.T, r *SkyfloAIReconciler) map[string]*metav1.LabelSelector {
+	t.Helper()
+	selectors := map[string]*metav1.LabelSelector{}
+	for _, name := range []string{"skyflo-ui", "skyflo-engine", "skyflo-mcp"} {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, deployment); err != nil {
+			t.Fatal(err)
+		}
+		selectors[name] = deployment.Spec.Selector
+	}
+	return selectors
+}
+
+func TestCommonMetadata(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Autoscaling = &skyflov1.AutoscalingSpec{MaxReplicas: 3}
+	skyflo.Spec.UI.PodDisruptionBudget = &skyflov1.PodDisruptionBudgetSpec{}
+	skyflo.Spec.MCP.ClusterRBAC = &skyflov1.MCPClusterRBACSpec{}
+	r := newTestReconciler([]client.Object{skyflo})
+	r.MCPClusterRBACNamespaces = []string{"default"}
+	reconcileOnce(t, r)
+	before := deploymentSelectors(t, r)
+
+	// Add common metadata to the running stack.
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.CommonLabels = map[string]string{"team": "platform", "app.kubernetes.io/instance": "override"}
+	got.Spec.CommonAnnotations = map[string]string{"example.com/owner": "platform"}
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	owned := ownedObjects(t, r, got)
+	if len(owned) < 9 {
+		t.Fatalf("found %d owned objects, want at least the Deployments, Services, HPA, PDB and MCP RBAC", len(owned))
+	}
+	for _, obj := range owned {
+		name := reflect.TypeOf(obj).Elem().Name() + " " + obj.GetName()
+		labels := obj.GetLabels()
+		if labels["team"] != "platform" {
+			t.Errorf("%s labels = %v, want team=platform", name, labels)
+		}
+		if labels["app.kubernetes.io/instance"] != "skyflo" {
+			t.Errorf("%s instance label = %q, want the common labels not to override it", name, labels["app.kubernetes.io/instance"])
+		}
+		if obj.GetAnnotations()["example.com/owner"] != "platform" {
+			t.Errorf("%s annotations = %v, want example.com/owner=platform", name, obj.GetAnnotations())
+		}
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			template := deployment.Spec.Template
+			if template.Labels["team"] != "platform" || template.Annotations["example.com/owner"] != "platform" {
+				t.Errorf("%s pod template metadata = %v %v, want the common metadata", name, template.Labels, template.Annotations)
+			}
+		}
+	}
+
+	if after := deploymentSelectors(t, r); !reflect.DeepEqual(after, before) {
+		t.Errorf("Deployment selectors changed by common labels: %v, then %v", before, after)
+	}
+
+	// Changing the common labels again still leaves the selectors alone.
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.CommonLabels = map[string]string{"team": "search"}
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if after := deploymentSelectors(t, r); !reflect.DeepEqual(after, before) {
+		t.Errorf("Deployment selectors changed by new common labels: %v, then %v", before, after)
+	}
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Labels["team"] != "search" || engine.Spec.Template.Labels["team"] != "search" {
+		t.Errorf("Engine labels = %v, template %v, want team=search", engine.Labels, engine.Spec.Template.Labels)
+	}
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// component describes one workload of a SkyfloAI stack: a Deployment and a
+// Service built from a ComponentSpec. The UI, Engine and MCP map their typed
+// specs onto it, and every entry of spec.components becomes one as well, so
+// the reconcile loop is the same for all of them.
+type component struct {
+	// name is the container name and the suffix of the Deployment and Service
+	name string
+
+	// displayName is used in logs and condition messages
+	displayName string
+
+	// port is the container port, exposed by the Service on port 80
+	port int32
+
+	// targetPort, when set, is the pod port the Service targets instead of
+	// port, e.g. a sidecar fronting the container
+	targetPort int32
+
+	spec *skyflov1.ComponentSpec
+
+	// env returns the environment variables the controller derives for the
+	// component, given the replica count its pods are sized for
+	env func(replicas int32) []corev1.EnvVar
+
+	// decorate, when set, adjusts the generated Deployment
+	decorate func(deployment *appsv1.Deployment)
+
+	// decorateService, when set, adjusts the generated Service
+	decorateService func(service *corev1.Service)
+
+	// serviceAnnotations are added to the component Service
+	serviceAnnotations map[string]string
+
+	// noService drops the container port and the component Service, for
+	// components that take no inbound traffic
+	noService bool
+
+	// custom marks components declared in spec.components
+	custom bool
+}
+
+// components returns the components of the stack in reconcile order.
+func components(skyflo *skyflov1.SkyfloAI) []component {
+	ui := component{
+		name:        "ui",
+		displayName: "UI",
+		port:        3000,
+		spec:        &skyflo.Spec.UI.ComponentSpec,
+	}
+	if !uiIngressEnabled(skyflo) {
+		ui.serviceAnnotations = externalDNSAnnotations(skyflo.Spec.UI)
+	}
+	ui.decorate = func(deployment *appsv1.Deployment) {
+		addDefaultProbes(deployment, uiHealthPath, uiHealthPath)
+		if len(skyflo.Spec.UI.SecurityHeaders) > 0 {
+			addSecurityHeadersProxy(skyflo, deployment)
+		}
+	}
+	if len(skyflo.Spec.UI.SecurityHeaders) > 0 {
+		ui.targetPort = securityHeadersPort
+	}
+	if lb := skyflo.Spec.UI.LoadBalancer; lb != nil {
+		ui.decorateService = func(service *corev1.Service) {
+			service.Spec.Type = corev1.ServiceTypeLoadBalancer
+			service.Spec.ExternalTrafficPolicy = lb.ExternalTrafficPolicy
+			service.Spec.HealthCheckNodePort = ptr.Deref(lb.HealthCheckNodePort, 0)
+		}
+	}
+
+	engine := component{
+		name:        "engine",
+		displayName: "Engine",
+		port:        8081,
+		spec:        &skyflo.Spec.Engine.ComponentSpec,
+		env: func(replicas int32) []corev1.EnvVar {
+			return engineEnv(skyflo, replicas)
+		},
+		decorate: func(deployment *appsv1.Deployment) {
+			deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = engineTerminationGracePeriod(skyflo.Spec.Engine)
+			addEngineProbes(skyflo.Spec.Engine, deployment)
+			if deadlock := skyflo.Spec.Engine.DeadlockDetection; deadlock != nil && skyflo.Spec.Engine.LivenessProbe == nil {
+				deployment.Spec.Template.Spec.Containers[0].LivenessProbe = deadlockProbe(deadlock)
+			}
+			if shm := skyflo.Spec.Engine.SharedMemory; shm != nil {
+				addSharedMemory(*shm, deployment)
+			}
+			if skyflo.Spec.Engine.Storage != nil {
+				addEngineStorage(skyflo, deployment)
+			}
+			if skyflo.Spec.Engine.BoundToken != nil {
+				addBoundToken(skyflo.Spec.Engine.BoundToken, deployment)
+			}
+			if scrapeEngine(skyflo) {
+				addMetricsPort(skyflo, deployment)
+			}
+		},
+		decorateService: func(service *corev1.Service) {
+			if scrapeEngine(skyflo) && !skyflo.Spec.Monitoring.SeparateMetricsService {
+				exposeMetrics(skyflo, service)
+			}
+		},
+	}
+
+	comps := []component{
+		ui,
+		engine,
+		{
+			name:        "mcp",
+			displayName: "MCP",
+			port:        8000,
+			spec:        &skyflo.Spec.MCP.ComponentSpec,
+			noService:   skyflo.Spec.MCP.Agentless,
+			env: func(int32) []corev1.EnvVar {
+				if skyflo.Spec.MCP.KubeconfigSecret == "" {
+					return nil
+				}
+				return kubeconfigEnv()
+			},
+			decorate: func(deployment *appsv1.Deployment) {
+				if secret := skyflo.Spec.MCP.KubeconfigSecret; secret != "" {
+					mountKubeconfig(secret, deployment)
+				}
+				// An agentless MCP has no http port to probe.
+				if !skyflo.Spec.MCP.Agentless {
+					addDefaultProbes(deployment, mcpLivenessPath, mcpReadinessPath)
+				}
+				deployment.Spec.Template.Spec.ServiceAccountName = mcpServiceAccountName(skyflo)
+				if skyflo.Spec.ImagePullSecretsTarget == skyflov1.ImagePullSecretsTargetServiceAccount {
+					deployment.Spec.Template.Spec.ImagePullSecrets = nil
+				}
+			},
+		},
+	}
+
+	for i := range skyflo.Spec.Components {
+		custom := &skyflo.Spec.Components[i]
+		comps = append(comps, component{
+			name:        custom.Name,
+			displayName: custom.Name,
+			port:        custom.Port,
+			spec:        &custom.ComponentSpec,
+			custom:      true,
+		})
+	}
+
+	return comps
+}
+
+// statusFor returns the status field of a built-in component.
+func statusFor(skyflo *skyflov1.SkyfloAI, name string) *skyflov1.ComponentStatus {
+	switch name {
+	case "ui":
+		return &skyflo.Status.UIStatus
+	case "engine":
+		return &skyflo.Status.EngineStatus
+	case "mcp":
+		return &skyflo.Status.MCPStatus
+	}
+	return nil
+}
+
+// serviceTargetPort returns the pod port the component Service targets.
+func (c component) serviceTargetPort() int32 {
+	if c.targetPort != 0 {
+		return c.targetPort
+	}
+	return c.port
+}
+
+// componentEnv returns the controller-derived env merged with the user env.
+func (c component) componentEnv(replicas int32) []corev1.EnvVar {
+	var generated []corev1.EnvVar
+	if c.env != nil {
+		generated = c.env(replicas)
+	}
+	if len(c.spec.BuildInfo) > 0 {
+		generated = mergeEnv(generated, buildInfoEnv(c.spec.BuildInfo))
+	}
+	if generated == nil {
+		return c.spec.Env
+	}
+	return mergeEnv(generated, c.spec.Env)
+}
+
+// pruneComponents deletes the objects of additional components that are no
+// longer declared in spec.components. The Deployments and Services go last:
+// they carry the component label removedComponents finds a component by, so
+// a prune that fails part way is retried on the next reconcile even once
+// status no longer lists the component.
+func (r *SkyfloAIReconciler) pruneComponents(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	removed, err := r.removedComponents(ctx, skyflo)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range removed {
+		if err := r.deleteIfOwned(ctx, skyflo, &autoscalingv2.HorizontalPodAutoscaler{}, skyflo.Name+"-"+name); err != nil {
+			return err
+		}
+		if err := r.deleteIfOwned(ctx, skyflo, &policyv1.PodDisruptionBudget{}, skyflo.Name+"-"+name); err != nil {
+			return err
+		}
+		if err := r.deleteIfOwned(ctx, skyflo, &corev1.Secret{}, internalTLSName(skyflo, name)); err != nil {
+			return err
+		}
+		if err := r.deleteIfOwned(ctx, skyflo, &corev1.Service{}, skyflo.Name+"-"+name); err != nil {
+			return err
+		}
+		for _, version := range []string{selectorV1, selectorV2} {
+			if err := r.deleteIfOwned(ctx, skyflo, &appsv1.Deployment{}, deploymentName(skyflo, name, version)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removedComponents returns the components that are no longer declared but
+// are still listed in status or still own a Deployment or Service labeled
+// with their name.
+func (r *SkyfloAIReconciler) removedComponents(ctx context.Context, skyflo *skyflov1.SkyfloAI) ([]string, error) {
+	found := map[string]bool{}
+	for _, status := range skyflo.Status.ComponentStatuses {
+		found[status.Name] = true
+	}
+
+	for _, list := range []client.ObjectList{&appsv1.DeploymentList{}, &corev1.ServiceList{}} {
+		if err := r.List(ctx, list, client.InNamespace(skyflo.Namespace),
+			client.MatchingLabels{"app.kubernetes.io/instance": skyflo.Name}); err != nil {
+			return nil, err
+		}
+		err := meta.EachListItem(list, func(item runtime.Object) error {
+			obj := item.(client.Object)
+			if name := obj.GetLabels()["app.kubernetes.io/component"]; name != "" && metav1.IsControlledBy(obj, skyflo) {
+				found[name] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range components(skyflo) {
+		delete(found, c.name)
+	}
+	removed := make([]string, 0, len(found))
+	for name := range found {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestCustomComponent(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	reconcileOnce(t, r)
+	before, _ := specHashes(t, r)
+
+	skyflo := &skyflov1.SkyfloAI{}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	skyflo.Spec.Components = []skyflov1.CustomComponentSpec{{
+		Name:          "gateway",
+		Port:          9000,
+		ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/gateway:test"},
+	}}
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	after, _ := specHashes(t, r)
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("built-in Deployments changed when adding a component: %v, then %v", before, after)
+	}
+
+	gatewayKey := types.NamespacedName{Namespace: "default", Name: "skyflo-gateway"}
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, gatewayKey, deployment); err != nil {
+		t.Fatalf("gateway Deployment was not created: %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Name != "gateway" || container.Image != "skyflo/gateway:test" {
+		t.Errorf("gateway container = %s %s, want gateway skyflo/gateway:test", container.Name, container.Image)
+	}
+	if len(container.Ports) != 1 || container.Ports[0].ContainerPort != 9000 {
+		t.Errorf("gateway ports = %v, want 9000", container.Ports)
+	}
+	service := &corev1.Service{}
+	if err := r.Get(ctx, gatewayKey, service); err != nil {
+		t.Fatalf("gateway Service was not created: %v", err)
+	}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if len(skyflo.Status.ComponentStatuses) != 1 || skyflo.Status.ComponentStatuses[0].Name != "gateway" {
+		t.Errorf("componentStatuses = %+v, want the gateway", skyflo.Status.ComponentStatuses)
+	}
+
+	skyflo.Spec.Components = nil
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, gatewayKey, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Errorf("gateway Deployment still present after removing the component: %v", err)
+	}
+	if err := r.Get(ctx, gatewayKey, &corev1.Service{}); !errors.IsNotFound(err) {
+		t.Errorf("gateway Service still present after removing the component: %v", err)
+	}
+}
+
+func TestPruneComponentRetried(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Components = []skyflov1.CustomComponentSpec{{
+		Name: "gateway",
+		Port: 9000,
+		ComponentSpec: skyflov1.ComponentSpec{
+			Image:       "skyflo/gateway:test",
+			Autoscaling: &skyflov1.AutoscalingSpec{MaxReplicas: 3},
+		},
+	}}
+	failHPADelete := false
+	r := newTestReconciler([]client.Object{skyflo}, interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*autoscalingv2.HorizontalPodAutoscaler); ok && failHPADelete {
+				return errors.NewInternalError(fmt.Errorf("injected"))
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+	reconcileOnce(t, r)
+
+	gatewayKey := types.NamespacedName{Namespace: "default", Name: "skyflo-gateway"}
+	if err := r.Get(ctx, gatewayKey, &autoscalingv2.HorizontalPodAutoscaler{}); err != nil {
+		t.Fatalf("gateway HorizontalPodAutoscaler: %v", err)
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.Components = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first prune fails, and status stops listing the gateway.
+	failHPADelete = true
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+		t.Fatal("Reconcile succeeded despite the failed prune")
+	}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.ComponentStatuses) != 0 {
+		t.Errorf("componentStatuses = %+v, want the gateway dropped", got.Status.ComponentStatuses)
+	}
+	if err := r.Get(ctx, gatewayKey, &appsv1.Deployment{}); err != nil {
+		t.Errorf("gateway Deployment deleted before its HorizontalPodAutoscaler: %v", err)
+	}
+
+	// The next reconcile finds the gateway by its labels and finishes.
+	failHPADelete = false
+	reconcileOnce(t, r)
+	for _, obj := range []client.Object{&autoscalingv2.HorizontalPodAutoscaler{}, &corev1.Service{}, &appsv1.Deployment{}} {
+		if err := r.Get(ctx, gatewayKey, obj); !errors.IsNotFound(err) {
+			t.Errorf("gateway %T still present after the retried prune: %v", obj, err)
+		}
+	}
+}
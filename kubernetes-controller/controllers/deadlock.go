@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	defaultSentinelFile          = "/tmp/skyflo-heartbeat"
+	defaultDeadlockMaxAge        = 60 * time.Second
+	defaultDeadlockPeriodSeconds = int32(10)
+	defaultDeadlockFailures      = int32(3)
+)
+
+// sentinelFile returns the heartbeat file of the deadlock check.
+func sentinelFile(deadlock *skyflov1.DeadlockSpec) string {
+	if deadlock.SentinelFile != "" {
+		return deadlock.SentinelFile
+	}
+	return defaultSentinelFile
+}
+
+// deadlockProbe builds an exec liveness probe that fails when the heartbeat
+// file is missing or older than MaxAge. Unlike an HTTP probe it catches a
+// deadlocked work loop whose HTTP server still answers.
+func deadlockProbe(deadlock *skyflov1.DeadlockSpec) *corev1.Probe {
+	maxAge := defaultDeadlockMaxAge
+	if deadlock.MaxAge != nil {
+		maxAge = deadlock.MaxAge.Duration
+	}
+	period := deadlock.PeriodSeconds
+	if period == 0 {
+		period = defaultDeadlockPeriodSeconds
+	}
+	failures := deadlock.FailureThreshold
+	if failures == 0 {
+		failures = defaultDeadlockFailures
+	}
+
+	check := fmt.Sprintf(`test $(( $(date +%%s) - $(stat -c %%Y %q) )) -lt %d`,
+		sentinelFile(deadlock), int(maxAge.Seconds()))
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", check},
+			},
+		},
+		InitialDelaySeconds: int32(maxAge.Seconds()),
+		PeriodSeconds:       period,
+		FailureThreshold:    failures,
+	}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestDeadlockProbe(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadlock *skyflov1.DeadlockSpec
+		want     *corev1.Probe
+		wantFile string
+	}{
+		{
+			name:     "defaults",
+			deadlock: &skyflov1.DeadlockSpec{},
+			want: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{
+					"/bin/sh", "-c", `test $(( $(date +%s) - $(stat -c %Y "/tmp/skyflo-heartbeat") )) -lt 60`,
+				}}},
+				InitialDelaySeconds: 60,
+				PeriodSeconds:       10,
+				FailureThreshold:    3,
+			},
+			wantFile: "/tmp/skyflo-heartbeat",
+		},
+		{
+			name: "configured",
+			deadlock: &skyflov1.DeadlockSpec{
+				SentinelFile:     "/run/engine/heartbeat",
+				MaxAge:           &metav1.Duration{Duration: 2 * time.Minute},
+				PeriodSeconds:    15,
+				FailureThreshold: 2,
+			},
+			want: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{
+					"/bin/sh", "-c", `test $(( $(date +%s) - $(stat -c %Y "/run/engine/heartbeat") )) -lt 120`,
+				}}},
+				InitialDelaySeconds: 120,
+				PeriodSeconds:       15,
+				FailureThreshold:    2,
+			},
+			wantFile: "/run/engine/heartbeat",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.DeadlockDetection = tt.deadlock
+			r := newTestReconciler(nil)
+
+			container := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec.Containers[0]
+			if !reflect.DeepEqual(container.LivenessProbe, tt.want) {
+				t.Errorf("liveness probe = %+v, want %+v", container.LivenessProbe, tt.want)
+			}
+			if got := envValue(container.Env, "DEADLOCK_SENTINEL_FILE"); got != tt.wantFile {
+				t.Errorf("DEADLOCK_SENTINEL_FILE = %q, want %q", got, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestDeadlockProbeExplicitLiveness(t *testing.T) {
+	explicit := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")}},
+	}
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.DeadlockDetection = &skyflov1.DeadlockSpec{}
+	skyflo.Spec.Engine.LivenessProbe = explicit
+	r := newTestReconciler(nil)
+
+	container := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec.Containers[0]
+	if !reflect.DeepEqual(container.LivenessProbe, explicit) {
+		t.Errorf("liveness probe = %+v, want the explicit probe", container.LivenessProbe)
+	}
+}
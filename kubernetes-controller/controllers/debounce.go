@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ignoreUpdates drops SkyfloAI update events from the primary watch while
+// they are debounced.
+var ignoreUpdates = predicate.Funcs{
+	UpdateFunc: func(event.UpdateEvent) bool { return false },
+}
+
+// debouncedUpdates enqueues SkyfloAI updates after the debounce delay. The
+// delaying queue keeps a single pending entry per object, so edits arriving
+// within the delay collapse into one reconcile, and that reconcile reads the
+// newest generation from the cache.
+func (r *SkyfloAIReconciler) debouncedUpdates() handler.EventHandler {
+	return handler.Funcs{
+		UpdateFunc: func(_ context.Context, e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			q.AddAfter(ctrl.Request{NamespacedName: types.NamespacedName{
+				Name:      e.ObjectNew.GetName(),
+				Namespace: e.ObjectNew.GetNamespace(),
+			}}, r.ReconcileDebounce)
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestDebouncedUpdates(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	r.ReconcileDebounce = 100 * time.Millisecond
+	reconcileOnce(t, r)
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+	handler := r.debouncedUpdates()
+
+	const edits = 5
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	for i := 1; i <= edits; i++ {
+		old := &skyflov1.SkyfloAI{}
+		if err := r.Get(ctx, key, old); err != nil {
+			t.Fatal(err)
+		}
+		edited := old.DeepCopy()
+		edited.Spec.Engine.Image = fmt.Sprintf("skyflo/engine:v%d", i)
+		if err := r.Update(ctx, edited); err != nil {
+			t.Fatal(err)
+		}
+		handler.Update(ctx, event.UpdateEvent{ObjectOld: old, ObjectNew: edited}, q)
+	}
+	if q.Len() != 0 {
+		t.Errorf("queue length before the debounce delay = %d, want 0", q.Len())
+	}
+
+	reconciles := 0
+	deadline := time.Now().Add(3 * r.ReconcileDebounce)
+	for time.Now().Before(deadline) {
+		if q.Len() == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		item, _ := q.Get()
+		req := item.(ctrl.Request)
+		if req.NamespacedName != key {
+			t.Errorf("queued %v, want %v", req.NamespacedName, key)
+		}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		q.Done(item)
+		reconciles++
+	}
+	if reconciles == 0 || reconciles >= edits {
+		t.Errorf("%d edits caused %d reconciles, want at least one and fewer than the edits", edits, reconciles)
+	}
+
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := engine.Spec.Template.Spec.Containers[0].Image, fmt.Sprintf("skyflo/engine:v%d", edits); got != want {
+		t.Errorf("engine image = %q, want the last edit %q", got, want)
+	}
+}
+
+func TestIgnoreUpdates(t *testing.T) {
+	skyflo := testSkyfloAI()
+	if ignoreUpdates.Update(event.UpdateEvent{ObjectOld: skyflo, ObjectNew: skyflo}) {
+		t.Error("update passed the primary watch while debounced")
+	}
+	if !ignoreUpdates.Create(event.CreateEvent{Object: skyflo}) || !ignoreUpdates.Delete(event.DeleteEvent{Object: skyflo}) {
+		t.Error("create or delete dropped from the primary watch")
+	}
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	defaultReadyPath  = `{.status.conditions[?(@.type=="Ready")].status}`
+	defaultReadyValue = "True"
+
+	// dependencyPollInterval is how often a dependency that is not ready
+	// yet is checked again; dependencies are not watched.
+	dependencyPollInterval = 15 * time.Second
+)
+
+// databaseReady reports whether the Engine's database dependency, if any, is
+// ready, and records the outcome in the WaitingForDatabase condition.
+func (r *SkyfloAIReconciler) databaseReady(ctx context.Context, skyflo *skyflov1.SkyfloAI) (bool, error) {
+	dep := skyflo.Spec.Engine.DatabaseDependency
+	if dep == nil {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "WaitingForDatabase")
+		return true, nil
+	}
+
+	ready, reason, err := r.dependencyReady(ctx, skyflo.Namespace, dep)
+	if err != nil {
+		return false, err
+	}
+	if ready {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "WaitingForDatabase",
+			Status:             metav1.ConditionFalse,
+			Reason:             "DatabaseReady",
+			Message:            fmt.Sprintf("%s %s is ready", dep.Kind, dep.Name),
+			ObservedGeneration: skyflo.Generation,
+		})
+		return true, nil
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:               "WaitingForDatabase",
+		Status:             metav1.ConditionTrue,
+		Reason:             "DatabaseNotReady",
+		Message:            fmt.Sprintf("holding the Engine rollout: %s", reason),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return false, nil
+}
+
+// dependencyReady fetches the referenced object and evaluates its readiness
+// path. When it is not ready, reason explains why.
+func (r *SkyfloAIReconciler) dependencyReady(ctx context.Context, namespace string, dep *skyflov1.DependencyRef) (bool, string, error) {
+	gv, err := schema.ParseGroupVersion(dep.APIVersion)
+	if err != nil {
+		return false, "", err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gv.WithKind(dep.Kind))
+	err = r.Get(ctx, types.NamespacedName{Name: dep.Name, Namespace: namespace}, obj)
+	if errors.IsNotFound(err) {
+		return false, fmt.Sprintf("%s %s does not exist", dep.Kind, dep.Name), nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	path := dep.ReadyPath
+	if path == "" {
+		path = defaultReadyPath
+	}
+	want := dep.ReadyValue
+	if want == "" {
+		want = defaultReadyValue
+	}
+
+	got, err := evalJSONPath(obj.Object, path)
+	if err != nil {
+		return false, "", fmt.Errorf("readyPath of %s %s: %w", dep.Kind, dep.Name, err)
+	}
+	if got != want {
+		return false, fmt.Sprintf("%s %s reports %q at %s, want %q", dep.Kind, dep.Name, got, path, want), nil
+	}
+	return true, "", nil
+}
+
+// evalJSONPath returns the value at a JSONPath, with multiple results joined
+// by spaces. Missing fields evaluate to the empty string.
+func evalJSONPath(obj map[string]interface{}, path string) (string, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	j := jsonpath.New("ready").AllowMissingKeys(true)
+	if err := j.Parse(path); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := j.Execute(&b, obj); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+var cnpgClusterGVK = schema.GroupVersionKind{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Cluster"}
+
+// cnpgCluster returns a CloudNativePG Cluster whose Ready condition has the
+// given status.
+func cnpgCluster(ready string) *unstructured.Unstructured {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cnpgClusterGVK)
+	cluster.SetName("skyflo-db")
+	cluster.SetNamespace("default")
+	cluster.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": ready},
+		},
+	}
+	return cluster
+}
+
+func TestDatabaseDependency(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.DatabaseDependency = &skyflov1.DependencyRef{
+		APIVersion: "postgresql.cnpg.io/v1",
+		Kind:       "Cluster",
+		Name:       "skyflo-db",
+	}
+	r := newTestReconcilerWithKinds([]schema.GroupVersionKind{cnpgClusterGVK}, []client.Object{skyflo, cnpgCluster("False")})
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > dependencyPollInterval {
+		t.Errorf("RequeueAfter = %v, want at most %v to poll the dependency", result.RequeueAfter, dependencyPollInterval)
+	}
+	if err := r.Get(ctx, engineKey, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Errorf("Engine rolled out before the database was ready: %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("UI held back by the Engine database: %v", err)
+	}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, "WaitingForDatabase")
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "DatabaseNotReady" {
+		t.Errorf("WaitingForDatabase = %+v, want True while the Cluster is not ready", condition)
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cnpgClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-db"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	cluster.Object["status"] = cnpgCluster("True").Object["status"]
+	if err := r.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if err := r.Get(ctx, engineKey, &appsv1.Deployment{}); err != nil {
+		t.Errorf("Engine not rolled out once the database is ready: %v", err)
+	}
+	if err := r.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	condition = meta.FindStatusCondition(got.Status.Conditions, "WaitingForDatabase")
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "DatabaseReady" {
+		t.Errorf("WaitingForDatabase = %+v, want False once the Cluster is ready", condition)
+	}
+}
+
+func TestDependencyReadyPath(t *testing.T) {
+	ctx := context.Background()
+	cluster := cnpgCluster("True")
+	cluster.Object["status"].(map[string]interface{})["phase"] = "Setting up primary"
+	r := newTestReconcilerWithKinds([]schema.GroupVersionKind{cnpgClusterGVK}, []client.Object{cluster})
+
+	tests := []struct {
+		name string
+		dep  skyflov1.DependencyRef
+		want bool
+	}{
+		{name: "default ready condition", dep: skyflov1.DependencyRef{Name: "skyflo-db"}, want: true},
+		{name: "custom path", dep: skyflov1.DependencyRef{Name: "skyflo-db", ReadyPath: ".status.phase", ReadyValue: "Cluster in healthy state"}},
+		{name: "missing object", dep: skyflov1.DependencyRef{Name: "other-db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dep.APIVersion, tt.dep.Kind = "postgresql.cnpg.io/v1", "Cluster"
+			ready, reason, err := r.dependencyReady(ctx, "default", &tt.dep)
+			if err != nil {
+				t.Fatalf("dependencyReady: %v", err)
+			}
+			if ready != tt.want {
+				t.Errorf("ready = %v (%s), want %v", ready, reason, tt.want)
+			}
+			if !ready && reason == "" {
+				t.Error("not ready without a reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// collectDiagnosticsAnnotation, set to "true" on a SkyfloAI, has the
+// controller write a diagnostics bundle to the <name>-diagnostics ConfigMap
+// and then removes it.
+const collectDiagnosticsAnnotation = "skyflo.ai/collect-diagnostics"
+
+// diagnosticsKey is the ConfigMap key holding the JSON bundle.
+const diagnosticsKey = "diagnostics.json"
+
+// The bundle is bounded so it always fits in a ConfigMap.
+const (
+	maxDiagnosticPods    = 20
+	maxDiagnosticEvents  = 50
+	maxDiagnosticMessage = 256
+)
+
+// diagnosticsBundle is the machine-readable summary support asks for.
+type diagnosticsBundle struct {
+	CollectedAt     metav1.Time           `json:"collectedAt"`
+	AppliedSpecHash string                `json:"appliedSpecHash"`
+	Conditions      []metav1.Condition    `json:"conditions,omitempty"`
+	Components      []componentDiagnostic `json:"components"`
+	Events          []eventDiagnostic     `json:"events,omitempty"`
+}
+
+type componentDiagnostic struct {
+	Name            string          `json:"name"`
+	Phase           string          `json:"phase,omitempty"`
+	ReadyReplicas   int32           `json:"readyReplicas"`
+	DesiredReplicas int32           `json:"desiredReplicas"`
+	Pods            []podDiagnostic `json:"pods,omitempty"`
+}
+
+type podDiagnostic struct {
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Node     string `json:"node,omitempty"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type eventDiagnostic struct {
+	Time    metav1.Time `json:"time"`
+	Type    string      `json:"type"`
+	Reason  string      `json:"reason"`
+	Object  string      `json:"object"`
+	Message string      `json:"message"`
+	Count   int32       `json:"count,omitempty"`
+}
+
+// collectDiagnostics writes the diagnostics bundle of a SkyfloAI annotated
+// for collection, references it in status.diagnosticsRef and removes the
+// annotation. The bundle holds the pod states of every component, the most
+// recent events of the SkyfloAI and its children, and the applied spec hash.
+func (r *SkyfloAIReconciler) collectDiagnostics(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	if skyflo.Annotations[collectDiagnosticsAnnotation] != "true" {
+		return nil
+	}
+
+	bundle := diagnosticsBundle{
+		CollectedAt:     metav1.NewTime(r.now()),
+		AppliedSpecHash: skyflo.Status.AppliedSpecHash,
+		Conditions:      skyflo.Status.Conditions,
+	}
+	for _, c := range components(skyflo) {
+		component, err := r.componentDiagnostic(ctx, skyflo, c)
+		if err != nil {
+			return err
+		}
+		bundle.Components = append(bundle.Components, component)
+	}
+	events, err := r.eventDiagnostics(ctx, skyflo)
+	if err != nil {
+		return err
+	}
+	bundle.Events = events
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      skyflo.Name + "-diagnostics",
+			Namespace: skyflo.Namespace,
+		},
+		Data: map[string]string{diagnosticsKey: string(data)},
+	}
+	if err := r.own(skyflo, configMap); err != nil {
+		return err
+	}
+	if err := r.createOrUpdateConfigMap(ctx, configMap); err != nil {
+		return err
+	}
+	skyflo.Status.DiagnosticsRef = &skyflov1.DiagnosticsReference{
+		Name:        configMap.Name,
+		Key:         diagnosticsKey,
+		CollectedAt: bundle.CollectedAt,
+	}
+
+	patched := skyflo.DeepCopy()
+	delete(patched.Annotations, collectDiagnosticsAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(skyflo), r.fieldOwner()); err != nil {
+		return err
+	}
+	skyflo.Annotations = patched.Annotations
+	skyflo.ResourceVersion = patched.ResourceVersion
+	return nil
+}
+
+// componentDiagnostic summarizes a component Deployment and up to
+// maxDiagnosticPods of its pods.
+func (r *SkyfloAIReconciler) componentDiagnostic(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component) (componentDiagnostic, error) {
+	diagnostic := componentDiagnostic{Name: c.name}
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, selectorVersion(skyflo)), Namespace: skyflo.Namespace}, deployment)
+	if err != nil {
+		return diagnostic, client.IgnoreNotFound(err)
+	}
+	diagnostic.Phase = getPhase(deployment)
+	diagnostic.ReadyReplicas = deployment.Status.ReadyReplicas
+	diagnostic.DesiredReplicas = *deployment.Spec.Replicas
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(deployment.Namespace),
+		client.MatchingLabels(deployment.Spec.Selector.MatchLabels),
+	); err != nil {
+		return diagnostic, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	for i, pod := range pods.Items {
+		if i == maxDiagnosticPods {
+			break
+		}
+		diagnostic.Pods = append(diagnostic.Pods, podSummary(&pod))
+	}
+	return diagnostic, nil
+}
+
+// podSummary reports a pod's phase, readiness, restarts and the reason its
+// first unhealthy container gives.
+func podSummary(pod *corev1.Pod) podDiagnostic {
+	summary := podDiagnostic{
+		Name:   pod.Name,
+		Phase:  string(pod.Status.Phase),
+		Node:   pod.Spec.NodeName,
+		Reason: pod.Status.Reason,
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			summary.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		summary.Restarts += status.RestartCount
+		if summary.Reason != "" {
+			continue
+		}
+		if waiting := status.State.Waiting; waiting != nil {
+			summary.Reason = waiting.Reason
+		} else if terminated := status.LastTerminationState.Terminated; terminated != nil && !status.Ready {
+			summary.Reason = terminated.Reason
+		}
+	}
+	return summary
+}
+
+// eventDiagnostics returns the most recent events of the SkyfloAI and of the
+// objects named after it. Events are read from the API server, not the
+// cache, so collecting diagnostics does not start an events informer.
+func (r *SkyfloAIReconciler) eventDiagnostics(ctx context.Context, skyflo *skyflov1.SkyfloAI) ([]eventDiagnostic, error) {
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	events := &corev1.EventList{}
+	if err := reader.List(ctx, events, client.InNamespace(skyflo.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var related []corev1.Event
+	for _, event := range events.Items {
+		name := event.InvolvedObject.Name
+		if name == skyflo.Name || strings.HasPrefix(name, skyflo.Name+"-") {
+			related = append(related, event)
+		}
+	}
+	sort.Slice(related, func(i, j int) bool {
+		return eventTime(&related[i]).Time.After(eventTime(&related[j]).Time)
+	})
+	if len(related) > maxDiagnosticEvents {
+		related = related[:maxDiagnosticEvents]
+	}
+
+	diagnostics := make([]eventDiagnostic, 0, len(related))
+	for i := range related {
+		event := &related[i]
+		message := event.Message
+		if len(message) > maxDiagnosticMessage {
+			message = message[:maxDiagnosticMessage] + "..."
+		}
+		diagnostics = append(diagnostics, eventDiagnostic{
+			Time:    eventTime(event),
+			Type:    event.Type,
+			Reason:  event.Reason,
+			Object:  event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name,
+			Message: message,
+			Count:   event.Count,
+		})
+	}
+	return diagnostics, nil
+}
+
+// eventTime returns when an event last occurred.
+func eventTime(event *corev1.Event) metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return metav1.NewTime(event.EventTime.Time)
+	}
+	return event.CreationTimestamp
+}
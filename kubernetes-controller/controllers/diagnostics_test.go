@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// diagnosticsEvent returns an event about the named object that last
+// occurred offset minutes after start.
+func diagnosticsEvent(name, object, message string, start time.Time, offset int) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: object, Namespace: "default"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Test",
+		Message:        message,
+		LastTimestamp:  metav1.NewTime(start.Add(time.Duration(offset) * time.Minute)),
+	}
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	objs := []client.Object{testSkyfloAI()}
+	for i := 0; i < maxDiagnosticEvents+10; i++ {
+		objs = append(objs, diagnosticsEvent(fmt.Sprintf("engine-%d", i), "skyflo-engine", "restarted", start, i))
+	}
+	objs = append(objs,
+		diagnosticsEvent("long", "skyflo-mcp", strings.Repeat("x", 2*maxDiagnosticMessage), start, 1000),
+		diagnosticsEvent("unrelated", "other-engine", "not ours", start, 2000),
+	)
+	r := newTestReconciler(objs)
+	r.Clock = clocktesting.NewFakePassiveClock(start.Add(48 * time.Hour))
+	reconcileOnce(t, r)
+
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxDiagnosticPods+5; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("skyflo-engine-%02d", i),
+				Namespace: "default",
+				Labels:    engine.Spec.Selector.MatchLabels,
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					RestartCount: 2,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+		}
+		if err := r.Create(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if skyflo.Status.DiagnosticsRef != nil {
+		t.Fatalf("diagnosticsRef = %+v before collection was requested", skyflo.Status.DiagnosticsRef)
+	}
+	skyflo.Annotations = map[string]string{collectDiagnosticsAnnotation: "true"}
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := skyflo.Annotations[collectDiagnosticsAnnotation]; ok {
+		t.Errorf("%s kept after collecting", collectDiagnosticsAnnotation)
+	}
+	ref := skyflo.Status.DiagnosticsRef
+	if ref == nil || ref.Name != "skyflo-diagnostics" || ref.Key != diagnosticsKey || !ref.CollectedAt.Time.Equal(start.Add(48*time.Hour)) {
+		t.Fatalf("diagnosticsRef = %+v, want skyflo-diagnostics/%s collected now", ref, diagnosticsKey)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: ref.Name}, configMap); err != nil {
+		t.Fatalf("diagnostics ConfigMap: %v", err)
+	}
+	if !metav1.IsControlledBy(configMap, skyflo) {
+		t.Errorf("diagnostics ConfigMap owners = %+v, want the SkyfloAI", configMap.OwnerReferences)
+	}
+	var bundle diagnosticsBundle
+	if err := json.Unmarshal([]byte(configMap.Data[ref.Key]), &bundle); err != nil {
+		t.Fatalf("diagnostics bundle: %v", err)
+	}
+	if bundle.AppliedSpecHash == "" || bundle.AppliedSpecHash != skyflo.Status.AppliedSpecHash {
+		t.Errorf("bundle spec hash = %q, want the applied %q", bundle.AppliedSpecHash, skyflo.Status.AppliedSpecHash)
+	}
+	if len(bundle.Components) != 3 {
+		t.Fatalf("bundle components = %d, want 3", len(bundle.Components))
+	}
+	var engineDiagnostic *componentDiagnostic
+	for i := range bundle.Components {
+		if bundle.Components[i].Name == "engine" {
+			engineDiagnostic = &bundle.Components[i]
+		}
+	}
+	if engineDiagnostic == nil {
+		t.Fatalf("bundle components %+v, want the Engine", bundle.Components)
+	}
+	if len(engineDiagnostic.Pods) != maxDiagnosticPods {
+		t.Errorf("Engine pods = %d, want them bounded to %d", len(engineDiagnostic.Pods), maxDiagnosticPods)
+	}
+	if pod := engineDiagnostic.Pods[0]; pod.Name != "skyflo-engine-00" || pod.Restarts != 2 || pod.Reason != "CrashLoopBackOff" || pod.Ready {
+		t.Errorf("first Engine pod = %+v, want skyflo-engine-00 crash looping with 2 restarts", pod)
+	}
+
+	if len(bundle.Events) != maxDiagnosticEvents {
+		t.Fatalf("bundle events = %d, want them bounded to %d", len(bundle.Events), maxDiagnosticEvents)
+	}
+	if first := bundle.Events[0]; first.Object != "Deployment/skyflo-mcp" || len(first.Message) != maxDiagnosticMessage+len("...") {
+		t.Errorf("most recent event = %+v, want the truncated MCP event", first)
+	}
+	for _, event := range bundle.Events {
+		if event.Object == "Deployment/other-engine" {
+			t.Errorf("bundle holds an event of an unrelated object: %+v", event)
+		}
+	}
+
+	// Without the annotation, later reconciles do not collect again.
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if got := skyflo.Status.DiagnosticsRef; got == nil || !got.CollectedAt.Equal(&ref.CollectedAt) {
+		t.Errorf("diagnosticsRef = %+v after a later reconcile, want it kept", got)
+	}
+}
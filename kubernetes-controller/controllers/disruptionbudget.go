@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// disruptionBudget returns the PodDisruptionBudget settings of a component,
+// or nil when none is configured or it is disabled.
+func disruptionBudget(c component) *skyflov1.PodDisruptionBudgetSpec {
+	spec := c.spec.PodDisruptionBudget
+	if spec == nil || (spec.Enabled != nil && !*spec.Enabled) {
+		return nil
+	}
+	return spec
+}
+
+// reconcileDisruptionBudget maintains the PodDisruptionBudget of a
+// component, removing it once no longer configured or disabled.
+func (r *SkyfloAIReconciler) reconcileDisruptionBudget(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component) error {
+	name := skyflo.Name + "-" + c.name
+	spec := disruptionBudget(c)
+	if spec == nil {
+		return r.deleteIfOwned(ctx, skyflo, &policyv1.PodDisruptionBudget{}, name)
+	}
+
+	maxUnavailable := spec.MaxUnavailable
+	if spec.MinAvailable == nil && maxUnavailable == nil {
+		maxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 1}
+	}
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: podLabels(skyflo, c.name, selectorVersion(skyflo))},
+			MinAvailable:   spec.MinAvailable,
+			MaxUnavailable: maxUnavailable,
+		},
+	}
+	if err := r.own(skyflo, pdb); err != nil {
+		return err
+	}
+	return r.createOrUpdateDisruptionBudget(ctx, pdb)
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateDisruptionBudget(ctx context.Context, pdb *policyv1.PodDisruptionBudget) error {
+	found := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, types.NamespacedName{Name: pdb.Name, Namespace: pdb.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, pdb)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, pdb, "PodDisruptionBudget"); err != nil {
+		return err
+	}
+
+	pdb.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, pdb)
+}
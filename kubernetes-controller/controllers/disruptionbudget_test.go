@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestDisruptionBudget(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.MinReadySeconds = 30
+	skyflo.Spec.Engine.PodDisruptionBudget = &skyflov1.PodDisruptionBudgetSpec{}
+	skyflo.Spec.UI.PodDisruptionBudget = &skyflov1.PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromString("50%"))}
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, engineKey, deployment); err != nil {
+		t.Fatal(err)
+	}
+	if deployment.Spec.MinReadySeconds != 30 {
+		t.Errorf("Engine minReadySeconds = %d, want 30", deployment.Spec.MinReadySeconds)
+	}
+
+	pdb := &policyv1.PodDisruptionBudget{}
+	if err := r.Get(ctx, engineKey, pdb); err != nil {
+		t.Fatalf("Engine PodDisruptionBudget: %v", err)
+	}
+	if !metav1.IsControlledBy(pdb, skyflo) {
+		t.Errorf("PodDisruptionBudget owners = %+v, want the SkyfloAI", pdb.OwnerReferences)
+	}
+	if pdb.Spec.MinAvailable != nil || pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("Engine budget = %+v, want the default maxUnavailable 1", pdb.Spec)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+		t.Errorf("budget selector %v does not select the Engine pods %v", selector, deployment.Spec.Template.Labels)
+	}
+
+	uiPDB := &policyv1.PodDisruptionBudget{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}, uiPDB); err != nil {
+		t.Fatalf("UI PodDisruptionBudget: %v", err)
+	}
+	if uiPDB.Spec.MinAvailable == nil || uiPDB.Spec.MinAvailable.StrVal != "50%" || uiPDB.Spec.MaxUnavailable != nil {
+		t.Errorf("UI budget = %+v, want minAvailable 50%%", uiPDB.Spec)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-mcp"}, &policyv1.PodDisruptionBudget{}); !errors.IsNotFound(err) {
+		t.Errorf("MCP without a budget has a PodDisruptionBudget: %v", err)
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.Engine.PodDisruptionBudget = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, engineKey, &policyv1.PodDisruptionBudget{}); !errors.IsNotFound(err) {
+		t.Errorf("Engine PodDisruptionBudget kept after removing it: %v", err)
+	}
+}
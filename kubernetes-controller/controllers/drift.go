@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// desiredHashAnnotation records on each generated Deployment the hash of the
+// object the controller last wrote, so changes to the desired state,
+// including removed fields, are told apart from an unchanged one.
+const desiredHashAnnotation = "skyflo.ai/desired-hash"
+
+// stampDesiredHash records the hash of the desired Deployment's metadata and
+// spec on it.
+func stampDesiredHash(deployment *appsv1.Deployment) error {
+	delete(deployment.Annotations, desiredHashAnnotation)
+	data, err := json.Marshal(struct {
+		Labels      map[string]string
+		Annotations map[string]string
+		Spec        appsv1.DeploymentSpec
+	}{deployment.Labels, deployment.Annotations, deployment.Spec})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[desiredHashAnnotation] = hex.EncodeToString(sum[:])[:16]
+	return nil
+}
+
+// deploymentUpToDate reports whether the live Deployment needs no write: it
+// was last written from the same desired state and every field the
+// controller sets still has the desired value. Fields the controller leaves
+// unset, which the API server defaults, are ignored, so only genuine drift,
+// such as a replica count or image edited in place, triggers an update.
+func deploymentUpToDate(desired, found *appsv1.Deployment) bool {
+	if found.Annotations[desiredHashAnnotation] != desired.Annotations[desiredHashAnnotation] {
+		return false
+	}
+	return equality.Semantic.DeepDerivative(desired.Labels, found.Labels) &&
+		equality.Semantic.DeepDerivative(desired.Annotations, found.Annotations) &&
+		equality.Semantic.DeepDerivative(desired.OwnerReferences, found.OwnerReferences) &&
+		equality.Semantic.DeepDerivative(desired.Spec, found.Spec)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// deploymentWrites returns the Deployment entries of writes recorded by
+// writesTo.
+func deploymentWrites(written []string) []string {
+	var deployments []string
+	for _, w := range written {
+		if strings.HasPrefix(w, "*v1.Deployment ") {
+			deployments = append(deployments, w)
+		}
+	}
+	return deployments
+}
+
+func TestDeploymentDrift(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+	var written []string
+	r := newTestReconciler([]client.Object{skyflo}, writesTo(&written))
+	reconcileOnce(t, r)
+	if got := deploymentWrites(written); len(got) != 3 {
+		t.Fatalf("first reconcile wrote Deployments %v, want the three components", got)
+	}
+
+	// A no-op reconcile writes no Deployment.
+	written = nil
+	reconcileOnce(t, r)
+	reconcileOnce(t, r)
+	if got := deploymentWrites(written); len(got) != 0 {
+		t.Errorf("no-op reconciles wrote Deployments %v, want none", got)
+	}
+
+	// Drift on a field the controller sets is corrected.
+	engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, engineKey, engine); err != nil {
+		t.Fatal(err)
+	}
+	engine.Spec.Replicas = ptr.To[int32](4)
+	engine.Spec.Template.Spec.Containers[0].Image = "skyflo/engine:hotfix"
+	if err := r.Update(ctx, engine); err != nil {
+		t.Fatal(err)
+	}
+	written = nil
+	reconcileOnce(t, r)
+	if got := deploymentWrites(written); len(got) != 1 || got[0] != "*v1.Deployment skyflo-engine" {
+		t.Errorf("drift reconcile wrote Deployments %v, want only the Engine", got)
+	}
+	if err := r.Get(ctx, engineKey, engine); err != nil {
+		t.Fatal(err)
+	}
+	if got := ptr.Deref(engine.Spec.Replicas, 0); got != 1 {
+		t.Errorf("Engine replicas = %d, want the drift reverted to 1", got)
+	}
+	if got := engine.Spec.Template.Spec.Containers[0].Image; got != "skyflo/engine:test" {
+		t.Errorf("Engine image = %q, want the drift reverted", got)
+	}
+
+	// A field only the API server or others set is not drift.
+	engine.Spec.Template.Spec.Containers[0].TerminationMessagePath = "/dev/termination-log"
+	if err := r.Update(ctx, engine); err != nil {
+		t.Fatal(err)
+	}
+	written = nil
+	reconcileOnce(t, r)
+	if got := deploymentWrites(written); len(got) != 0 {
+		t.Errorf("defaulted field triggered Deployment writes %v, want none", got)
+	}
+
+	// Removing a field from the desired state is written.
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.Engine.Env = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	written = nil
+	reconcileOnce(t, r)
+	if got := deploymentWrites(written); len(got) != 1 || got[0] != "*v1.Deployment skyflo-engine" {
+		t.Errorf("removed env wrote Deployments %v, want only the Engine", got)
+	}
+	if err := r.Get(ctx, engineKey, engine); err != nil {
+		t.Fatal(err)
+	}
+	if envValue(engine.Spec.Template.Spec.Containers[0].Env, "LOG_LEVEL") != "" {
+		t.Errorf("LOG_LEVEL kept on the Engine after removing it from the spec")
+	}
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestServiceIPFamilies(t *testing.T) {
+	dual := []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	tests := []struct {
+		name         string
+		policy       *corev1.IPFamilyPolicy
+		families     []corev1.IPFamily
+		wantPolicy   *corev1.IPFamilyPolicy
+		wantFamilies []corev1.IPFamily
+		wantIPs      []string
+	}{
+		{
+			name:         "unset keeps the allocated families",
+			wantPolicy:   ptr.To(corev1.IPFamilyPolicyRequireDualStack),
+			wantFamilies: dual,
+			wantIPs:      []string{"10.0.0.10", "fd00::10"},
+		},
+		{
+			name:         "dual stack kept",
+			policy:       ptr.To(corev1.IPFamilyPolicyRequireDualStack),
+			families:     dual,
+			wantPolicy:   ptr.To(corev1.IPFamilyPolicyRequireDualStack),
+			wantFamilies: dual,
+			wantIPs:      []string{"10.0.0.10", "fd00::10"},
+		},
+		{
+			name:         "downgrade to single stack keeps the primary family",
+			policy:       ptr.To(corev1.IPFamilyPolicySingleStack),
+			wantPolicy:   ptr.To(corev1.IPFamilyPolicySingleStack),
+			wantFamilies: []corev1.IPFamily{corev1.IPv4Protocol},
+			wantIPs:      []string{"10.0.0.10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.UI.IPFamilyPolicy = tt.policy
+			skyflo.Spec.UI.IPFamilies = tt.families
+			existing := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"},
+				Spec: corev1.ServiceSpec{
+					ClusterIP:      "10.0.0.10",
+					ClusterIPs:     []string{"10.0.0.10", "fd00::10"},
+					IPFamilyPolicy: ptr.To(corev1.IPFamilyPolicyRequireDualStack),
+					IPFamilies:     dual,
+				},
+			}
+			r := newTestReconciler([]client.Object{ownedBy(skyflo, existing)})
+
+			ui := components(skyflo)[0]
+			service := r.service(skyflo, ui)
+			if !reflect.DeepEqual(service.Spec.IPFamilyPolicy, tt.policy) || !reflect.DeepEqual(service.Spec.IPFamilies, tt.families) {
+				t.Errorf("rendered policy %v and families %v, want %v and %v",
+					service.Spec.IPFamilyPolicy, service.Spec.IPFamilies, tt.policy, tt.families)
+			}
+			if err := r.own(skyflo, service); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.createOrUpdateService(ctx, skyflo, service, nil); err != nil {
+				t.Fatalf("createOrUpdateService: %v", err)
+			}
+
+			got := &corev1.Service{}
+			if err := r.Get(ctx, types.NamespacedName{Name: "skyflo-ui", Namespace: "default"}, got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Spec.IPFamilyPolicy, tt.wantPolicy) {
+				t.Errorf("ipFamilyPolicy = %v, want %v", ptr.Deref(got.Spec.IPFamilyPolicy, ""), *tt.wantPolicy)
+			}
+			if !reflect.DeepEqual(got.Spec.IPFamilies, tt.wantFamilies) {
+				t.Errorf("ipFamilies = %v, want %v", got.Spec.IPFamilies, tt.wantFamilies)
+			}
+			if !reflect.DeepEqual(got.Spec.ClusterIPs, tt.wantIPs) || got.Spec.ClusterIP != "10.0.0.10" {
+				t.Errorf("clusterIPs = %v (clusterIP %s), want %v", got.Spec.ClusterIPs, got.Spec.ClusterIP, tt.wantIPs)
+			}
+		})
+	}
+}
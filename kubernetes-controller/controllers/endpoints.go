@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// reconcilePublishedEndpoints maintains the <name>-endpoints ConfigMap that
+// external tools read to discover the in-cluster endpoints of the stack,
+// removing it once publishing is disabled. It is built from the Services as
+// they exist, so it follows their port changes.
+func (r *SkyfloAIReconciler) reconcilePublishedEndpoints(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	name := skyflo.Name + "-endpoints"
+	if publish := skyflo.Spec.PublishEndpoints; publish == nil || !publish.Enabled {
+		return r.deleteIfOwned(ctx, skyflo, &corev1.ConfigMap{}, name)
+	}
+
+	data := map[string]string{}
+	for _, c := range components(skyflo) {
+		service := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: skyflo.Name + "-" + c.name, Namespace: skyflo.Namespace}, service)
+		if err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		if len(service.Spec.Ports) == 0 {
+			continue
+		}
+
+		host := service.Name + "." + service.Namespace + ".svc"
+		port := strconv.Itoa(int(service.Spec.Ports[0].Port))
+		data[c.name+".host"] = host
+		data[c.name+".port"] = port
+		data[c.name+".url"] = fmt.Sprintf("http://%s:%s", host, port)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Data: data,
+	}
+	if err := r.own(skyflo, configMap); err != nil {
+		return err
+	}
+	return r.createOrUpdateConfigMap(ctx, configMap)
+}
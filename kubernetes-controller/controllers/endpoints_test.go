@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestPublishEndpoints(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.PublishEndpoints = &skyflov1.PublishSpec{Enabled: true}
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-endpoints"}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		t.Fatalf("endpoints ConfigMap: %v", err)
+	}
+	if !metav1.IsControlledBy(configMap, skyflo) {
+		t.Errorf("endpoints ConfigMap owners = %+v, want the SkyfloAI", configMap.OwnerReferences)
+	}
+	want := map[string]string{}
+	for _, name := range []string{"ui", "engine", "mcp"} {
+		service := &corev1.Service{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-" + name}, service); err != nil {
+			t.Fatal(err)
+		}
+		if service.Spec.Ports[0].Port != 80 {
+			t.Fatalf("%s Service port = %d, want 80", name, service.Spec.Ports[0].Port)
+		}
+		want[name+".host"] = "skyflo-" + name + ".default.svc"
+		want[name+".port"] = "80"
+		want[name+".url"] = "http://skyflo-" + name + ".default.svc:80"
+	}
+	if !reflect.DeepEqual(configMap.Data, want) {
+		t.Errorf("endpoints = %v, want %v", configMap.Data, want)
+	}
+
+	// A changed Service port is republished.
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, service); err != nil {
+		t.Fatal(err)
+	}
+	service.Spec.Ports[0].Port = 8080
+	if err := r.Update(ctx, service); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcilePublishedEndpoints(ctx, skyflo); err != nil {
+		t.Fatalf("reconcilePublishedEndpoints: %v", err)
+	}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if got := configMap.Data["engine.port"]; got != "8080" {
+		t.Errorf("engine.port = %q, want 8080", got)
+	}
+	if got := configMap.Data["engine.url"]; got != "http://skyflo-engine.default.svc:8080" {
+		t.Errorf("engine.url = %q, want the new port", got)
+	}
+	if got := configMap.Data["ui.port"]; got != "80" {
+		t.Errorf("ui.port = %q, want 80", got)
+	}
+
+	// Disabling publishing removes the ConfigMap.
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.PublishEndpoints = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("endpoints ConfigMap kept after disabling publishing: %v", err)
+	}
+}
+
+func TestPublishEndpointsAgentlessMCP(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.PublishEndpoints = &skyflov1.PublishSpec{Enabled: true}
+	skyflo.Spec.MCP.Agentless = true
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo-endpoints"}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"mcp.host", "mcp.port", "mcp.url"} {
+		if _, ok := configMap.Data[key]; ok {
+			t.Errorf("%s published for an MCP without a Service", key)
+		}
+	}
+	if configMap.Data["engine.host"] == "" {
+		t.Errorf("engine endpoint missing: %v", configMap.Data)
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// envReference matches a $(NAME) reference to another variable.
+var envReference = regexp.MustCompile(`\$\(([^)]+)\)`)
+
+// sortEnv orders the env of every container of the pod template, so an
+// unchanged spec always renders a byte-identical template whatever order
+// the generated and user variables were merged in.
+func sortEnv(template *corev1.PodTemplateSpec) {
+	for i := range template.Spec.InitContainers {
+		template.Spec.InitContainers[i].Env = orderEnv(template.Spec.InitContainers[i].Env)
+	}
+	for i := range template.Spec.Containers {
+		template.Spec.Containers[i].Env = orderEnv(template.Spec.Containers[i].Env)
+	}
+}
+
+// orderEnv sorts env by name. A variable referencing others through
+// $(NAME) is placed after them, as the kubelet only expands references to
+// variables defined earlier. Of duplicate names the last entry, the one the
+// kubelet would apply, is kept; the controller merges user variables after
+// the ones it generates, so user variables take precedence.
+func orderEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	if len(env) < 2 {
+		return env
+	}
+
+	byName := make(map[string]corev1.EnvVar, len(env))
+	for _, v := range env {
+		byName[v.Name] = v
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deps := make(map[string][]string, len(names))
+	for _, name := range names {
+		// $$( escapes a reference.
+		value := strings.ReplaceAll(byName[name].Value, "$$", "")
+		for _, match := range envReference.FindAllStringSubmatch(value, -1) {
+			if ref := match[1]; ref != name {
+				if _, ok := byName[ref]; ok {
+					deps[name] = append(deps[name], ref)
+				}
+			}
+		}
+	}
+
+	ordered := make([]corev1.EnvVar, 0, len(names))
+	placed := make(map[string]bool, len(names))
+	for len(ordered) < len(names) {
+		next := ""
+		for _, name := range names {
+			if placed[name] {
+				continue
+			}
+			if next == "" {
+				// Fallback for reference cycles, which never expand.
+				next = name
+			}
+			if depsPlaced(deps[name], placed) {
+				next = name
+				break
+			}
+		}
+		placed[next] = true
+		ordered = append(ordered, byName[next])
+	}
+	return ordered
+}
+
+func depsPlaced(deps []string, placed map[string]bool) bool {
+	for _, dep := range deps {
+		if !placed[dep] {
+			return false
+		}
+	}
+	return true
+}
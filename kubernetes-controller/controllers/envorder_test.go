@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// envNames returns the names of env in order.
+func envNames(env []corev1.EnvVar) []string {
+	names := make([]string, 0, len(env))
+	for _, v := range env {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func TestOrderEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []corev1.EnvVar
+		want []corev1.EnvVar
+	}{
+		{name: "empty"},
+		{
+			name: "sorted by name",
+			env:  []corev1.EnvVar{{Name: "C", Value: "3"}, {Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+			want: []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}, {Name: "C", Value: "3"}},
+		},
+		{
+			name: "last duplicate wins",
+			env:  []corev1.EnvVar{{Name: "B", Value: "generated"}, {Name: "A", Value: "1"}, {Name: "B", Value: "user"}},
+			want: []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "user"}},
+		},
+		{
+			name: "reference after its target",
+			env:  []corev1.EnvVar{{Name: "A_URL", Value: "http://$(Z_HOST):80"}, {Name: "Z_HOST", Value: "engine"}},
+			want: []corev1.EnvVar{{Name: "Z_HOST", Value: "engine"}, {Name: "A_URL", Value: "http://$(Z_HOST):80"}},
+		},
+		{
+			name: "escaped and unknown references",
+			env:  []corev1.EnvVar{{Name: "B", Value: "$$(C) $(MISSING)"}, {Name: "C", Value: "c"}, {Name: "A", Value: "a"}},
+			want: []corev1.EnvVar{{Name: "A", Value: "a"}, {Name: "B", Value: "$$(C) $(MISSING)"}, {Name: "C", Value: "c"}},
+		},
+		{
+			name: "reference cycle",
+			env:  []corev1.EnvVar{{Name: "B", Value: "$(A)"}, {Name: "A", Value: "$(B)"}, {Name: "C", Value: "c"}},
+			want: []corev1.EnvVar{{Name: "C", Value: "c"}, {Name: "A", Value: "$(B)"}, {Name: "B", Value: "$(A)"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderEnv(tt.env); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orderEnv = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvStableAcrossReconciles(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.TotalConcurrency = ptr.To[int32](8)
+	skyflo.Spec.Engine.Queues = []string{"default"}
+	skyflo.Spec.Engine.Env = []corev1.EnvVar{
+		{Name: "ZETA", Value: "z"},
+		{Name: "ALPHA", Value: "a"},
+		{Name: "WORKER_CONCURRENCY", Value: "99"},
+	}
+	r := newTestReconciler([]client.Object{skyflo})
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+
+	var templates []corev1.PodTemplateSpec
+	for i := 0; i < 3; i++ {
+		reconcileOnce(t, r)
+		engine := &appsv1.Deployment{}
+		if err := r.Get(ctx, key, engine); err != nil {
+			t.Fatal(err)
+		}
+		templates = append(templates, engine.Spec.Template)
+	}
+	for i := 1; i < len(templates); i++ {
+		if !reflect.DeepEqual(templates[i], templates[0]) {
+			t.Errorf("reconcile %d rendered a different pod template", i+1)
+		}
+	}
+
+	env := templates[0].Spec.Containers[0].Env
+	names := envNames(env)
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Engine env %v, want it sorted by name", names)
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			t.Errorf("Engine env repeats %s", name)
+		}
+		seen[name] = true
+	}
+	for name, want := range map[string]string{"ALPHA": "a", "ZETA": "z", "QUEUES": "default", "WORKER_CONCURRENCY": "99"} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
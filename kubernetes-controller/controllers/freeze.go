@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// holdForFreeze reports whether a change freeze is in effect. During the
+// freeze only the ChangeFreeze condition is recorded and the SkyfloAI is
+// requeued for the end of the window, when reconciling resumes.
+func (r *SkyfloAIReconciler) holdForFreeze(ctx context.Context, skyflo *skyflov1.SkyfloAI) (ctrl.Result, bool) {
+	remaining := time.Until(r.FreezeUntil)
+	if r.FreezeUntil.IsZero() || remaining <= 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "ChangeFreeze")
+		return ctrl.Result{}, false
+	}
+
+	log.FromContext(ctx).Info("change freeze in effect; not reconciling", "until", r.FreezeUntil.Format(time.RFC3339))
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:               "ChangeFreeze",
+		Status:             metav1.ConditionTrue,
+		Reason:             "FreezeWindow",
+		Message:            fmt.Sprintf("no changes are applied until %s", r.FreezeUntil.Format(time.RFC3339)),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return ctrl.Result{RequeueAfter: remaining}, true
+}
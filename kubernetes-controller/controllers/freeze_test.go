@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// writesTo records the objects, other than SkyfloAIs, written through the
+// client.
+func writesTo(written *[]string) interceptor.Funcs {
+	record := func(obj client.Object) {
+		if _, ok := obj.(*skyflov1.SkyfloAI); !ok {
+			*written = append(*written, fmt.Sprintf("%T %s", obj, obj.GetName()))
+		}
+	}
+	return interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			record(obj)
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			record(obj)
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			record(obj)
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			record(obj)
+			return c.Delete(ctx, obj, opts...)
+		},
+	}
+}
+
+func TestChangeFreeze(t *testing.T) {
+	ctx := context.Background()
+	var written []string
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, writesTo(&written))
+	r.FreezeUntil = time.Now().Add(time.Hour)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if result.RequeueAfter <= 59*time.Minute || result.RequeueAfter > time.Hour {
+		t.Errorf("requeue after %v, want the end of the freeze in an hour", result.RequeueAfter)
+	}
+	if len(written) > 0 {
+		t.Errorf("wrote %v during the freeze, want no writes", written)
+	}
+	err = r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, &appsv1.Deployment{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("engine Deployment during the freeze: err = %v, want NotFound", err)
+	}
+
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(skyflo.Status.Conditions, "ChangeFreeze"); condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("ChangeFreeze = %+v, want True", condition)
+	}
+
+	r.FreezeUntil = time.Now().Add(-time.Minute)
+	reconcileOnce(t, r)
+	if len(written) == 0 {
+		t.Error("no writes after the freeze, want reconciling to resume")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("engine Deployment after the freeze: %v", err)
+	}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(skyflo.Status.Conditions, "ChangeFreeze"); condition != nil {
+		t.Errorf("ChangeFreeze = %+v after the freeze, want it removed", condition)
+	}
+}
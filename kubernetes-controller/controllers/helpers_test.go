@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func testScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(skyflov1.AddToScheme(scheme))
+	return scheme
+}
+
+// newTestReconciler returns a reconciler backed by a fake client holding
+// objs. funcs, if given, intercept the client's calls.
+func newTestReconciler(objs []client.Object, funcs ...interceptor.Funcs) *SkyfloAIReconciler {
+	return newTestReconcilerWithKinds(nil, objs, funcs...)
+}
+
+// newTestReconcilerWithKinds is newTestReconciler on a cluster that also
+// serves the given namespaced custom kinds, such as the Prometheus Operator
+// ones.
+func newTestReconcilerWithKinds(kinds []schema.GroupVersionKind, objs []client.Object, funcs ...interceptor.Funcs) *SkyfloAIReconciler {
+	scheme := testScheme()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range scheme.AllKnownTypes() {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	for _, gvk := range kinds {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	builder := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithObjects(objs...).
+		WithStatusSubresource(&skyflov1.SkyfloAI{})
+	if len(funcs) > 0 {
+		builder = builder.WithInterceptorFuncs(funcs[0])
+	}
+	return &SkyfloAIReconciler{Client: builder.Build(), Scheme: scheme}
+}
+
+// testSkyfloAI returns a minimal SkyfloAI named skyflo in namespace default.
+func testSkyfloAI() *skyflov1.SkyfloAI {
+	return &skyflov1.SkyfloAI{
+		ObjectMeta: metav1.ObjectMeta{Name: "skyflo", Namespace: "default", UID: "skyflo-uid", Generation: 1},
+		Spec: skyflov1.SkyfloAISpec{
+			UI:     skyflov1.UISpec{ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/ui:test"}},
+			Engine: skyflov1.EngineSpec{ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/engine:test"}},
+			MCP:    skyflov1.MCPSpec{ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/mcp:test"}},
+		},
+	}
+}
+
+// envValue returns the value of the named variable in env, or "" if unset.
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// ownedBy makes obj controlled by skyflo, as if the controller created it.
+func ownedBy(skyflo *skyflov1.SkyfloAI, obj client.Object) client.Object {
+	utilruntime.Must(controllerutil.SetControllerReference(skyflo, obj, testScheme()))
+	return obj
+}
+
+// markReady reports the Deployment of each named component as fully rolled
+// out.
+func markReady(t *testing.T, r *SkyfloAIReconciler, names ...string) {
+	t.Helper()
+	ctx := context.Background()
+	for _, name := range names {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, deployment); err != nil {
+			t.Fatal(err)
+		}
+		replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+		deployment.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: deployment.Generation,
+			Replicas:           replicas,
+			ReadyReplicas:      replicas,
+			AvailableReplicas:  replicas,
+			UpdatedReplicas:    replicas,
+		}
+		if err := r.Status().Update(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// imageDigestAnnotation records on the pod template the digest a tracked
+// image tag resolved to. A new digest rolls the pods onto the pushed image.
+const imageDigestAnnotation = "skyflo.ai/image-digest"
+
+// DigestResolver resolves an image reference to the digest its tag currently
+// points to.
+type DigestResolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// stampImageDigest annotates the pod template of a component tracking its
+// image tag with the tag's current digest, and makes the container always pull
+// so restarted pods do not run a node's cached copy of the old image. When the
+// digest cannot be resolved the previously recorded one is kept, so registry
+// outages never roll pods.
+func (r *SkyfloAIReconciler) stampImageDigest(ctx context.Context, c component, deployment *appsv1.Deployment) error {
+	if !c.spec.TrackTag || r.DigestResolver == nil || strings.Contains(c.spec.Image, "@") {
+		return nil
+	}
+
+	current := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	digest := current.Spec.Template.Annotations[imageDigestAnnotation]
+
+	resolved, err := r.DigestResolver.Resolve(ctx, c.spec.Image)
+	if err != nil {
+		log.FromContext(ctx).Info("failed to resolve image digest; keeping the recorded one",
+			"component", c.displayName, "image", c.spec.Image, "reason", err.Error())
+	} else {
+		digest = resolved
+	}
+
+	deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+	if digest == "" {
+		return nil
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[imageDigestAnnotation] = digest
+	return nil
+}
+
+// RegistryResolver resolves image digests anonymously through the OCI
+// distribution API. Results are cached for the poll interval so frequent
+// reconciles do not hammer the registry.
+type RegistryResolver struct {
+	client   *http.Client
+	interval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]resolvedDigest
+}
+
+type resolvedDigest struct {
+	digest   string
+	resolved time.Time
+}
+
+// NewRegistryResolver returns a resolver that looks up each image at most
+// once per interval.
+func NewRegistryResolver(interval time.Duration) *RegistryResolver {
+	return &RegistryResolver{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		interval: interval,
+		cache:    map[string]resolvedDigest{},
+	}
+}
+
+// Resolve implements DigestResolver.
+func (r *RegistryResolver) Resolve(ctx context.Context, image string) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[image]
+	r.mu.Unlock()
+	if ok && time.Since(cached.resolved) < r.interval {
+		return cached.digest, nil
+	}
+
+	digest, err := r.fetchDigest(ctx, image)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[image] = resolvedDigest{digest: digest, resolved: time.Now()}
+	r.mu.Unlock()
+	return digest, nil
+}
+
+// manifestMediaTypes are the manifest and index types a tag may point to.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+func (r *RegistryResolver) fetchDigest(ctx context.Context, image string) (string, error) {
+	registry, repository, tag := parseImage(image)
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag)
+
+	resp, err := r.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := r.anonymousToken(ctx, resp.Header.Get("WWW-Authenticate"), repository)
+		if err != nil {
+			return "", err
+		}
+		if resp, err = r.headManifest(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s", resp.Status, image)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not report a digest for %s", image)
+	}
+	return digest, nil
+}
+
+func (r *RegistryResolver) headManifest(ctx context.Context, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// anonymousToken requests a pull token from the bearer realm named in a
+// registry's authentication challenge.
+func (r *RegistryResolver) anonymousToken(ctx context.Context, challenge, repository string) (string, error) {
+	params := parseBearerChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("unsupported registry authentication challenge %q", challenge)
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+repository+":pull")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseBearerChallenge returns the parameters of a WWW-Authenticate Bearer
+// challenge.
+func parseBearerChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	rest, ok := strings.CutPrefix(challenge, "Bearer ")
+	if !ok {
+		return params
+	}
+	for _, part := range strings.Split(rest, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	return params
+}
+
+// parseImage splits an image reference into its registry host, repository
+// and tag, applying the Docker Hub defaults.
+func parseImage(image string) (registry, repository, tag string) {
+	registry = "registry-1.docker.io"
+	repository = image
+	if first, rest, ok := strings.Cut(image, "/"); ok &&
+		(strings.ContainsAny(first, ".:") || first == "localhost") {
+		registry, repository = first, rest
+	}
+	if registry == "docker.io" {
+		registry = "registry-1.docker.io"
+	}
+	if registry == "registry-1.docker.io" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	tag = "latest"
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	return registry, repository, tag
+}
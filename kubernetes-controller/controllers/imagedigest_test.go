@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeResolver resolves every image to digest, or fails with err.
+type fakeResolver struct {
+	digest string
+	err    error
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, _ string) (string, error) {
+	return f.digest, f.err
+}
+
+func TestTrackTagRollout(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Image = "skyflo/engine:stable"
+	skyflo.Spec.Engine.TrackTag = true
+	resolver := &fakeResolver{digest: "sha256:aaa"}
+	r := newTestReconciler([]client.Object{skyflo})
+	r.DigestResolver = resolver
+	r.DigestPollInterval = 5 * time.Minute
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	reconcile := func() *appsv1.Deployment {
+		t.Helper()
+		result, err := r.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if result.RequeueAfter != 5*time.Minute {
+			t.Errorf("RequeueAfter = %v, want the poll interval", result.RequeueAfter)
+		}
+		engine := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+			t.Fatal(err)
+		}
+		return engine
+	}
+
+	engine := reconcile()
+	if got := engine.Spec.Template.Annotations[imageDigestAnnotation]; got != "sha256:aaa" {
+		t.Errorf("digest annotation = %q, want sha256:aaa", got)
+	}
+	if got := engine.Spec.Template.Spec.Containers[0].ImagePullPolicy; got != corev1.PullAlways {
+		t.Errorf("imagePullPolicy = %q, want Always", got)
+	}
+
+	resolver.digest = "sha256:bbb"
+	if got := reconcile().Spec.Template.Annotations[imageDigestAnnotation]; got != "sha256:bbb" {
+		t.Errorf("digest annotation after a push = %q, want sha256:bbb", got)
+	}
+
+	resolver.err = errors.New("registry unavailable")
+	if got := reconcile().Spec.Template.Annotations[imageDigestAnnotation]; got != "sha256:bbb" {
+		t.Errorf("digest annotation during a registry outage = %q, want sha256:bbb kept", got)
+	}
+
+	ui := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}, ui); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ui.Spec.Template.Annotations[imageDigestAnnotation]; ok {
+		t.Errorf("untracked UI has digest annotation %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// uiIngressEnabled reports whether the UI is exposed through an Ingress.
+func uiIngressEnabled(skyflo *skyflov1.SkyfloAI) bool {
+	return skyflo.Spec.UI.Ingress != nil && skyflo.Spec.UI.Ingress.Enabled
+}
+
+// reconcileUIIngress maintains the Ingress routing to the UI Service,
+// removing it once disabled. The external-dns annotations of the UI move
+// from the Service to the Ingress while it is enabled.
+func (r *SkyfloAIReconciler) reconcileUIIngress(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	name := skyflo.Name + "-ui"
+	if !uiIngressEnabled(skyflo) {
+		return r.deleteIfOwned(ctx, skyflo, &networkingv1.Ingress{}, name)
+	}
+	spec := skyflo.Spec.UI.Ingress
+
+	annotations := externalDNSAnnotations(skyflo.Spec.UI)
+	if len(spec.Annotations) > 0 && annotations == nil {
+		annotations = make(map[string]string, len(spec.Annotations))
+	}
+	for key, value := range spec.Annotations {
+		annotations[key] = value
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   skyflo.Namespace,
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: spec.ClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: spec.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: name,
+									Port: networkingv1.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		tls := networkingv1.IngressTLS{SecretName: spec.TLSSecretName}
+		if spec.Host != "" {
+			tls.Hosts = []string{spec.Host}
+		}
+		ingress.Spec.TLS = []networkingv1.IngressTLS{tls}
+	}
+	if err := r.own(skyflo, ingress); err != nil {
+		return err
+	}
+	return r.createOrUpdateIngress(ctx, ingress)
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateIngress(ctx context.Context, ingress *networkingv1.Ingress) error {
+	found := &networkingv1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, ingress)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, ingress, "Ingress"); err != nil {
+		return err
+	}
+
+	ingress.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, ingress)
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestExternalDNSAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		ui   skyflov1.UISpec
+		want map[string]string
+	}{
+		{name: "no DNS name"},
+		{name: "TTL without a name", ui: skyflov1.UISpec{DNSTTL: ptr.To[int32](60)}},
+		{
+			name: "hostname",
+			ui:   skyflov1.UISpec{DNSName: "skyflo.example.com"},
+			want: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "skyflo.example.com"},
+		},
+		{
+			name: "hostname and TTL",
+			ui:   skyflov1.UISpec{DNSName: "skyflo.example.com", DNSTTL: ptr.To[int32](300)},
+			want: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "skyflo.example.com",
+				"external-dns.alpha.kubernetes.io/ttl":      "300",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := externalDNSAnnotations(tt.ui); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("externalDNSAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalDNSAnnotationsTarget(t *testing.T) {
+	const hostname = "external-dns.alpha.kubernetes.io/hostname"
+	for _, ingress := range []bool{false, true} {
+		skyflo := testSkyfloAI()
+		skyflo.Spec.UI.DNSName = "skyflo.example.com"
+		if ingress {
+			skyflo.Spec.UI.Ingress = &skyflov1.IngressSpec{Enabled: true, Host: "skyflo.example.com"}
+		}
+		r := newTestReconciler(nil)
+
+		service := r.service(skyflo, components(skyflo)[0])
+		if _, ok := service.Annotations[hostname]; ok == ingress {
+			t.Errorf("with ingress %v, Service annotated = %v", ingress, ok)
+		}
+		if err := r.reconcileUIIngress(context.Background(), skyflo); err != nil {
+			t.Fatalf("reconcileUIIngress: %v", err)
+		}
+		if !ingress {
+			continue
+		}
+		got := &networkingv1.Ingress{}
+		if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}, got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Annotations[hostname] != "skyflo.example.com" {
+			t.Errorf("Ingress annotations = %v", got.Annotations)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	internalTLSVolume    = "internal-tls"
+	internalTLSMountPath = "/etc/skyflo/tls"
+
+	// internalTLSHashKey records on the pod template a hash of the mounted
+	// certificate, so a rotation rolls the pods onto the new one.
+	internalTLSHashKey = "skyflo.ai/internal-tls-hash"
+
+	defaultRotationDays = int32(30)
+	internalCAValidity  = 10 * 365 * 24 * time.Hour
+
+	// internalTLSRecheckInterval is how often certificates are checked for
+	// rotation; nothing else triggers a reconcile when one comes due.
+	internalTLSRecheckInterval = time.Hour
+)
+
+// now returns the current time from the reconciler's clock.
+func (r *SkyfloAIReconciler) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock.Now()
+	}
+	return time.Now()
+}
+
+func internalCAName(skyflo *skyflov1.SkyfloAI) string {
+	return skyflo.Name + "-internal-ca"
+}
+
+func internalTLSName(skyflo *skyflov1.SkyfloAI, component string) string {
+	return skyflo.Name + "-" + component + "-tls"
+}
+
+// internalTLSEnabled reports whether internal mTLS is configured.
+func internalTLSEnabled(skyflo *skyflov1.SkyfloAI) bool {
+	return skyflo.Spec.InternalTLS != nil && skyflo.Spec.InternalTLS.Enabled
+}
+
+// reconcileInternalTLS maintains the internal CA and a certificate per
+// component for component-to-component mTLS. Certificates are reissued once
+// they are RotationDays old and stay valid for twice as long, so pods that
+// have not rolled yet keep working. Everything is removed once disabled.
+func (r *SkyfloAIReconciler) reconcileInternalTLS(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	comps := components(skyflo)
+	if !internalTLSEnabled(skyflo) {
+		for _, c := range comps {
+			if err := r.deleteIfOwned(ctx, skyflo, &corev1.Secret{}, internalTLSName(skyflo, c.name)); err != nil {
+				return err
+			}
+		}
+		return r.deleteIfOwned(ctx, skyflo, &corev1.Secret{}, internalCAName(skyflo))
+	}
+
+	ca, err := r.internalCA(ctx, skyflo)
+	if err != nil {
+		return err
+	}
+	for _, c := range comps {
+		if err := r.reconcileComponentCert(ctx, skyflo, ca, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyPair is a parsed certificate with its key and PEM encodings.
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// internalCA loads the internal CA, generating it when missing or about to
+// expire.
+func (r *SkyfloAIReconciler) internalCA(ctx context.Context, skyflo *skyflov1.SkyfloAI) (*keyPair, error) {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: internalCAName(skyflo), Namespace: skyflo.Namespace}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		ca, parseErr := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if parseErr == nil && r.now().Before(ca.cert.NotAfter.Add(-rotationPeriod(skyflo)*2)) {
+			return ca, nil
+		}
+	}
+
+	ca, err := issue(r.now(), internalCAValidity, "skyflo-internal-ca/"+skyflo.Namespace+"/"+skyflo.Name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ca, r.writeTLSSecret(ctx, skyflo, internalCAName(skyflo), map[string][]byte{
+		corev1.TLSCertKey:       ca.certPEM,
+		corev1.TLSPrivateKeyKey: ca.keyPEM,
+	})
+}
+
+// reconcileComponentCert reissues a component's certificate when missing,
+// due for rotation, or signed by another CA.
+func (r *SkyfloAIReconciler) reconcileComponentCert(ctx context.Context, skyflo *skyflov1.SkyfloAI, ca *keyPair, c component) error {
+	name := internalTLSName(skyflo, c.name)
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: skyflo.Namespace}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && bytes.Equal(secret.Data["ca.crt"], ca.certPEM) {
+		leaf, parseErr := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if parseErr == nil && r.now().Before(leaf.cert.NotBefore.Add(rotationPeriod(skyflo))) {
+			return nil
+		}
+	}
+
+	service := skyflo.Name + "-" + c.name
+	dnsNames := []string{
+		service,
+		service + "." + skyflo.Namespace,
+		service + "." + skyflo.Namespace + ".svc",
+		service + "." + skyflo.Namespace + ".svc.cluster.local",
+	}
+	leaf, err := issue(r.now(), 2*rotationPeriod(skyflo), service, dnsNames, ca)
+	if err != nil {
+		return err
+	}
+	return r.writeTLSSecret(ctx, skyflo, name, map[string][]byte{
+		corev1.TLSCertKey:       leaf.certPEM,
+		corev1.TLSPrivateKeyKey: leaf.keyPEM,
+		"ca.crt":                ca.certPEM,
+	})
+}
+
+func (r *SkyfloAIReconciler) writeTLSSecret(ctx context.Context, skyflo *skyflov1.SkyfloAI, name string, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: data,
+	}
+	if err := r.own(skyflo, secret); err != nil {
+		return err
+	}
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: skyflo.Namespace}, found)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.create(ctx, secret)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, secret, "Secret"); err != nil {
+		return err
+	}
+
+	secret.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, secret)
+}
+
+// rotationPeriod returns how long a component certificate is used before it
+// is reissued.
+func rotationPeriod(skyflo *skyflov1.SkyfloAI) time.Duration {
+	days := skyflo.Spec.InternalTLS.RotationDays
+	if days <= 0 {
+		days = defaultRotationDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// issue creates an ECDSA key and a certificate for it, self-signed when ca
+// is nil.
+func issue(now time.Time, validity time.Duration, commonName string, dnsNames []string, ca *keyPair) (*keyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+	}
+	parent, signer := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		parent, signer = ca.cert, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeyPair(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	)
+}
+
+func parseKeyPair(certPEM, keyPEM []byte) (*keyPair, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &keyPair{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}, nil
+}
+
+// mountInternalTLS mounts the component certificate into its container,
+// points TLS_CERT_FILE, TLS_KEY_FILE and TLS_CA_FILE at it, and annotates the
+// pod template with a hash of the certificate so rotations roll the pods.
+func (r *SkyfloAIReconciler) mountInternalTLS(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component, deployment *appsv1.Deployment) error {
+	if !internalTLSEnabled(skyflo) {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	name := internalTLSName(skyflo, c.name)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: skyflo.Namespace}, secret); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(secret.Data[corev1.TLSCertKey])
+
+	template := &deployment.Spec.Template
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[internalTLSHashKey] = hex.EncodeToString(sum[:])[:16]
+
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: internalTLSVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: name},
+		},
+	})
+	container := &template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      internalTLSVolume,
+		MountPath: internalTLSMountPath,
+		ReadOnly:  true,
+	})
+	container.Env = mergeEnv([]corev1.EnvVar{
+		{Name: "TLS_CERT_FILE", Value: internalTLSMountPath + "/" + corev1.TLSCertKey},
+		{Name: "TLS_KEY_FILE", Value: internalTLSMountPath + "/" + corev1.TLSPrivateKeyKey},
+		{Name: "TLS_CA_FILE", Value: internalTLSMountPath + "/ca.crt"},
+	}, container.Env)
+	return nil
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestInternalTLS(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.InternalTLS = &skyflov1.InternalTLSSpec{Enabled: true, RotationDays: 10}
+	r := newTestReconciler([]client.Object{skyflo})
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(start)
+	r.Clock = clock
+
+	secret := func(name string) *corev1.Secret {
+		t.Helper()
+		s := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	engineHash := func() string {
+		t.Helper()
+		d := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, d); err != nil {
+			t.Fatal(err)
+		}
+		return d.Spec.Template.Annotations[internalTLSHashKey]
+	}
+
+	reconcileOnce(t, r)
+	ca := secret("skyflo-internal-ca")
+	caCert, err := parseKeyPair(ca.Data[corev1.TLSCertKey], ca.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert.cert)
+	for _, name := range []string{"ui", "engine", "mcp"} {
+		leafSecret := secret("skyflo-" + name + "-tls")
+		leaf, err := parseKeyPair(leafSecret.Data[corev1.TLSCertKey], leafSecret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			t.Fatalf("%s certificate: %v", name, err)
+		}
+		if !bytes.Equal(leafSecret.Data["ca.crt"], ca.Data[corev1.TLSCertKey]) {
+			t.Errorf("%s ca.crt is not the internal CA", name)
+		}
+		if _, err := leaf.cert.Verify(x509.VerifyOptions{
+			DNSName:     "skyflo-" + name + ".default.svc",
+			Roots:       roots,
+			CurrentTime: start,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}); err != nil {
+			t.Errorf("%s certificate does not verify: %v", name, err)
+		}
+		if want := start.Add(20 * 24 * time.Hour); !leaf.cert.NotAfter.Equal(want) {
+			t.Errorf("%s certificate expires %v, want twice the rotation period, %v", name, leaf.cert.NotAfter, want)
+		}
+	}
+
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	var mounted bool
+	for _, volume := range engine.Spec.Template.Spec.Volumes {
+		mounted = mounted || volume.Secret != nil && volume.Secret.SecretName == "skyflo-engine-tls"
+	}
+	if !mounted {
+		t.Errorf("volumes = %v, want skyflo-engine-tls", engine.Spec.Template.Spec.Volumes)
+	}
+	container := engine.Spec.Template.Spec.Containers[0]
+	if got := envValue(container.Env, "TLS_CERT_FILE"); got != internalTLSMountPath+"/tls.crt" {
+		t.Errorf("TLS_CERT_FILE = %q", got)
+	}
+	if got := envValue(container.Env, "TLS_CA_FILE"); got != internalTLSMountPath+"/ca.crt" {
+		t.Errorf("TLS_CA_FILE = %q", got)
+	}
+	issued := engineHash()
+	if issued == "" {
+		t.Fatal("no certificate hash on the engine pod template")
+	}
+	firstCert := secret("skyflo-engine-tls").Data[corev1.TLSCertKey]
+
+	clock.SetTime(start.Add(5 * 24 * time.Hour))
+	reconcileOnce(t, r)
+	if !bytes.Equal(secret("skyflo-engine-tls").Data[corev1.TLSCertKey], firstCert) || engineHash() != issued {
+		t.Error("certificate reissued before its rotation was due")
+	}
+
+	clock.SetTime(start.Add(11 * 24 * time.Hour))
+	reconcileOnce(t, r)
+	if bytes.Equal(secret("skyflo-engine-tls").Data[corev1.TLSCertKey], firstCert) {
+		t.Error("certificate not rotated after the rotation period")
+	}
+	if engineHash() == issued {
+		t.Error("pod template hash unchanged after rotation, want a rollout")
+	}
+	if !bytes.Equal(secret("skyflo-internal-ca").Data[corev1.TLSCertKey], ca.Data[corev1.TLSCertKey]) {
+		t.Error("CA reissued with the component certificates")
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.InternalTLS.Enabled = false
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	for _, name := range []string{"skyflo-internal-ca", "skyflo-engine-tls"} {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+			t.Errorf("%s after disabling: err = %v, want NotFound", name, err)
+		}
+	}
+	if hash := engineHash(); hash != "" {
+		t.Errorf("certificate hash %q still on the engine pod template after disabling", hash)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	// kubeconfigKey is the key of the kubeconfig in the MCP kubeconfig Secret.
+	kubeconfigKey = "kubeconfig"
+
+	kubeconfigVolume    = "kubeconfig"
+	kubeconfigMountPath = "/etc/skyflo/kubeconfig"
+
+	// kubeconfigFile is the file the kubeconfig is projected to, the name
+	// kubectl uses for its own config.
+	kubeconfigFile = "config"
+
+	// kubeconfigCheckTimeout bounds the /version request to the target
+	// API server.
+	kubeconfigCheckTimeout = 5 * time.Second
+)
+
+// KubeconfigChecker verifies that the API server a kubeconfig points at
+// answers.
+type KubeconfigChecker func(ctx context.Context, config *rest.Config) error
+
+// CheckServerVersion is a KubeconfigChecker requesting /version from the
+// target API server.
+func CheckServerVersion(ctx context.Context, config *rest.Config) error {
+	config = rest.CopyConfig(config)
+	config.Timeout = kubeconfigCheckTimeout
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	_, err = client.ServerVersion()
+	return err
+}
+
+// kubeconfigReady reports whether the MCP kubeconfig, if any, parses and,
+// when a KubeconfigChecker is set, reaches its API server. The outcome is
+// recorded in the KubeconfigUnreachable condition.
+func (r *SkyfloAIReconciler) kubeconfigReady(ctx context.Context, skyflo *skyflov1.SkyfloAI) (bool, error) {
+	name := skyflo.Spec.MCP.KubeconfigSecret
+	if name == "" {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "KubeconfigUnreachable")
+		return true, nil
+	}
+
+	reason, message, err := r.checkKubeconfig(ctx, skyflo.Namespace, name)
+	if err != nil {
+		return false, err
+	}
+	if reason != "" {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "KubeconfigUnreachable",
+			Status:             metav1.ConditionTrue,
+			Reason:             reason,
+			Message:            "holding the MCP rollout: " + message,
+			ObservedGeneration: skyflo.Generation,
+		})
+		return false, nil
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:               "KubeconfigUnreachable",
+		Status:             metav1.ConditionFalse,
+		Reason:             "KubeconfigValid",
+		Message:            fmt.Sprintf("kubeconfig in Secret %s is valid", name),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return true, nil
+}
+
+// checkKubeconfig returns the reason and message of a kubeconfig the MCP
+// cannot use, or an empty reason when it is usable.
+func (r *SkyfloAIReconciler) checkKubeconfig(ctx context.Context, namespace, name string) (string, string, error) {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+	if errors.IsNotFound(err) {
+		return "SecretNotFound", fmt.Sprintf("Secret %s not found", name), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	data, ok := secret.Data[kubeconfigKey]
+	if !ok {
+		return "InvalidKubeconfig", fmt.Sprintf("Secret %s has no %q key", name, kubeconfigKey), nil
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return "InvalidKubeconfig", fmt.Sprintf("kubeconfig in Secret %s is invalid: %v", name, err), nil
+	}
+
+	if r.KubeconfigChecker != nil {
+		if err := r.KubeconfigChecker(ctx, config); err != nil {
+			return "APIServerUnreachable", fmt.Sprintf("API server %s of the kubeconfig in Secret %s is unreachable: %v",
+				config.Host, name, err), nil
+		}
+	}
+	return "", "", nil
+}
+
+// mountKubeconfig projects the kubeconfig of the MCP kubeconfig Secret into
+// the MCP container, where KUBECONFIG, honored by kubectl and helm, points
+// at it. The Secret name is part of the pod template, so pointing
+// kubeconfigSecret at another Secret rolls the MCP.
+func mountKubeconfig(secret string, deployment *appsv1.Deployment) {
+	spec := &deployment.Spec.Template.Spec
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: kubeconfigVolume,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+						Items:                []corev1.KeyToPath{{Key: kubeconfigKey, Path: kubeconfigFile}},
+					},
+				}},
+			},
+		},
+	})
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      kubeconfigVolume,
+		MountPath: kubeconfigMountPath,
+		ReadOnly:  true,
+	})
+}
+
+func kubeconfigEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{{Name: "KUBECONFIG", Value: kubeconfigMountPath + "/" + kubeconfigFile}}
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: target
+  cluster:
+    server: https://target.example.com:6443
+contexts:
+- name: target
+  context:
+    cluster: target
+    user: mcp
+current-context: target
+users:
+- name: mcp
+  user:
+    token: test
+`
+
+func kubeconfigSecret(data string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "target-kubeconfig", Namespace: "default"},
+		Data:       map[string][]byte{kubeconfigKey: []byte(data)},
+	}
+}
+
+func TestKubeconfigReachability(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		checkErr   error
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantHost   string
+	}{
+		{
+			name:       "reachable",
+			kubeconfig: testKubeconfig,
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "KubeconfigValid",
+			wantHost:   "https://target.example.com:6443",
+		},
+		{
+			name:       "unreachable",
+			kubeconfig: testKubeconfig,
+			checkErr:   fmt.Errorf("connection refused"),
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "APIServerUnreachable",
+			wantHost:   "https://target.example.com:6443",
+		},
+		{
+			name:       "unparseable",
+			kubeconfig: "not: [a kubeconfig",
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "InvalidKubeconfig",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.MCP.KubeconfigSecret = "target-kubeconfig"
+			r := newTestReconciler([]client.Object{skyflo, kubeconfigSecret(tt.kubeconfig)})
+			var checkedHost string
+			r.KubeconfigChecker = func(_ context.Context, config *rest.Config) error {
+				checkedHost = config.Host
+				return tt.checkErr
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+
+			result, err := r.Reconcile(ctx, req)
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			if checkedHost != tt.wantHost {
+				t.Errorf("checked host = %q, want %q", checkedHost, tt.wantHost)
+			}
+
+			got := &skyflov1.SkyfloAI{}
+			if err := r.Get(ctx, req.NamespacedName, got); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(got.Status.Conditions, "KubeconfigUnreachable")
+			if condition == nil || condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Errorf("KubeconfigUnreachable = %+v, want %s/%s", condition, tt.wantStatus, tt.wantReason)
+			}
+
+			err = r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-mcp"}, &appsv1.Deployment{})
+			if tt.wantStatus == metav1.ConditionTrue {
+				if !errors.IsNotFound(err) {
+					t.Errorf("MCP rolled out with an unusable kubeconfig: %v", err)
+				}
+				if result.RequeueAfter == 0 || result.RequeueAfter > dependencyPollInterval {
+					t.Errorf("RequeueAfter = %v, want at most %v to recheck the kubeconfig", result.RequeueAfter, dependencyPollInterval)
+				}
+			} else if err != nil {
+				t.Errorf("MCP Deployment not created: %v", err)
+			}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}, &appsv1.Deployment{}); err != nil {
+				t.Errorf("UI held back by the MCP kubeconfig: %v", err)
+			}
+		})
+	}
+}
+
+func TestKubeconfigSecretMissing(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.MCP.KubeconfigSecret = "target-kubeconfig"
+	r := newTestReconciler([]client.Object{skyflo})
+	r.KubeconfigChecker = func(context.Context, *rest.Config) error {
+		t.Error("checker called without a kubeconfig")
+		return nil
+	}
+	reconcileOnce(t, r)
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, "KubeconfigUnreachable")
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "SecretNotFound" {
+		t.Errorf("KubeconfigUnreachable = %+v, want True/SecretNotFound", condition)
+	}
+}
+
+// mcpKubeconfigSecret returns the Secret the MCP pod template projects its
+// kubeconfig from, and the template's spec hash.
+func mcpKubeconfigSecret(t *testing.T, r *SkyfloAIReconciler) (string, string) {
+	t.Helper()
+	mcp := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo-mcp"}, mcp); err != nil {
+		t.Fatal(err)
+	}
+	for _, volume := range mcp.Spec.Template.Spec.Volumes {
+		if volume.Name == kubeconfigVolume && volume.Projected != nil && len(volume.Projected.Sources) == 1 {
+			return volume.Projected.Sources[0].Secret.Name, mcp.Annotations[specHashAnnotation]
+		}
+	}
+	t.Fatalf("MCP volumes = %+v, want the kubeconfig volume", mcp.Spec.Template.Spec.Volumes)
+	return "", ""
+}
+
+func TestKubeconfigSecretRotation(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.MCP.KubeconfigSecret = "target-kubeconfig"
+	rotated := kubeconfigSecret(testKubeconfig)
+	rotated.Name = "rotated-kubeconfig"
+	r := newTestReconciler([]client.Object{skyflo, kubeconfigSecret(testKubeconfig), rotated})
+	reconcileOnce(t, r)
+	secret, hash := mcpKubeconfigSecret(t, r)
+	if secret != "target-kubeconfig" {
+		t.Fatalf("MCP kubeconfig Secret = %q, want target-kubeconfig", secret)
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.MCP.KubeconfigSecret = "rotated-kubeconfig"
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	rotatedSecret, rotatedHash := mcpKubeconfigSecret(t, r)
+	if rotatedSecret != "rotated-kubeconfig" {
+		t.Errorf("MCP kubeconfig Secret = %q after rotating, want rotated-kubeconfig", rotatedSecret)
+	}
+	if rotatedHash == hash {
+		t.Errorf("MCP spec hash %s unchanged by the rotation, want the pod template to roll", hash)
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// reconcileLimitRange maintains the namespace LimitRange giving containers
+// without explicit resources default requests and limits, removing it once
+// no longer configured.
+func (r *SkyfloAIReconciler) reconcileLimitRange(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	name := skyflo.Name + "-limits"
+	spec := skyflo.Spec.NamespaceLimitRange
+	if spec == nil {
+		return r.deleteIfOwned(ctx, skyflo, &corev1.LimitRange{}, name)
+	}
+
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:           corev1.LimitTypeContainer,
+					Default:        spec.Default,
+					DefaultRequest: spec.DefaultRequest,
+					Max:            spec.Max,
+				},
+			},
+		},
+	}
+	if err := r.own(skyflo, limitRange); err != nil {
+		return err
+	}
+	return r.createOrUpdateLimitRange(ctx, limitRange)
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateLimitRange(ctx context.Context, limitRange *corev1.LimitRange) error {
+	found := &corev1.LimitRange{}
+	err := r.Get(ctx, types.NamespacedName{Name: limitRange.Name, Namespace: limitRange.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, limitRange)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, limitRange, "LimitRange"); err != nil {
+		return err
+	}
+
+	limitRange.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, limitRange)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestNamespaceLimitRange(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	spec := &skyflov1.LimitRangeSpec{
+		Default:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+		DefaultRequest: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+		Max:            corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("8Gi")},
+	}
+	skyflo.Spec.NamespaceLimitRange = spec
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-limits"}
+	limitRange := &corev1.LimitRange{}
+	if err := r.Get(ctx, key, limitRange); err != nil {
+		t.Fatal(err)
+	}
+	if !metav1.IsControlledBy(limitRange, skyflo) {
+		t.Errorf("owner references = %v, want the SkyfloAI", limitRange.OwnerReferences)
+	}
+	want := []corev1.LimitRangeItem{{
+		Type:           corev1.LimitTypeContainer,
+		Default:        spec.Default,
+		DefaultRequest: spec.DefaultRequest,
+		Max:            spec.Max,
+	}}
+	if !equality.Semantic.DeepEqual(limitRange.Spec.Limits, want) {
+		t.Errorf("limits = %+v, want %+v", limitRange.Spec.Limits, want)
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.NamespaceLimitRange.Max = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, limitRange); err != nil {
+		t.Fatal(err)
+	}
+	if max := limitRange.Spec.Limits[0].Max; len(max) > 0 {
+		t.Errorf("max = %v after removing it from the spec, want none", max)
+	}
+
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.NamespaceLimitRange = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, limitRange); !apierrors.IsNotFound(err) {
+		t.Errorf("LimitRange after removal: err = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestHealthCheckNodePort(t *testing.T) {
+	tests := []struct {
+		name      string
+		pinned    *int32
+		allocated int32
+		want      int32
+	}{
+		{name: "pinned on create", pinned: ptr.To[int32](32000), want: 32000},
+		{name: "allocated port preserved", allocated: 31500, want: 31500},
+		{name: "pinned port replaces the allocated one", pinned: ptr.To[int32](32000), allocated: 31500, want: 32000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.UI.LoadBalancer = &skyflov1.LoadBalancerSpec{
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+				HealthCheckNodePort:   tt.pinned,
+			}
+			var objs []client.Object
+			if tt.allocated != 0 {
+				objs = append(objs, ownedBy(skyflo, &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"},
+					Spec: corev1.ServiceSpec{
+						Type:                  corev1.ServiceTypeLoadBalancer,
+						ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+						HealthCheckNodePort:   tt.allocated,
+					},
+				}))
+			}
+			r := newTestReconciler(objs)
+
+			service := r.service(skyflo, components(skyflo)[0])
+			if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				t.Errorf("type = %s, want LoadBalancer", service.Spec.Type)
+			}
+			if err := r.own(skyflo, service); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.createOrUpdateService(ctx, skyflo, service, nil); err != nil {
+				t.Fatalf("createOrUpdateService: %v", err)
+			}
+
+			got := &corev1.Service{}
+			if err := r.Get(ctx, types.NamespacedName{Name: "skyflo-ui", Namespace: "default"}, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Spec.HealthCheckNodePort != tt.want {
+				t.Errorf("healthCheckNodePort = %d, want %d", got.Spec.HealthCheckNodePort, tt.want)
+			}
+			if got.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+				t.Errorf("externalTrafficPolicy = %s, want Local", got.Spec.ExternalTrafficPolicy)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// Cluster-scoped objects, and retained Engine claims, which must outlive the
+// SkyfloAI, record their owning SkyfloAI in these labels instead of an owner
+// reference.
+const (
+	ownerNamespaceLabel = "skyflo.ai/owner-namespace"
+	ownerNameLabel      = "skyflo.ai/owner-name"
+)
+
+// defaultAggregationLabels select the ClusterRoles aggregated into the MCP
+// ClusterRole when clusterRBAC sets no aggregationLabels.
+var defaultAggregationLabels = map[string]string{"skyflo.ai/aggregate-to-mcp": "true"}
+
+func mcpServiceAccountName(skyflo *skyflov1.SkyfloAI) string {
+	return skyflo.Name + "-mcp"
+}
+
+// mcpClusterRoleName is shared by the ClusterRole and its binding, and
+// includes the namespace since both are cluster-scoped.
+func mcpClusterRoleName(skyflo *skyflov1.SkyfloAI) string {
+	return "skyflo:" + skyflo.Namespace + ":" + skyflo.Name + "-mcp"
+}
+
+// mcpClusterRoleEnabled reports whether the MCP ServiceAccount is bound to a
+// generated ClusterRole, aggregated or holding explicit rules.
+func mcpClusterRoleEnabled(skyflo *skyflov1.SkyfloAI) bool {
+	rbac := skyflo.Spec.MCP.RBAC
+	return skyflo.Spec.MCP.ClusterRBAC != nil || (rbac != nil && rbac.Create)
+}
+
+// reconcileMCPClusterRBAC maintains the MCP ServiceAccount and, when
+// configured, its ClusterRole and the binding between them, removing the
+// cluster-scoped objects once no longer configured. The ClusterRole either
+// aggregates labeled ClusterRoles (clusterRBAC) or holds the rules of
+// spec.mcp.rbac.
+func (r *SkyfloAIReconciler) reconcileMCPClusterRBAC(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mcpServiceAccountName(skyflo),
+			Namespace: skyflo.Namespace,
+		},
+	}
+	if linkPullSecrets(skyflo) {
+		serviceAccount.ImagePullSecrets = skyflo.Spec.ImagePullSecrets
+	}
+	if err := r.own(skyflo, serviceAccount); err != nil {
+		return err
+	}
+	if err := r.createOrUpdateServiceAccount(ctx, serviceAccount); err != nil {
+		return err
+	}
+
+	if !mcpClusterRoleEnabled(skyflo) {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "MCPClusterRBACDenied")
+		return r.pruneClusterObjects(ctx, skyflo, r.pruningPolicy(skyflo))
+	}
+	if !r.clusterRBACAllowed(skyflo.Namespace) {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:   "MCPClusterRBACDenied",
+			Status: metav1.ConditionTrue,
+			Reason: "NamespaceNotAllowed",
+			Message: fmt.Sprintf("namespace %s is not listed in --mcp-cluster-rbac-namespaces, so no MCP ClusterRole is generated",
+				skyflo.Namespace),
+			ObservedGeneration: skyflo.Generation,
+		})
+		// Permissions granted before the namespace lost its trust are
+		// revoked whatever the pruning policy.
+		return r.pruneClusterObjects(ctx, skyflo, skyflov1.PruningPolicyDelete)
+	}
+	meta.RemoveStatusCondition(&skyflo.Status.Conditions, "MCPClusterRBACDenied")
+
+	name := mcpClusterRoleName(skyflo)
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: clusterOwnerLabels(skyflo),
+		},
+	}
+	addCommonMetadata(skyflo, role, "mcp")
+	var carryOver func(found client.Object)
+	if spec := skyflo.Spec.MCP.ClusterRBAC; spec != nil {
+		selector := spec.AggregationLabels
+		if len(selector) == 0 {
+			selector = defaultAggregationLabels
+		}
+		role.AggregationRule = &rbacv1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: selector}},
+		}
+		carryOver = func(found client.Object) {
+			// The aggregation controller owns the rules.
+			role.Rules = found.(*rbacv1.ClusterRole).Rules
+		}
+	} else {
+		role.Rules = skyflo.Spec.MCP.RBAC.Rules
+	}
+	if err := r.createOrUpdateClusterObject(ctx, skyflo, role, &rbacv1.ClusterRole{}, "ClusterRole", carryOver); err != nil {
+		return err
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: clusterOwnerLabels(skyflo),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      serviceAccount.Name,
+			Namespace: skyflo.Namespace,
+		}},
+	}
+	addCommonMetadata(skyflo, binding, "mcp")
+	return r.createOrUpdateClusterObject(ctx, skyflo, binding, &rbacv1.ClusterRoleBinding{}, "ClusterRoleBinding", nil)
+}
+
+// clusterRBACAllowed reports whether SkyfloAIs in namespace may have an MCP
+// ClusterRole generated.
+func (r *SkyfloAIReconciler) clusterRBACAllowed(namespace string) bool {
+	for _, allowed := range r.MCPClusterRBACNamespaces {
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneClusterObjects deletes the MCP ClusterRole and ClusterRoleBinding
+// owned by the SkyfloAI or, under the Orphan policy, drops the owner labels
+// and marks them for adoption.
+func (r *SkyfloAIReconciler) pruneClusterObjects(ctx context.Context, skyflo *skyflov1.SkyfloAI, policy skyflov1.PruningPolicy) error {
+	for _, obj := range []client.Object{&rbacv1.ClusterRoleBinding{}, &rbacv1.ClusterRole{}} {
+		err := r.Get(ctx, types.NamespacedName{Name: mcpClusterRoleName(skyflo)}, obj)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !ownsClusterObject(skyflo, obj) {
+			continue
+		}
+
+		if policy == skyflov1.PruningPolicyDelete {
+			if err := client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+				return err
+			}
+			continue
+		}
+		labels := obj.GetLabels()
+		delete(labels, ownerNamespaceLabel)
+		delete(labels, ownerNameLabel)
+		obj.SetLabels(labels)
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[adoptAnnotation] = "true"
+		obj.SetAnnotations(annotations)
+		if err := client.IgnoreNotFound(r.Update(ctx, obj, r.fieldOwner())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func clusterOwnerLabels(skyflo *skyflov1.SkyfloAI) map[string]string {
+	return map[string]string{
+		ownerNamespaceLabel: skyflo.Namespace,
+		ownerNameLabel:      skyflo.Name,
+	}
+}
+
+func ownsClusterObject(skyflo *skyflov1.SkyfloAI, obj client.Object) bool {
+	labels := obj.GetLabels()
+	return labels[ownerNamespaceLabel] == skyflo.Namespace && labels[ownerNameLabel] == skyflo.Name
+}
+
+// createOrUpdateClusterObject writes a cluster-scoped object, adopting an
+// existing one only if the SkyfloAI already owns it or it opted into
+// adoption. carryOver, when set, copies fields managed by others from the
+// existing object.
+func (r *SkyfloAIReconciler) createOrUpdateClusterObject(ctx context.Context, skyflo *skyflov1.SkyfloAI, obj, found client.Object, kind string, carryOver func(found client.Object)) error {
+	err := r.Get(ctx, types.NamespacedName{Name: obj.GetName()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, obj)
+		}
+		return err
+	}
+	if !ownsClusterObject(skyflo, found) && found.GetAnnotations()[adoptAnnotation] != "true" {
+		return &foreignResourceError{kind: kind, name: found.GetName()}
+	}
+
+	// An apply leaves fields it does not set to their managers.
+	if carryOver != nil && !r.ServerSideApply {
+		carryOver(found)
+	}
+	obj.SetResourceVersion(found.GetResourceVersion())
+	return r.update(ctx, obj)
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateServiceAccount(ctx context.Context, serviceAccount *corev1.ServiceAccount) error {
+	found := &corev1.ServiceAccount{}
+	err := r.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, serviceAccount)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, serviceAccount, "ServiceAccount"); err != nil {
+		return err
+	}
+
+	// Keep the token and image pull secrets other controllers attach.
+	serviceAccount.Secrets = found.Secrets
+	serviceAccount.ImagePullSecrets = mergePullSecrets(found.ImagePullSecrets, serviceAccount.ImagePullSecrets)
+	serviceAccount.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, serviceAccount)
+}
+
+// linkPullSecrets reports whether the image pull secrets are linked to the
+// ServiceAccounts the operator manages.
+func linkPullSecrets(skyflo *skyflov1.SkyfloAI) bool {
+	target := skyflo.Spec.ImagePullSecretsTarget
+	return target == skyflov1.ImagePullSecretsTargetServiceAccount || target == skyflov1.ImagePullSecretsTargetPodAndServiceAccount
+}
+
+// mergePullSecrets appends the linked secrets missing from existing.
+// Secrets are never unlinked, since those attached by others cannot be told
+// apart.
+func mergePullSecrets(existing, linked []corev1.LocalObjectReference) []corev1.LocalObjectReference {
+	merged := existing
+	for _, secret := range linked {
+		found := false
+		for _, current := range existing {
+			if current.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, secret)
+		}
+	}
+	return merged
+}
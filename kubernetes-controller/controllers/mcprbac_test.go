@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestMCPAggregatedClusterRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]string
+	}{
+		{name: "default labels", want: defaultAggregationLabels},
+		{name: "custom labels", labels: map[string]string{"rbac.example.com/mcp": "true"}, want: map[string]string{"rbac.example.com/mcp": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.MCP.ClusterRBAC = &skyflov1.MCPClusterRBACSpec{AggregationLabels: tt.labels}
+			r := newTestReconciler([]client.Object{skyflo})
+			r.MCPClusterRBACNamespaces = []string{"default"}
+			reconcileOnce(t, r)
+
+			key := types.NamespacedName{Name: "skyflo:default:skyflo-mcp"}
+			role := &rbacv1.ClusterRole{}
+			if err := r.Get(ctx, key, role); err != nil {
+				t.Fatal(err)
+			}
+			if role.AggregationRule == nil || len(role.AggregationRule.ClusterRoleSelectors) != 1 ||
+				!reflect.DeepEqual(role.AggregationRule.ClusterRoleSelectors[0].MatchLabels, tt.want) {
+				t.Errorf("aggregation rule = %+v, want matchLabels %v", role.AggregationRule, tt.want)
+			}
+			if !ownsClusterObject(skyflo, role) {
+				t.Errorf("ClusterRole labels = %v, want the owner labels", role.Labels)
+			}
+
+			binding := &rbacv1.ClusterRoleBinding{}
+			if err := r.Get(ctx, key, binding); err != nil {
+				t.Fatal(err)
+			}
+			wantSubjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "skyflo-mcp", Namespace: "default"}}
+			if binding.RoleRef.Name != key.Name || !reflect.DeepEqual(binding.Subjects, wantSubjects) {
+				t.Errorf("binding = %v to %v, want %s to %v", binding.RoleRef.Name, binding.Subjects, key.Name, wantSubjects)
+			}
+
+			// The aggregation controller fills in the rules, which the next
+			// reconcile must keep.
+			aggregated := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+			role.Rules = aggregated
+			if err := r.Update(ctx, role); err != nil {
+				t.Fatal(err)
+			}
+			reconcileOnce(t, r)
+			if err := r.Get(ctx, key, role); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(role.Rules, aggregated) {
+				t.Errorf("rules = %v after reconcile, want the aggregated %v", role.Rules, aggregated)
+			}
+		})
+	}
+}
+
+func TestMCPClusterRBACNamespaceDenied(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.MCP.ClusterRBAC = &skyflov1.MCPClusterRBACSpec{}
+	r := newTestReconciler([]client.Object{skyflo})
+	r.MCPClusterRBACNamespaces = []string{"platform"}
+	reconcileOnce(t, r)
+
+	err := r.Get(ctx, types.NamespacedName{Name: "skyflo:default:skyflo-mcp"}, &rbacv1.ClusterRole{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("ClusterRole outside the allowed namespaces: err = %v, want NotFound", err)
+	}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(got.Status.Conditions, "MCPClusterRBACDenied"); condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("MCPClusterRBACDenied = %+v, want True", condition)
+	}
+}
+
+func TestImagePullSecretsTarget(t *testing.T) {
+	pullSecrets := []corev1.LocalObjectReference{{Name: "registry"}}
+	tests := []struct {
+		name        string
+		target      skyflov1.ImagePullSecretsTarget
+		wantLinked  bool
+		wantMCPPods bool
+	}{
+		{name: "default", wantMCPPods: true},
+		{name: "pod", target: skyflov1.ImagePullSecretsTargetPod, wantMCPPods: true},
+		{name: "service account", target: skyflov1.ImagePullSecretsTargetServiceAccount, wantLinked: true},
+		{name: "both", target: skyflov1.ImagePullSecretsTargetPodAndServiceAccount, wantLinked: true, wantMCPPods: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.ImagePullSecrets = pullSecrets
+			skyflo.Spec.ImagePullSecretsTarget = tt.target
+			// A secret linked by someone else is kept.
+			existing := &corev1.ServiceAccount{
+				ObjectMeta:       metav1.ObjectMeta{Name: mcpServiceAccountName(skyflo), Namespace: "default"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "other"}},
+			}
+			r := newTestReconciler([]client.Object{skyflo, ownedBy(skyflo, existing)})
+			reconcileOnce(t, r)
+
+			serviceAccount := &corev1.ServiceAccount{}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: mcpServiceAccountName(skyflo)}, serviceAccount); err != nil {
+				t.Fatal(err)
+			}
+			want := []corev1.LocalObjectReference{{Name: "other"}}
+			if tt.wantLinked {
+				want = append(want, pullSecrets...)
+			}
+			if !reflect.DeepEqual(serviceAccount.ImagePullSecrets, want) {
+				t.Errorf("ServiceAccount imagePullSecrets = %v, want %v", serviceAccount.ImagePullSecrets, want)
+			}
+
+			for _, name := range []string{"ui", "engine", "mcp"} {
+				deployment := &appsv1.Deployment{}
+				if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-" + name}, deployment); err != nil {
+					t.Fatal(err)
+				}
+				var wantPod []corev1.LocalObjectReference
+				if name != "mcp" || tt.wantMCPPods {
+					wantPod = pullSecrets
+				}
+				if got := deployment.Spec.Template.Spec.ImagePullSecrets; !reflect.DeepEqual(got, wantPod) {
+					t.Errorf("%s pod imagePullSecrets = %v, want %v", name, got, wantPod)
+				}
+			}
+
+			// Linking is idempotent.
+			reconcileOnce(t, r)
+			if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: mcpServiceAccountName(skyflo)}, serviceAccount); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(serviceAccount.ImagePullSecrets, want) {
+				t.Errorf("ServiceAccount imagePullSecrets after a second reconcile = %v, want %v", serviceAccount.ImagePullSecrets, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// componentLabels label the per-object gauges with the SkyfloAI and the
+// component they describe.
+var componentLabels = []string{"namespace", "name", "component"}
+
+var (
+	componentDesiredReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "skyflo_component_desired_replicas",
+		Help: "Desired number of pods of a SkyfloAI component.",
+	}, componentLabels)
+
+	componentReadyReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "skyflo_component_ready_replicas",
+		Help: "Number of ready pods of a SkyfloAI component.",
+	}, componentLabels)
+
+	apiThrottled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "skyflo_api_throttled",
+		Help: "1 while reconciles are paused because the API server answered 429 Too Many Requests, 0 otherwise.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(componentDesiredReplicas, componentReadyReplicas, apiThrottled)
+}
+
+// recordComponentMetrics exports the replica counts of every component in
+// the SkyfloAI status, dropping the series of components that were removed.
+func recordComponentMetrics(skyflo *skyflov1.SkyfloAI) {
+	forgetComponentMetrics(types.NamespacedName{Name: skyflo.Name, Namespace: skyflo.Namespace})
+
+	record := func(component string, status skyflov1.ComponentStatus) {
+		labels := prometheus.Labels{"namespace": skyflo.Namespace, "name": skyflo.Name, "component": component}
+		componentDesiredReplicas.With(labels).Set(float64(status.DesiredReplicas))
+		componentReadyReplicas.With(labels).Set(float64(status.ReadyReplicas))
+	}
+	record("ui", skyflo.Status.UIStatus)
+	record("engine", skyflo.Status.EngineStatus)
+	record("mcp", skyflo.Status.MCPStatus)
+	for _, status := range skyflo.Status.ComponentStatuses {
+		record(status.Name, status.ComponentStatus)
+	}
+}
+
+// forgetComponentMetrics deletes every series of a SkyfloAI.
+func forgetComponentMetrics(name types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": name.Namespace, "name": name.Name}
+	componentDesiredReplicas.DeletePartialMatch(labels)
+	componentReadyReplicas.DeletePartialMatch(labels)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// gaugeValues returns the values of the series of vec that belong to the
+// named SkyfloAI, by component.
+func gaugeValues(t *testing.T, vec *prometheus.GaugeVec, name types.NamespacedName) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+	values := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, label := range m.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["namespace"] == name.Namespace && labels["name"] == name.Name {
+			values[labels["component"]] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestComponentMetrics(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Name = "metrics"
+	skyflo.Spec.Engine.Replicas = ptr.To[int32](3)
+	r := newTestReconciler([]client.Object{skyflo})
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "metrics"}}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	want := map[string]float64{"ui": 1, "engine": 3, "mcp": 1}
+	if got := gaugeValues(t, componentDesiredReplicas, req.NamespacedName); !reflect.DeepEqual(got, want) {
+		t.Errorf("desired replicas = %v, want %v", got, want)
+	}
+	want = map[string]float64{"ui": 0, "engine": 0, "mcp": 0}
+	if got := gaugeValues(t, componentReadyReplicas, req.NamespacedName); !reflect.DeepEqual(got, want) {
+		t.Errorf("ready replicas = %v, want %v", got, want)
+	}
+
+	if err := r.Delete(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	// The first reconcile releases the cleanup finalizer, the second sees
+	// the SkyfloAI gone.
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile after deletion: %v", err)
+		}
+	}
+	if err := r.Get(ctx, req.NamespacedName, skyflo); !errors.IsNotFound(err) {
+		t.Fatalf("SkyfloAI not deleted: %v", err)
+	}
+	for _, vec := range []*prometheus.GaugeVec{componentDesiredReplicas, componentReadyReplicas} {
+		if got := gaugeValues(t, vec, req.NamespacedName); len(got) > 0 {
+			t.Errorf("series left after deletion: %v", got)
+		}
+	}
+}
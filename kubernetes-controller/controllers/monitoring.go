@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+//go:embed dashboards/skyfloai.json
+var dashboardTemplate string
+
+func (r *SkyfloAIReconciler) reconcileMonitoring(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	monitoring := skyflo.Spec.Monitoring
+	if monitoring == nil {
+		monitoring = &skyflov1.MonitoringSpec{}
+	}
+
+	if monitoring.GrafanaDashboard != nil && monitoring.GrafanaDashboard.Enabled {
+		dashboard := r.grafanaDashboard(skyflo)
+		if err := r.own(skyflo, dashboard); err != nil {
+			return err
+		}
+		if err := r.createOrUpdateConfigMap(ctx, dashboard); err != nil {
+			return err
+		}
+	} else if err := r.deleteIfOwned(ctx, skyflo, &corev1.ConfigMap{}, skyflo.Name+"-grafana-dashboard"); err != nil {
+		return err
+	}
+
+	if err := r.reconcileEngineMetrics(ctx, skyflo, monitoring); err != nil {
+		return err
+	}
+
+	installed, err := r.kindInstalled(prometheusRuleGVK)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		if monitoring.PrometheusRules {
+			log.FromContext(ctx).Info("PrometheusRule CRD not installed; skipping default alerts")
+		}
+		return nil
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	if !monitoring.PrometheusRules {
+		return r.deleteIfOwned(ctx, skyflo, rule, skyflo.Name+"-alerts")
+	}
+	rule = r.prometheusRule(skyflo)
+	if err := r.own(skyflo, rule); err != nil {
+		return err
+	}
+	return r.createOrUpdateUnstructured(ctx, rule)
+}
+
+// reconcileEngineMetrics maintains the dedicated Engine metrics Service and
+// the ServiceMonitor scraping the Engine.
+func (r *SkyfloAIReconciler) reconcileEngineMetrics(ctx context.Context, skyflo *skyflov1.SkyfloAI, monitoring *skyflov1.MonitoringSpec) error {
+	metricsServiceName := skyflo.Name + "-engine-metrics"
+	if monitoring.ServiceMonitor && monitoring.SeparateMetricsService {
+		service := engineMetricsService(skyflo)
+		if err := r.own(skyflo, service); err != nil {
+			return err
+		}
+		if err := r.createOrUpdateService(ctx, skyflo, service, nil); err != nil {
+			return err
+		}
+	} else if err := r.deleteIfOwned(ctx, skyflo, &corev1.Service{}, metricsServiceName); err != nil {
+		return err
+	}
+
+	if monitoring.ServiceMonitor && monitoring.MonitoringNamespaceSelector != nil {
+		policy := engineMetricsNetworkPolicy(skyflo)
+		if err := r.own(skyflo, policy); err != nil {
+			return err
+		}
+		if err := r.createOrUpdateNetworkPolicy(ctx, policy); err != nil {
+			return err
+		}
+	} else if err := r.deleteIfOwned(ctx, skyflo, &networkingv1.NetworkPolicy{}, skyflo.Name+"-engine-metrics"); err != nil {
+		return err
+	}
+
+	installed, err := r.kindInstalled(serviceMonitorGVK)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		if monitoring.ServiceMonitor {
+			log.FromContext(ctx).Info("ServiceMonitor CRD not installed; skipping Engine scraping")
+		}
+		return nil
+	}
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	if !monitoring.ServiceMonitor {
+		return r.deleteIfOwned(ctx, skyflo, serviceMonitor, skyflo.Name+"-engine")
+	}
+	serviceMonitor = r.engineServiceMonitor(skyflo)
+	if err := r.own(skyflo, serviceMonitor); err != nil {
+		return err
+	}
+	return r.createOrUpdateUnstructured(ctx, serviceMonitor)
+}
+
+var (
+	prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+	serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+)
+
+// metricsServiceLabel marks the Service exposing a component's metrics port,
+// which is either the component Service or its dedicated metrics Service.
+const metricsServiceLabel = "skyflo.ai/metrics-for"
+
+// defaultMetricsPort is the Engine metrics port when none is configured.
+const defaultMetricsPort = 9090
+
+// scrapeEngine reports whether the Engine metrics port is exposed for
+// scraping.
+func scrapeEngine(skyflo *skyflov1.SkyfloAI) bool {
+	return skyflo.Spec.Monitoring != nil && skyflo.Spec.Monitoring.ServiceMonitor
+}
+
+func engineMetricsPort(skyflo *skyflov1.SkyfloAI) int32 {
+	if port := skyflo.Spec.Monitoring.MetricsPort; port != nil {
+		return *port
+	}
+	return defaultMetricsPort
+}
+
+// addMetricsPort declares the metrics port on the Engine container.
+func addMetricsPort(skyflo *skyflov1.SkyfloAI, deployment *appsv1.Deployment) {
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	container.Ports = append(container.Ports, corev1.ContainerPort{
+		ContainerPort: engineMetricsPort(skyflo),
+		Name:          "metrics",
+	})
+}
+
+// exposeMetrics adds the metrics port to a Service and labels it for the
+// ServiceMonitor.
+func exposeMetrics(skyflo *skyflov1.SkyfloAI, service *corev1.Service) {
+	port := engineMetricsPort(skyflo)
+	service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+		Port:       port,
+		TargetPort: intstr.FromInt(int(port)),
+		Name:       "metrics",
+	})
+	if service.Labels == nil {
+		service.Labels = map[string]string{}
+	}
+	service.Labels[metricsServiceLabel] = skyflo.Name + "-engine"
+}
+
+// engineMetricsService builds a Service exposing only the Engine metrics
+// port, so mesh and scraping policies can treat it apart from the API port.
+func engineMetricsService(skyflo *skyflov1.SkyfloAI) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      skyflo.Name + "-engine-metrics",
+			Namespace: skyflo.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: podLabels(skyflo, "engine", selectorVersion(skyflo)),
+		},
+	}
+	exposeMetrics(skyflo, service)
+	return service
+}
+
+// engineMetricsNetworkPolicy admits pods in the monitoring namespaces to the
+// Engine metrics port. Selecting the Engine pods isolates them for ingress, so
+// the policy also admits the SkyfloAI's own pods, which reach the Engine API.
+func engineMetricsNetworkPolicy(skyflo *skyflov1.SkyfloAI) *networkingv1.NetworkPolicy {
+	protocol := corev1.ProtocolTCP
+	port := intstr.FromInt(int(engineMetricsPort(skyflo)))
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      skyflo.Name + "-engine-metrics",
+			Namespace: skyflo.Namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: podLabels(skyflo, "engine", selectorVersion(skyflo)),
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{{
+						NamespaceSelector: skyflo.Spec.Monitoring.MonitoringNamespaceSelector.DeepCopy(),
+						PodSelector:       &metav1.LabelSelector{},
+					}},
+					Ports: []networkingv1.NetworkPolicyPort{{
+						Protocol: &protocol,
+						Port:     &port,
+					}},
+				},
+				{
+					From: []networkingv1.NetworkPolicyPeer{{
+						PodSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"app.kubernetes.io/instance": skyflo.Name},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// engineServiceMonitor scrapes the metrics port of the Service labeled for the
+// Engine.
+func (r *SkyfloAIReconciler) engineServiceMonitor(skyflo *skyflov1.SkyfloAI) *unstructured.Unstructured {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(skyflo.Name + "-engine")
+	serviceMonitor.SetNamespace(skyflo.Namespace)
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				metricsServiceLabel: skyflo.Name + "-engine",
+			},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port": "metrics",
+				"path": "/metrics",
+			},
+		},
+	}
+	return serviceMonitor
+}
+
+// kindInstalled reports whether the API server serves the given kind.
+func (r *SkyfloAIReconciler) kindInstalled(gvk schema.GroupVersionKind) (bool, error) {
+	_, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// prometheusRule builds the default alerts for the stack's Deployments and
+// pods from kube-state-metrics series.
+func (r *SkyfloAIReconciler) prometheusRule(skyflo *skyflov1.SkyfloAI) *unstructured.Unstructured {
+	deployments := fmt.Sprintf(`namespace="%s", deployment=~"%s-(ui|engine|mcp)(-v2)?"`, skyflo.Namespace, skyflo.Name)
+	pods := fmt.Sprintf(`namespace="%s", pod=~"%s-(ui|engine|mcp)-.*"`, skyflo.Namespace, skyflo.Name)
+
+	alert := func(name, expr, duration, severity, summary string) interface{} {
+		return map[string]interface{}{
+			"alert": name,
+			"expr":  expr,
+			"for":   duration,
+			"labels": map[string]interface{}{
+				"severity": severity,
+			},
+			"annotations": map[string]interface{}{
+				"summary": summary,
+			},
+		}
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	rule.SetName(skyflo.Name + "-alerts")
+	rule.SetNamespace(skyflo.Namespace)
+	rule.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name": "skyflo.rules",
+				"rules": []interface{}{
+					alert("SkyfloComponentDown",
+						fmt.Sprintf("kube_deployment_status_replicas_available{%s} == 0", deployments),
+						"5m", "critical",
+						"Skyflo component {{ $labels.deployment }} has no available replicas"),
+					alert("SkyfloComponentCrashLooping",
+						fmt.Sprintf(`max by (pod) (kube_pod_container_status_waiting_reason{%s, reason="CrashLoopBackOff"}) > 0`, pods),
+						"10m", "warning",
+						"Skyflo pod {{ $labels.pod }} is crash looping"),
+					alert("SkyfloHighRestartRate",
+						fmt.Sprintf("sum by (pod) (increase(kube_pod_container_status_restarts_total{%s}[1h])) > 5", pods),
+						"15m", "warning",
+						"Skyflo pod {{ $labels.pod }} restarted more than 5 times in the last hour"),
+				},
+			},
+		},
+	}
+	return rule
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateUnstructured(ctx context.Context, obj *unstructured.Unstructured) error {
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, obj)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, obj, obj.GetKind()); err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(found.GetResourceVersion())
+	return r.update(ctx, obj)
+}
+
+// grafanaDashboard renders the default dashboard into a ConfigMap labeled for
+// discovery by the Grafana dashboard sidecar.
+func (r *SkyfloAIReconciler) grafanaDashboard(skyflo *skyflov1.SkyfloAI) *corev1.ConfigMap {
+	uid := sha256.Sum256([]byte(skyflo.Namespace + "/" + skyflo.Name))
+	dashboard := strings.NewReplacer(
+		"__NAMESPACE__", skyflo.Namespace,
+		"__NAME__", skyflo.Name,
+		"__UID__", "skyflo-"+hex.EncodeToString(uid[:])[:24],
+	).Replace(dashboardTemplate)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      skyflo.Name + "-grafana-dashboard",
+			Namespace: skyflo.Namespace,
+			Labels: map[string]string{
+				"grafana_dashboard": "1",
+			},
+		},
+		Data: map[string]string{
+			skyflo.Name + ".json": dashboard,
+		},
+	}
+	if folder := skyflo.Spec.Monitoring.GrafanaDashboard.FolderLabel; folder != "" {
+		configMap.Annotations = map[string]string{
+			"grafana_folder": folder,
+		}
+	}
+	return configMap
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateConfigMap(ctx context.Context, configMap *corev1.ConfigMap) error {
+	found := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, configMap)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, configMap, "ConfigMap"); err != nil {
+		return err
+	}
+
+	configMap.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, configMap)
+}
+
+func (r *SkyfloAIReconciler) createOrUpdateNetworkPolicy(ctx context.Context, policy *networkingv1.NetworkPolicy) error {
+	found := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.create(ctx, policy)
+		}
+		return err
+	}
+	if err := checkAdoptable(found, policy, "NetworkPolicy"); err != nil {
+		return err
+	}
+
+	policy.ResourceVersion = found.ResourceVersion
+	return r.update(ctx, policy)
+}
+
+// deleteIfOwned prunes the named object when it exists and is controlled by
+// the SkyfloAI, leaving objects created by anyone else untouched.
+func (r *SkyfloAIReconciler) deleteIfOwned(ctx context.Context, skyflo *skyflov1.SkyfloAI, obj client.Object, name string) error {
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: skyflo.Namespace}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !metav1.IsControlledBy(obj, skyflo) {
+		return nil
+	}
+	return r.prune(ctx, skyflo, obj)
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestGrafanaDashboard(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Monitoring = &skyflov1.MonitoringSpec{
+		GrafanaDashboard: &skyflov1.DashboardSpec{Enabled: true, FolderLabel: "Skyflo"},
+	}
+	r := newTestReconciler(nil)
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileMonitoring: %v", err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-grafana-dashboard"}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		t.Fatalf("dashboard ConfigMap was not created: %v", err)
+	}
+	if configMap.Labels["grafana_dashboard"] != "1" {
+		t.Errorf("labels = %v, want the grafana_dashboard discovery label", configMap.Labels)
+	}
+	if configMap.Annotations["grafana_folder"] != "Skyflo" {
+		t.Errorf("annotations = %v, want the grafana_folder annotation", configMap.Annotations)
+	}
+	var dashboard struct {
+		Title string `json:"title"`
+		UID   string `json:"uid"`
+	}
+	if err := json.Unmarshal([]byte(configMap.Data["skyflo.json"]), &dashboard); err != nil {
+		t.Fatalf("dashboard payload is not JSON: %v", err)
+	}
+	if dashboard.Title != "Skyflo default/skyflo" || dashboard.UID == "" || dashboard.UID == "__UID__" {
+		t.Errorf("dashboard title %q and uid %q were not filled in", dashboard.Title, dashboard.UID)
+	}
+
+	skyflo.Spec.Monitoring.GrafanaDashboard.Enabled = false
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileMonitoring: %v", err)
+	}
+	if err := r.Get(ctx, key, configMap); !errors.IsNotFound(err) {
+		t.Errorf("dashboard ConfigMap was not pruned once disabled: %v", err)
+	}
+}
+
+func TestPrometheusRule(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Monitoring = &skyflov1.MonitoringSpec{PrometheusRules: true}
+	r := newTestReconcilerWithKinds([]schema.GroupVersionKind{prometheusRuleGVK}, nil)
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileMonitoring: %v", err)
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-alerts"}
+	if err := r.Get(ctx, key, rule); err != nil {
+		t.Fatalf("PrometheusRule was not created: %v", err)
+	}
+	groups, _, _ := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	if len(groups) != 1 {
+		t.Fatalf("groups = %v, want a single group", groups)
+	}
+	rules, _, _ := unstructured.NestedSlice(groups[0].(map[string]interface{}), "rules")
+	var alerts []string
+	for _, rule := range rules {
+		alerts = append(alerts, rule.(map[string]interface{})["alert"].(string))
+	}
+	want := []string{"SkyfloComponentDown", "SkyfloComponentCrashLooping", "SkyfloHighRestartRate"}
+	if len(alerts) != len(want) {
+		t.Fatalf("alerts = %v, want %v", alerts, want)
+	}
+	for i := range want {
+		if alerts[i] != want[i] {
+			t.Errorf("alerts = %v, want %v", alerts, want)
+			break
+		}
+	}
+
+	skyflo.Spec.Monitoring.PrometheusRules = false
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileMonitoring: %v", err)
+	}
+	if err := r.Get(ctx, key, rule); !errors.IsNotFound(err) {
+		t.Errorf("PrometheusRule still present after disabling rules: %v", err)
+	}
+}
+
+func TestPrometheusRuleWithoutCRD(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Monitoring = &skyflov1.MonitoringSpec{PrometheusRules: true}
+	r := newTestReconciler(nil)
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileMonitoring without the CRD: %v", err)
+	}
+}
+
+func TestSeparateMetricsService(t *testing.T) {
+	for _, separate := range []bool{false, true} {
+		ctx := context.Background()
+		skyflo := testSkyfloAI()
+		skyflo.Spec.Monitoring = &skyflov1.MonitoringSpec{ServiceMonitor: true, SeparateMetricsService: separate}
+		r := newTestReconcilerWithKinds([]schema.GroupVersionKind{serviceMonitorGVK}, []client.Object{skyflo})
+		reconcileOnce(t, r)
+
+		main := &corev1.Service{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, main); err != nil {
+			t.Fatal(err)
+		}
+		metrics := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine-metrics"}, metrics)
+		scraped := main
+		if separate {
+			if err != nil {
+				t.Fatalf("metrics Service was not created: %v", err)
+			}
+			if ports := servicePortNames(main); !reflect.DeepEqual(ports, []string{"http"}) {
+				t.Errorf("separate: Engine Service ports = %v, want only http", ports)
+			}
+			if _, ok := main.Labels[metricsServiceLabel]; ok {
+				t.Errorf("separate: Engine Service carries the scrape label")
+			}
+			if !reflect.DeepEqual(metrics.Spec.Selector, main.Spec.Selector) {
+				t.Errorf("metrics Service selector = %v, want the Engine pods %v", metrics.Spec.Selector, main.Spec.Selector)
+			}
+			scraped = metrics
+		} else if !errors.IsNotFound(err) {
+			t.Errorf("shared: metrics Service exists: %v", err)
+		}
+		if ports := servicePortNames(scraped); ports[len(ports)-1] != "metrics" {
+			t.Errorf("separate %v: scraped Service ports = %v, want a metrics port", separate, ports)
+		}
+		if separate && len(scraped.Spec.Ports) != 1 {
+			t.Errorf("separate: metrics Service ports = %v, want only metrics", servicePortNames(scraped))
+		}
+
+		serviceMonitor := &unstructured.Unstructured{}
+		serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, serviceMonitor); err != nil {
+			t.Fatalf("ServiceMonitor was not created: %v", err)
+		}
+		matchLabels, _, _ := unstructured.NestedStringMap(serviceMonitor.Object, "spec", "selector", "matchLabels")
+		if len(matchLabels) == 0 {
+			t.Fatalf("ServiceMonitor selects no Service")
+		}
+		for key, value := range matchLabels {
+			if scraped.Labels[key] != value {
+				t.Errorf("separate %v: ServiceMonitor selects %s=%s, which %s does not carry", separate, key, value, scraped.Name)
+			}
+		}
+	}
+}
+
+// servicePortNames returns the names of the Service's ports in order.
+func servicePortNames(service *corev1.Service) []string {
+	var names []string
+	for _, port := range service.Spec.Ports {
+		names = append(names, port.Name)
+	}
+	return names
+}
+
+func TestEngineMetricsNetworkPolicy(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "monitoring"}}
+	skyflo.Spec.Monitoring = &skyflov1.MonitoringSpec{
+		ServiceMonitor:              true,
+		MetricsPort:                 ptr.To[int32](9100),
+		MonitoringNamespaceSelector: selector,
+	}
+	r := newTestReconcilerWithKinds([]schema.GroupVersionKind{serviceMonitorGVK}, []client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-engine-metrics"}
+	policy := &networkingv1.NetworkPolicy{}
+	if err := r.Get(ctx, key, policy); err != nil {
+		t.Fatalf("metrics NetworkPolicy was not created: %v", err)
+	}
+	if !metav1.IsControlledBy(policy, skyflo) {
+		t.Errorf("NetworkPolicy owners = %+v, want the SkyfloAI", policy.OwnerReferences)
+	}
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !podSelector.Matches(labels.Set(engine.Spec.Template.Labels)) {
+		t.Errorf("NetworkPolicy selects %v, not the Engine pods %v", podSelector, engine.Spec.Template.Labels)
+	}
+	if ui := r.deployment(skyflo, components(skyflo)[0]); podSelector.Matches(labels.Set(ui.Spec.Template.Labels)) {
+		t.Errorf("NetworkPolicy selects the UI pods %v", ui.Spec.Template.Labels)
+	}
+	if !reflect.DeepEqual(policy.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}) {
+		t.Errorf("policy types = %v, want Ingress only", policy.Spec.PolicyTypes)
+	}
+
+	scrapeRule := -1
+	for i, rule := range policy.Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector != nil {
+				scrapeRule = i
+			}
+		}
+	}
+	if scrapeRule < 0 {
+		t.Fatalf("ingress rules %+v, want one from the monitoring namespaces", policy.Spec.Ingress)
+	}
+	scrape := policy.Spec.Ingress[scrapeRule]
+	if len(scrape.From) != 1 || !reflect.DeepEqual(scrape.From[0].NamespaceSelector, selector) {
+		t.Errorf("scrape rule peers = %+v, want the monitoring namespace selector", scrape.From)
+	}
+	if len(scrape.Ports) != 1 || scrape.Ports[0].Port.IntValue() != 9100 || ptr.Deref(scrape.Ports[0].Protocol, "") != corev1.ProtocolTCP {
+		t.Errorf("scrape rule ports = %+v, want only TCP 9100", scrape.Ports)
+	}
+	for i, rule := range policy.Spec.Ingress {
+		if i == scrapeRule {
+			continue
+		}
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector != nil || peer.PodSelector == nil ||
+				!reflect.DeepEqual(peer.PodSelector.MatchLabels, map[string]string{"app.kubernetes.io/instance": "skyflo"}) {
+				t.Errorf("unexpected ingress peer %+v, want only the SkyfloAI's own pods besides monitoring", peer)
+			}
+		}
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	got.Spec.Monitoring.MonitoringNamespaceSelector = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, &networkingv1.NetworkPolicy{}); !errors.IsNotFound(err) {
+		t.Errorf("metrics NetworkPolicy kept after removing the selector: %v", err)
+	}
+}
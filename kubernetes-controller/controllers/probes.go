@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+const (
+	defaultEngineLivenessPath  = "/healthz"
+	defaultEngineReadinessPath = "/ready"
+
+	uiHealthPath = "/api/health"
+
+	mcpLivenessPath  = "/health"
+	mcpReadinessPath = "/health/ready"
+)
+
+// addEngineProbes gives the Engine container the default probes wherever no
+// probe is configured: a shallow liveness check, so dependency outages never
+// restart the pods, and a deep readiness check that takes pods out of the
+// Service endpoints while the database or Redis is unreachable. The deadlock
+// probe, when enabled, later replaces the default liveness probe.
+func addEngineProbes(engine skyflov1.EngineSpec, deployment *appsv1.Deployment) {
+	addDefaultProbes(deployment,
+		pathOrDefault(engine.LivenessPath, defaultEngineLivenessPath),
+		pathOrDefault(engine.ReadinessPath, defaultEngineReadinessPath))
+}
+
+// addDefaultProbes gives the component container HTTP probes of the given
+// paths on its http port wherever no probe is configured.
+func addDefaultProbes(deployment *appsv1.Deployment, livenessPath, readinessPath string) {
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	if container.LivenessProbe == nil {
+		container.LivenessProbe = httpProbe(livenessPath)
+	}
+	if container.ReadinessProbe == nil {
+		container.ReadinessProbe = httpProbe(readinessPath)
+	}
+}
+
+// httpProbe probes the path on the container's http port.
+func httpProbe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromString("http"),
+			},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}
+}
+
+func pathOrDefault(path, def string) string {
+	if path == "" {
+		return def
+	}
+	return path
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestProbeHandlers(t *testing.T) {
+	exec := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{"/bin/healthcheck"}},
+		},
+	}
+	tcp := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+		},
+	}
+	grpc := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			GRPC: &corev1.GRPCAction{Port: 8081},
+		},
+	}
+
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.LivenessProbe = exec
+	skyflo.Spec.Engine.ReadinessProbe = tcp
+	skyflo.Spec.MCP.LivenessProbe = grpc
+	skyflo.Spec.MCP.ReadinessProbe = grpc
+	r := newTestReconciler(nil)
+
+	want := map[string][2]*corev1.Probe{
+		"ui":     {exec, httpProbe(uiHealthPath)},
+		"engine": {httpProbe(defaultEngineLivenessPath), tcp},
+		"mcp":    {grpc, grpc},
+	}
+	for _, c := range components(skyflo) {
+		container := r.deployment(skyflo, c).Spec.Template.Spec.Containers[0]
+		if !reflect.DeepEqual(container.LivenessProbe, want[c.name][0]) {
+			t.Errorf("%s liveness probe = %+v, want %+v", c.name, container.LivenessProbe, want[c.name][0])
+		}
+		if !reflect.DeepEqual(container.ReadinessProbe, want[c.name][1]) {
+			t.Errorf("%s readiness probe = %+v, want %+v", c.name, container.ReadinessProbe, want[c.name][1])
+		}
+	}
+}
+
+func TestEngineProbePaths(t *testing.T) {
+	tests := []struct {
+		name                    string
+		liveness, readiness     string
+		wantLiveness, wantReady string
+	}{
+		{name: "defaults", wantLiveness: "/healthz", wantReady: "/ready"},
+		{name: "configured", liveness: "/livez", readiness: "/readyz/deep", wantLiveness: "/livez", wantReady: "/readyz/deep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.LivenessPath = tt.liveness
+			skyflo.Spec.Engine.ReadinessPath = tt.readiness
+			r := newTestReconciler(nil)
+
+			container := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec.Containers[0]
+			if !reflect.DeepEqual(container.LivenessProbe, httpProbe(tt.wantLiveness)) {
+				t.Errorf("liveness probe = %+v, want %s on the http port", container.LivenessProbe, tt.wantLiveness)
+			}
+			if !reflect.DeepEqual(container.ReadinessProbe, httpProbe(tt.wantReady)) {
+				t.Errorf("readiness probe = %+v, want %s on the http port", container.ReadinessProbe, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestDefaultProbes(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	want := map[string]struct {
+		port                int32
+		liveness, readiness string
+	}{
+		"ui":     {3000, uiHealthPath, uiHealthPath},
+		"engine": {8081, defaultEngineLivenessPath, defaultEngineReadinessPath},
+		"mcp":    {8000, mcpLivenessPath, mcpReadinessPath},
+	}
+	for _, c := range components(skyflo) {
+		container := r.deployment(skyflo, c).Spec.Template.Spec.Containers[0]
+		var httpPort int32
+		for _, port := range container.Ports {
+			if port.Name == "http" {
+				httpPort = port.ContainerPort
+			}
+		}
+		if httpPort != want[c.name].port {
+			t.Errorf("%s http port = %d, want %d", c.name, httpPort, want[c.name].port)
+		}
+		probes := []struct {
+			probe *corev1.Probe
+			path  string
+		}{
+			{container.LivenessProbe, want[c.name].liveness},
+			{container.ReadinessProbe, want[c.name].readiness},
+		}
+		for _, p := range probes {
+			if p.probe == nil || p.probe.HTTPGet == nil || p.probe.HTTPGet.Path != p.path || p.probe.HTTPGet.Port != intstr.FromString("http") {
+				t.Errorf("%s probe = %+v, want an HTTP GET of %s on the http port", c.name, p.probe, p.path)
+			}
+		}
+	}
+}
+
+func TestProbeChangeRollsOut(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	reconcileOnce(t, r)
+	before, _ := specHashes(t, r)
+
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	readiness := httpProbe("/ready")
+	readiness.InitialDelaySeconds = 20
+	skyflo.Spec.Engine.ReadinessProbe = readiness
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	after, _ := specHashes(t, r)
+
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(engine.Spec.Template.Spec.Containers[0].ReadinessProbe, readiness) {
+		t.Errorf("engine pod template readiness probe = %+v, want %+v", engine.Spec.Template.Spec.Containers[0].ReadinessProbe, readiness)
+	}
+	if after["engine"] == before["engine"] {
+		t.Error("engine spec hash unchanged by the probe update")
+	}
+	if after["ui"] != before["ui"] || after["mcp"] != before["mcp"] {
+		t.Errorf("UI or MCP changed by an engine probe update: %v, then %v", before, after)
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// recordProgress emits events narrating the bring-up of the stack, so that
+// kubectl describe reads as a progress log: what the controller waits on,
+// each component rolling out and becoming ready, and the stack becoming
+// available. Events are emitted only for transitions between the status read
+// at the start of the reconcile and the one about to be written, so a
+// reconcile that observes nothing new emits nothing.
+func (r *SkyfloAIReconciler) recordProgress(skyflo *skyflov1.SkyfloAI, observed *skyflov1.SkyfloAIStatus) {
+	if r.Recorder == nil {
+		return
+	}
+
+	before := meta.FindStatusCondition(observed.Conditions, "WaitingForDatabase")
+	if after := meta.FindStatusCondition(skyflo.Status.Conditions, "WaitingForDatabase"); after != nil {
+		waiting := after.Status == metav1.ConditionTrue
+		wasWaiting := before != nil && before.Status == metav1.ConditionTrue
+		if waiting && (!wasWaiting || before.Message != after.Message) {
+			r.Recorder.Event(skyflo, corev1.EventTypeNormal, "WaitingForDatabase", after.Message)
+		} else if !waiting && wasWaiting {
+			r.Recorder.Event(skyflo, corev1.EventTypeNormal, "DatabaseReady", after.Message)
+		}
+	}
+
+	available, wasAvailable := true, true
+	for _, c := range components(skyflo) {
+		previous, current := progressStatuses(skyflo, observed, c)
+		if current.Phase != "Ready" {
+			available = false
+		}
+		if previous.Phase != "Ready" {
+			wasAvailable = false
+		}
+		if current.Phase == "" || (current.ReadyReplicas == previous.ReadyReplicas &&
+			current.DesiredReplicas == previous.DesiredReplicas && current.Phase == previous.Phase) {
+			continue
+		}
+
+		switch current.Phase {
+		case "Ready":
+			r.Recorder.Eventf(skyflo, corev1.EventTypeNormal, "ComponentReady", "%s ready", c.displayName)
+			continue
+		case "WarmingUp":
+			r.Recorder.Eventf(skyflo, corev1.EventTypeNormal, "ComponentWarmingUp", "%s warming up", c.displayName)
+			continue
+		case "WarmupFailed":
+			r.Recorder.Eventf(skyflo, corev1.EventTypeWarning, "WarmupFailed", "%s %s", c.displayName, current.Message)
+			continue
+		}
+		r.Recorder.Eventf(skyflo, corev1.EventTypeNormal, "ComponentRollingOut", "%s rolling out %d/%d",
+			c.displayName, current.ReadyReplicas, current.DesiredReplicas)
+	}
+
+	if available && !wasAvailable {
+		r.Recorder.Event(skyflo, corev1.EventTypeNormal, "StackAvailable", "Stack Available")
+	}
+}
+
+// progressStatuses returns a component's status as read at the start of the
+// reconcile and as about to be written.
+func progressStatuses(skyflo *skyflov1.SkyfloAI, observed *skyflov1.SkyfloAIStatus, c component) (skyflov1.ComponentStatus, skyflov1.ComponentStatus) {
+	if !c.custom {
+		return *statusFor(&skyflov1.SkyfloAI{Status: *observed}, c.name), *statusFor(skyflo, c.name)
+	}
+
+	find := func(statuses []skyflov1.NamedComponentStatus) skyflov1.ComponentStatus {
+		for _, status := range statuses {
+			if status.Name == c.name {
+				return status.ComponentStatus
+			}
+		}
+		return skyflov1.ComponentStatus{}
+	}
+	return find(observed.ComponentStatuses), find(skyflo.Status.ComponentStatuses)
+}
+
+// recordDeploymentWrite emits an event when the Deployment of a component
+// was created or changed. Reconciles that leave it untouched emit nothing.
+func (r *SkyfloAIReconciler) recordDeploymentWrite(skyflo *skyflov1.SkyfloAI, c component, operation controllerutil.OperationResult) {
+	if r.Recorder == nil {
+		return
+	}
+	switch operation {
+	case controllerutil.OperationResultCreated:
+		r.Recorder.Eventf(skyflo, corev1.EventTypeNormal, "ComponentCreated", "%s Deployment created", c.displayName)
+	case controllerutil.OperationResultUpdated:
+		r.Recorder.Eventf(skyflo, corev1.EventTypeNormal, "ComponentUpdated", "%s Deployment updated", c.displayName)
+	}
+}
+
+// recordComponentFailure emits a warning carrying the error a component
+// failed to reconcile with, for operators without access to the controller
+// logs. Repeated failures are aggregated by the event recorder.
+func (r *SkyfloAIReconciler) recordComponentFailure(skyflo *skyflov1.SkyfloAI, c component, err error) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(skyflo, corev1.EventTypeWarning, "ComponentReconcileFailed", "%s: %v", c.displayName, err)
+}
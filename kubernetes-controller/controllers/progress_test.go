@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// progressEvents drains the recorded events, leaving out the Deployment
+// write events that are not part of the bring-up narrative.
+func progressEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			if !strings.Contains(event, " ComponentCreated ") && !strings.Contains(event, " ComponentUpdated ") {
+				events = append(events, event)
+			}
+		default:
+			return events
+		}
+	}
+}
+
+func TestProgressEvents(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.DatabaseDependency = &skyflov1.DependencyRef{
+		APIVersion: "postgresql.cnpg.io/v1",
+		Kind:       "Cluster",
+		Name:       "skyflo-db",
+	}
+	r := newTestReconcilerWithKinds([]schema.GroupVersionKind{cnpgClusterGVK}, []client.Object{skyflo, cnpgCluster("False")})
+	recorder := record.NewFakeRecorder(100)
+	r.Recorder = recorder
+
+	steps := []struct {
+		name    string
+		prepare func()
+		want    []string
+	}{
+		{
+			name: "waiting for the database",
+			want: []string{
+				`Normal WaitingForDatabase holding the Engine rollout: Cluster skyflo-db reports "False" at {.status.conditions[?(@.type=="Ready")].status}, want "True"`,
+				"Normal ComponentRollingOut UI rolling out 0/1",
+				"Normal ComponentRollingOut MCP rolling out 0/1",
+			},
+		},
+		{name: "nothing new"},
+		{
+			name: "database ready",
+			prepare: func() {
+				cluster := &unstructured.Unstructured{}
+				cluster.SetGroupVersionKind(cnpgClusterGVK)
+				if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-db"}, cluster); err != nil {
+					t.Fatal(err)
+				}
+				cluster.Object["status"] = cnpgCluster("True").Object["status"]
+				if err := r.Update(ctx, cluster); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{
+				"Normal DatabaseReady Cluster skyflo-db is ready",
+				"Normal ComponentRollingOut Engine rolling out 0/1",
+			},
+		},
+		{
+			name:    "UI ready",
+			prepare: func() { markReady(t, r, "skyflo-ui") },
+			want:    []string{"Normal ComponentReady UI ready"},
+		},
+		{
+			name:    "all ready",
+			prepare: func() { markReady(t, r, "skyflo-engine", "skyflo-mcp") },
+			want: []string{
+				"Normal ComponentReady Engine ready",
+				"Normal ComponentReady MCP ready",
+				"Normal StackAvailable Stack Available",
+			},
+		},
+		{name: "steady state"},
+	}
+	for _, step := range steps {
+		if step.prepare != nil {
+			step.prepare()
+		}
+		reconcileOnce(t, r)
+		if got := progressEvents(recorder); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: events = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// pruningPolicy returns how the SkyfloAI's resources that are no longer
+// needed are pruned. --enable-pruning=false forces Orphan for every SkyfloAI.
+func (r *SkyfloAIReconciler) pruningPolicy(skyflo *skyflov1.SkyfloAI) skyflov1.PruningPolicy {
+	if r.DisablePruning || skyflo.Spec.PruningPolicy == skyflov1.PruningPolicyOrphan {
+		return skyflov1.PruningPolicyOrphan
+	}
+	return skyflov1.PruningPolicyDelete
+}
+
+// prune deletes an object controlled by the SkyfloAI or, under the Orphan
+// policy, releases it by removing the owner reference. Orphaned objects are
+// marked for adoption so re-enabling the feature takes them back over.
+func (r *SkyfloAIReconciler) prune(ctx context.Context, skyflo *skyflov1.SkyfloAI, obj client.Object) error {
+	if r.pruningPolicy(skyflo) == skyflov1.PruningPolicyDelete {
+		return client.IgnoreNotFound(r.Delete(ctx, obj))
+	}
+
+	var refs []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != skyflo.UID {
+			refs = append(refs, ref)
+		}
+	}
+	obj.SetOwnerReferences(refs)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[adoptAnnotation] = "true"
+	obj.SetAnnotations(annotations)
+	return client.IgnoreNotFound(r.Update(ctx, obj, r.fieldOwner()))
+}
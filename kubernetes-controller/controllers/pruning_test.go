@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestPruningPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         skyflov1.PruningPolicy
+		disablePruning bool
+		wantOrphan     bool
+	}{
+		{name: "default"},
+		{name: "delete", policy: skyflov1.PruningPolicyDelete},
+		{name: "orphan", policy: skyflov1.PruningPolicyOrphan, wantOrphan: true},
+		{name: "pruning disabled", policy: skyflov1.PruningPolicyDelete, disablePruning: true, wantOrphan: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.PruningPolicy = tt.policy
+			skyflo.Spec.Components = []skyflov1.CustomComponentSpec{{
+				Name:          "gateway",
+				Port:          9000,
+				ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/gateway:test"},
+			}}
+			r := newTestReconciler([]client.Object{skyflo})
+			r.DisablePruning = tt.disablePruning
+			reconcileOnce(t, r)
+
+			key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+			if err := r.Get(ctx, key, skyflo); err != nil {
+				t.Fatal(err)
+			}
+			skyflo.Spec.Components = nil
+			if err := r.Update(ctx, skyflo); err != nil {
+				t.Fatal(err)
+			}
+			reconcileOnce(t, r)
+
+			gatewayKey := types.NamespacedName{Namespace: "default", Name: "skyflo-gateway"}
+			for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+				err := r.Get(ctx, gatewayKey, obj)
+				if !tt.wantOrphan {
+					if !apierrors.IsNotFound(err) {
+						t.Errorf("%T of the removed component: err = %v, want NotFound", obj, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%T of the removed component was deleted: %v", obj, err)
+				}
+				if metav1.IsControlledBy(obj, skyflo) {
+					t.Errorf("orphaned %T still controlled by the SkyfloAI", obj)
+				}
+				if obj.GetAnnotations()[adoptAnnotation] != "true" {
+					t.Errorf("orphaned %T annotations = %v, want it marked for adoption", obj, obj.GetAnnotations())
+				}
+			}
+
+			if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, &appsv1.Deployment{}); err != nil {
+				t.Errorf("engine Deployment: %v", err)
+			}
+			if !tt.wantOrphan {
+				return
+			}
+
+			if err := r.Get(ctx, key, skyflo); err != nil {
+				t.Fatal(err)
+			}
+			skyflo.Spec.Components = []skyflov1.CustomComponentSpec{{
+				Name:          "gateway",
+				Port:          9000,
+				ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/gateway:test"},
+			}}
+			if err := r.Update(ctx, skyflo); err != nil {
+				t.Fatal(err)
+			}
+			reconcileOnce(t, r)
+			deployment := &appsv1.Deployment{}
+			if err := r.Get(ctx, gatewayKey, deployment); err != nil {
+				t.Fatal(err)
+			}
+			if !metav1.IsControlledBy(deployment, skyflo) {
+				t.Error("orphaned Deployment not adopted back when the component returned")
+			}
+		})
+	}
+}
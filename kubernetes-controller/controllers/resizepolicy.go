@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// resizePolicyMinVersion is the first release serving resizePolicy on
+// containers. In-place resize itself also needs the InPlacePodVerticalScaling
+// feature gate, which is on by default from 1.33; without it the API server
+// drops the field and resource changes roll the pods as before.
+var resizePolicyMinVersion = utilversion.MajorMinor(1, 27)
+
+// resizePolicy returns the component's container resize policy, or nil when
+// the cluster is too old to serve it.
+func (r *SkyfloAIReconciler) resizePolicy(ctx context.Context, c component) []corev1.ContainerResizePolicy {
+	if len(c.spec.ResizePolicy) == 0 {
+		return nil
+	}
+	if r.ServerVersion != nil {
+		serverVersion, err := utilversion.ParseGeneric(r.ServerVersion.GitVersion)
+		if err == nil && !serverVersion.AtLeast(resizePolicyMinVersion) {
+			log.FromContext(ctx).Info("resizePolicy requires Kubernetes 1.27 or newer; ignoring",
+				"component", c.displayName, "serverVersion", r.ServerVersion.GitVersion)
+			return nil
+		}
+	}
+	return c.spec.ResizePolicy
+}
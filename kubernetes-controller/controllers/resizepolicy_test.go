@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestResizePolicy(t *testing.T) {
+	policy := []corev1.ContainerResizePolicy{
+		{ResourceName: corev1.ResourceCPU, RestartPolicy: corev1.NotRequired},
+		{ResourceName: corev1.ResourceMemory, RestartPolicy: corev1.RestartContainer},
+	}
+	tests := []struct {
+		name          string
+		serverVersion *version.Info
+		want          []corev1.ContainerResizePolicy
+	}{
+		{name: "supported", serverVersion: &version.Info{GitVersion: "v1.29.2"}, want: policy},
+		{name: "first supported release", serverVersion: &version.Info{GitVersion: "v1.27.0"}, want: policy},
+		{name: "too old", serverVersion: &version.Info{GitVersion: "v1.26.9"}},
+		{name: "unknown version", want: policy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.ResizePolicy = policy
+			r := newTestReconciler([]client.Object{skyflo})
+			r.ServerVersion = tt.serverVersion
+			reconcileOnce(t, r)
+
+			for name, want := range map[string][]corev1.ContainerResizePolicy{"skyflo-engine": tt.want, "skyflo-ui": nil} {
+				deployment := &appsv1.Deployment{}
+				if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, deployment); err != nil {
+					t.Fatal(err)
+				}
+				if got := deployment.Spec.Template.Spec.Containers[0].ResizePolicy; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s resize policy = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestStampDependentRestarts(t *testing.T) {
+	engineAt := func(image string, rolledOut bool) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine", Namespace: "default", Generation: 2},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](2),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "engine", Image: image}}}},
+			},
+			Status: appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+		}
+		if rolledOut {
+			deployment.Status.UpdatedReplicas = 2
+		}
+		return deployment
+	}
+	revision := func(image string) string {
+		return templateRevision(&engineAt(image, true).Spec.Template)
+	}
+	key := restartAnnotationPrefix + "engine-revision"
+
+	tests := []struct {
+		name     string
+		engine   *appsv1.Deployment
+		recorded string // revision on the live UI Deployment
+		want     string
+	}{
+		{name: "engine not created yet"},
+		{name: "engine still rolling out", engine: engineAt("engine:v1", false)},
+		{name: "engine rolled out", engine: engineAt("engine:v1", true), want: revision("engine:v1")},
+		{name: "unchanged engine", engine: engineAt("engine:v1", true), recorded: revision("engine:v1"), want: revision("engine:v1")},
+		{name: "new image still rolling out", engine: engineAt("engine:v2", false), recorded: revision("engine:v1"), want: revision("engine:v1")},
+		{name: "new image rolled out", engine: engineAt("engine:v2", true), recorded: revision("engine:v1"), want: revision("engine:v2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.RestartDependentsOnChange = map[string][]string{"engine": {"ui"}}
+			ui := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"}}
+			if tt.recorded != "" {
+				ui.Spec.Template.Annotations = map[string]string{key: tt.recorded}
+			}
+			objs := []client.Object{ui}
+			if tt.engine != nil {
+				objs = append(objs, tt.engine)
+			}
+			r := newTestReconciler(objs)
+
+			desired := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"}}
+			if err := r.stampDependentRestarts(context.Background(), skyflo, "ui", desired); err != nil {
+				t.Fatalf("stampDependentRestarts: %v", err)
+			}
+			if got := desired.Spec.Template.Annotations[key]; got != tt.want {
+				t.Errorf("%s = %q, want %q", key, got, tt.want)
+			}
+
+			engine := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine", Namespace: "default"}}
+			if err := r.stampDependentRestarts(context.Background(), skyflo, "engine", engine); err != nil {
+				t.Fatalf("stampDependentRestarts: %v", err)
+			}
+			if len(engine.Spec.Template.Annotations) != 0 {
+				t.Errorf("the engine, which depends on nothing, was stamped: %v", engine.Spec.Template.Annotations)
+			}
+		})
+	}
+}
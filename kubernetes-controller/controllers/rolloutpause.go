@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// pauseRolloutAnnotation pauses component rollouts once the given percentage
+// of replicas runs the new pod template, so an external system can analyse
+// the canary pods. Removing the annotation resumes the rollouts.
+const pauseRolloutAnnotation = "skyflo.ai/pause-rollout-at-percent"
+
+// rolloutPausedAnnotation marks a Deployment the controller paused for
+// pauseRolloutAnnotation, as opposed to one paused through spec.
+const rolloutPausedAnnotation = "skyflo.ai/rollout-paused"
+
+// pauseThreshold returns the rollout percentage to pause at, if requested.
+// A rollout is complete, and no longer pausable, once every replica is
+// updated, so thresholds above 99 are ignored.
+func pauseThreshold(skyflo *skyflov1.SkyfloAI) (int32, bool) {
+	value, ok := skyflo.Annotations[pauseRolloutAnnotation]
+	if !ok {
+		return 0, false
+	}
+	percent, err := strconv.Atoi(value)
+	if err != nil || percent < 1 || percent > 99 {
+		return 0, false
+	}
+	return int32(percent), true
+}
+
+// pauseRollout pauses the component Deployment once its in-progress rollout
+// reaches the threshold, and keeps it paused until the annotation is removed.
+func (r *SkyfloAIReconciler) pauseRollout(ctx context.Context, skyflo *skyflov1.SkyfloAI, deployment *appsv1.Deployment) error {
+	threshold, ok := pauseThreshold(skyflo)
+	if !ok || deployment.Spec.Paused {
+		return nil
+	}
+
+	current := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, current)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if current.Annotations[rolloutPausedAnnotation] == "" {
+		replicas := int32(1)
+		if current.Spec.Replicas != nil {
+			replicas = *current.Spec.Replicas
+		}
+		updated := current.Status.UpdatedReplicas
+		rolling := current.Status.ObservedGeneration >= current.Generation && updated < replicas
+		if !rolling || replicas == 0 || updated*100/replicas < threshold {
+			return nil
+		}
+	}
+
+	deployment.Spec.Paused = true
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[rolloutPausedAnnotation] = "true"
+	return nil
+}
+
+// setRolloutPausedCondition reports the components whose rollout is held by
+// pauseRolloutAnnotation.
+func (r *SkyfloAIReconciler) setRolloutPausedCondition(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	var paused []string
+	for _, c := range components(skyflo) {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, selectorVersion(skyflo)), Namespace: skyflo.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if deployment.Annotations[rolloutPausedAnnotation] != "" {
+			paused = append(paused, c.displayName)
+		}
+	}
+
+	if len(paused) == 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "RolloutPaused")
+		return nil
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "RolloutPaused",
+		Status: metav1.ConditionTrue,
+		Reason: "PausedAtThreshold",
+		Message: fmt.Sprintf("rollout paused at %s%% of replicas for: %s; remove the %s annotation to resume",
+			skyflo.Annotations[pauseRolloutAnnotation], strings.Join(paused, ", "), pauseRolloutAnnotation),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return nil
+}
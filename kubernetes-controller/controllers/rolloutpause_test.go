@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPauseRollout(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		updated    int32
+		paused     bool // the live Deployment was already paused for the annotation
+		wantPaused bool
+	}{
+		{name: "no annotation", updated: 2},
+		{name: "below threshold", annotation: "75", updated: 2},
+		{name: "at threshold", annotation: "50", updated: 2, wantPaused: true},
+		{name: "past threshold", annotation: "25", updated: 3, wantPaused: true},
+		{name: "rollout complete", annotation: "50", updated: 4},
+		{name: "stays paused", annotation: "50", updated: 2, paused: true, wantPaused: true},
+		{name: "resumes once cleared", updated: 2, paused: true},
+		{name: "100 is ignored", annotation: "100", updated: 3},
+		{name: "invalid threshold", annotation: "half", updated: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			if tt.annotation != "" {
+				skyflo.Annotations = map[string]string{pauseRolloutAnnotation: tt.annotation}
+			}
+			current := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](4)},
+				Status:     appsv1.DeploymentStatus{UpdatedReplicas: tt.updated},
+			}
+			if tt.paused {
+				current.Annotations = map[string]string{rolloutPausedAnnotation: "true"}
+				current.Spec.Paused = true
+			}
+			r := newTestReconciler([]client.Object{current})
+
+			desired := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine", Namespace: "default"}}
+			if err := r.pauseRollout(context.Background(), skyflo, desired); err != nil {
+				t.Fatalf("pauseRollout: %v", err)
+			}
+			if desired.Spec.Paused != tt.wantPaused {
+				t.Errorf("paused = %v, want %v", desired.Spec.Paused, tt.wantPaused)
+			}
+			if got := desired.Annotations[rolloutPausedAnnotation] != ""; got != tt.wantPaused {
+				t.Errorf("%s annotation set = %v, want %v", rolloutPausedAnnotation, got, tt.wantPaused)
+			}
+		})
+	}
+}
+
+func TestSetRolloutPausedCondition(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Annotations = map[string]string{pauseRolloutAnnotation: "50"}
+	paused := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        deploymentName(skyflo, "engine", selectorVersion(skyflo)),
+		Namespace:   "default",
+		Annotations: map[string]string{rolloutPausedAnnotation: "true"},
+	}}
+	r := newTestReconciler([]client.Object{paused})
+
+	if err := r.setRolloutPausedCondition(context.Background(), skyflo); err != nil {
+		t.Fatalf("setRolloutPausedCondition: %v", err)
+	}
+	if len(skyflo.Status.Conditions) != 1 || skyflo.Status.Conditions[0].Type != "RolloutPaused" {
+		t.Fatalf("conditions = %+v, want RolloutPaused", skyflo.Status.Conditions)
+	}
+
+	if err := r.Delete(context.Background(), paused); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.setRolloutPausedCondition(context.Background(), skyflo); err != nil {
+		t.Fatalf("setRolloutPausedCondition: %v", err)
+	}
+	if len(skyflo.Status.Conditions) != 0 {
+		t.Errorf("conditions = %+v, want none once resumed", skyflo.Status.Conditions)
+	}
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// checkSchedulable verifies that at least one node satisfies the node
+// selector, required node affinity and tolerations of every component and
+// records the outcome in the Unschedulable condition.
+func (r *SkyfloAIReconciler) checkSchedulable(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return err
+	}
+
+	var unschedulable []string
+	for _, c := range components(skyflo) {
+		if !anyNodeFits(nodes.Items, &r.deployment(skyflo, c).Spec.Template.Spec) {
+			unschedulable = append(unschedulable, c.displayName)
+		}
+	}
+
+	if len(unschedulable) == 0 {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "Unschedulable",
+			Status:             metav1.ConditionFalse,
+			Reason:             "NodesAvailable",
+			Message:            "every component matches at least one node",
+			ObservedGeneration: skyflo.Generation,
+		})
+		return nil
+	}
+
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "Unschedulable",
+		Status: metav1.ConditionTrue,
+		Reason: "NoMatchingNodes",
+		Message: fmt.Sprintf("no node matches the nodeSelector, required node affinity and tolerations of: %s",
+			strings.Join(unschedulable, ", ")),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return nil
+}
+
+// anyNodeFits reports whether at least one schedulable node satisfies the
+// pod's placement constraints.
+func anyNodeFits(nodes []corev1.Node, spec *corev1.PodSpec) bool {
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable {
+			continue
+		}
+		if !labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if !matchesRequiredAffinity(node, spec.Affinity) {
+			continue
+		}
+		if !toleratesTaints(node.Spec.Taints, spec.Tolerations) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesRequiredAffinity evaluates the required node affinity terms, which
+// are ORed together.
+func matchesRequiredAffinity(node *corev1.Node, affinity *corev1.Affinity) bool {
+	if affinity == nil || affinity.NodeAffinity == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		if matchesNodeSelectorTerm(node, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesNodeSelectorTerm(node *corev1.Node, term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+
+	for _, expr := range term.MatchExpressions {
+		if !matchesNodeSelectorRequirement(labels.Set(node.Labels), expr) {
+			return false
+		}
+	}
+	for _, expr := range term.MatchFields {
+		if expr.Key != "metadata.name" {
+			return false
+		}
+		if !matchesNodeSelectorRequirement(labels.Set{"metadata.name": node.Name}, expr) {
+			return false
+		}
+	}
+	return true
+}
+
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+func matchesNodeSelectorRequirement(set labels.Set, expr corev1.NodeSelectorRequirement) bool {
+	op, ok := nodeSelectorOperators[expr.Operator]
+	if !ok {
+		return false
+	}
+	requirement, err := labels.NewRequirement(expr.Key, op, expr.Values)
+	if err != nil {
+		return false
+	}
+	return requirement.Matches(set)
+}
+
+// toleratesTaints reports whether every NoSchedule and NoExecute taint is
+// tolerated. PreferNoSchedule taints never block scheduling.
+func toleratesTaints(taints []corev1.Taint, tolerations []corev1.Toleration) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
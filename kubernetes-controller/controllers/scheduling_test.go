@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCheckSchedulable(t *testing.T) {
+	spotTaint := corev1.Taint{Key: "cloud.google.com/gke-spot", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		name         string
+		nodes        []corev1.Node
+		nodeSelector map[string]string
+		tolerations  []corev1.Toleration
+		want         metav1.ConditionStatus
+		wantMessage  string
+	}{
+		{
+			name:  "plain node",
+			nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+			want:  metav1.ConditionFalse,
+		},
+		{
+			name:        "no nodes",
+			want:        metav1.ConditionTrue,
+			wantMessage: "no node matches the nodeSelector, required node affinity and tolerations of: UI, Engine, MCP",
+		},
+		{
+			name:         "selector matches no node",
+			nodes:        []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"pool": "cpu"}}}},
+			nodeSelector: map[string]string{"pool": "gpu"},
+			want:         metav1.ConditionTrue,
+			wantMessage:  "no node matches the nodeSelector, required node affinity and tolerations of: UI, Engine, MCP",
+		},
+		{
+			name:         "selector matches",
+			nodes:        []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"pool": "gpu"}}}},
+			nodeSelector: map[string]string{"pool": "gpu"},
+			want:         metav1.ConditionFalse,
+		},
+		{
+			// Only the spot Engine tolerates the spot taint.
+			name:        "taint not tolerated",
+			nodes:       []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: corev1.NodeSpec{Taints: []corev1.Taint{spotTaint}}}},
+			want:        metav1.ConditionTrue,
+			wantMessage: "no node matches the nodeSelector, required node affinity and tolerations of: UI, MCP",
+		},
+		{
+			name:        "taint tolerated",
+			nodes:       []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: corev1.NodeSpec{Taints: []corev1.Taint{spotTaint}}}},
+			tolerations: []corev1.Toleration{{Key: spotTaint.Key, Operator: corev1.TolerationOpExists}},
+			want:        metav1.ConditionFalse,
+		},
+		{
+			name:        "cordoned node",
+			nodes:       []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: corev1.NodeSpec{Unschedulable: true}}},
+			want:        metav1.ConditionTrue,
+			wantMessage: "no node matches the nodeSelector, required node affinity and tolerations of: UI, Engine, MCP",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.NodeSelector = tt.nodeSelector
+			skyflo.Spec.Tolerations = tt.tolerations
+			skyflo.Spec.Engine.Spot = true
+			var objs []client.Object
+			for i := range tt.nodes {
+				objs = append(objs, &tt.nodes[i])
+			}
+			r := newTestReconciler(objs)
+
+			if err := r.checkSchedulable(context.Background(), skyflo); err != nil {
+				t.Fatalf("checkSchedulable: %v", err)
+			}
+			condition := meta.FindStatusCondition(skyflo.Status.Conditions, "Unschedulable")
+			if condition == nil {
+				t.Fatal("no Unschedulable condition")
+			}
+			if condition.Status != tt.want {
+				t.Errorf("status = %s, want %s", condition.Status, tt.want)
+			}
+			if tt.wantMessage != "" && condition.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", condition.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestMatchesRequiredAffinity(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"zone": "z1"}}}
+	term := func(exprs ...corev1.NodeSelectorRequirement) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: exprs}
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	inZone := func(zone string) corev1.NodeSelectorRequirement {
+		return corev1.NodeSelectorRequirement{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{zone}}
+	}
+	tests := []struct {
+		name     string
+		affinity *corev1.Affinity
+		want     bool
+	}{
+		{name: "no affinity", want: true},
+		{name: "matching term", affinity: affinity(term(inZone("z1"))), want: true},
+		{name: "terms are ORed", affinity: affinity(term(inZone("z2")), term(inZone("z1"))), want: true},
+		{name: "expressions are ANDed", affinity: affinity(term(inZone("z1"), inZone("z2")))},
+		{name: "empty term matches nothing", affinity: affinity(term())},
+		{name: "node name field", affinity: affinity(corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+		}}), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesRequiredAffinity(node, tt.affinity); got != tt.want {
+				t.Errorf("matchesRequiredAffinity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// The UI is a standalone Next.js build whose response headers are fixed at
+// image build time, so configured security headers are added by an nginx
+// sidecar that fronts the UI container inside the pod.
+const (
+	securityHeadersContainer    = "security-headers"
+	securityHeadersPort         = 8080
+	securityHeadersVolume       = "security-headers-config"
+	securityHeadersHashKey      = "skyflo.ai/security-headers-hash"
+	defaultSecurityHeadersImage = "nginxinc/nginx-unprivileged:1.27-alpine"
+)
+
+// reconcileSecurityHeaders maintains the nginx configuration of the UI
+// security headers proxy, removing it once no headers are configured.
+func (r *SkyfloAIReconciler) reconcileSecurityHeaders(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	name := skyflo.Name + "-ui-security-headers"
+	if len(skyflo.Spec.UI.SecurityHeaders) == 0 {
+		return r.deleteIfOwned(ctx, skyflo, &corev1.ConfigMap{}, name)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Data: map[string]string{
+			"default.conf": securityHeadersConfig(skyflo.Spec.UI.SecurityHeaders),
+		},
+	}
+	if err := r.own(skyflo, configMap); err != nil {
+		return err
+	}
+	return r.createOrUpdateConfigMap(ctx, configMap)
+}
+
+// headerValueEscaper quotes a header value for an nginx string. nginx has no
+// escape for '$', which would start a variable, so it is written through the
+// $dollar variable holding a literal '$'.
+var headerValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `${dollar}`)
+
+// securityHeadersConfig renders an nginx server that proxies to the UI
+// container and adds every header, in name order, to all responses.
+func securityHeadersConfig(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	dollar := false
+	for name, value := range headers {
+		names = append(names, name)
+		dollar = dollar || strings.Contains(value, "$")
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	if dollar {
+		b.WriteString("geo $dollar {\n")
+		b.WriteString("    default \"$\";\n")
+		b.WriteString("}\n\n")
+	}
+	b.WriteString("map $http_upgrade $connection_upgrade {\n")
+	b.WriteString("    default upgrade;\n")
+	b.WriteString("    ''      close;\n")
+	b.WriteString("}\n\n")
+	b.WriteString("server {\n")
+	fmt.Fprintf(&b, "    listen %d;\n\n", securityHeadersPort)
+	b.WriteString("    location / {\n")
+	b.WriteString("        proxy_pass http://127.0.0.1:3000;\n")
+	b.WriteString("        proxy_http_version 1.1;\n")
+	b.WriteString("        proxy_set_header Host $host;\n")
+	b.WriteString("        proxy_set_header Upgrade $http_upgrade;\n")
+	b.WriteString("        proxy_set_header Connection $connection_upgrade;\n")
+	b.WriteString("        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n")
+	b.WriteString("        proxy_set_header X-Forwarded-Proto $scheme;\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "        add_header %s \"%s\" always;\n", name, headerValueEscaper.Replace(headers[name]))
+	}
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// addSecurityHeadersProxy adds the security headers sidecar to the UI
+// Deployment. The pod template is annotated with a hash of the headers so a
+// configuration change rolls the pods.
+func addSecurityHeadersProxy(skyflo *skyflov1.SkyfloAI, deployment *appsv1.Deployment) {
+	ui := skyflo.Spec.UI
+	image := ui.SecurityHeadersProxyImage
+	if image == "" {
+		image = defaultSecurityHeadersImage
+	}
+
+	sum := sha256.Sum256([]byte(securityHeadersConfig(ui.SecurityHeaders)))
+	template := &deployment.Spec.Template
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[securityHeadersHashKey] = hex.EncodeToString(sum[:])[:16]
+
+	template.Spec.Containers = append(template.Spec.Containers, corev1.Container{
+		Name:  securityHeadersContainer,
+		Image: image,
+		Ports: []corev1.ContainerPort{
+			{
+				ContainerPort: securityHeadersPort,
+				Name:          "http-proxy",
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      securityHeadersVolume,
+				MountPath: "/etc/nginx/conf.d/default.conf",
+				SubPath:   "default.conf",
+				ReadOnly:  true,
+			},
+		},
+	})
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: securityHeadersVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: skyflo.Name + "-ui-security-headers",
+				},
+			},
+		},
+	})
+}
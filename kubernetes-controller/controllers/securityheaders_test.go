@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSecurityHeadersConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		want      []string
+		wantGeo   bool
+		forbidden []string
+	}{
+		{
+			name:    "headers in name order",
+			headers: map[string]string{"X-Frame-Options": "DENY", "Content-Security-Policy": "default-src 'self'"},
+			want: []string{
+				`add_header Content-Security-Policy "default-src 'self'" always;
+        add_header X-Frame-Options "DENY" always;`,
+			},
+		},
+		{
+			name:    "quotes and backslashes",
+			headers: map[string]string{"X-Test": `a "b" \c`},
+			want:    []string{`add_header X-Test "a \"b\" \\c" always;`},
+		},
+		{
+			name:      "dollar kept literal",
+			headers:   map[string]string{"Content-Security-Policy": "script-src 'nonce-$request_id'"},
+			want:      []string{`add_header Content-Security-Policy "script-src 'nonce-${dollar}request_id'" always;`},
+			wantGeo:   true,
+			forbidden: []string{"'nonce-$request_id'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := securityHeadersConfig(tt.headers)
+			for _, want := range tt.want {
+				if !strings.Contains(config, want) {
+					t.Errorf("config does not contain %q:\n%s", want, config)
+				}
+			}
+			for _, forbidden := range tt.forbidden {
+				if strings.Contains(config, forbidden) {
+					t.Errorf("config contains %q:\n%s", forbidden, config)
+				}
+			}
+			if geo := strings.Contains(config, "geo $dollar {\n    default \"$\";\n}"); geo != tt.wantGeo {
+				t.Errorf("$dollar defined = %v, want %v", geo, tt.wantGeo)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersProxy(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.SecurityHeaders = map[string]string{"X-Frame-Options": "DENY"}
+	r := newTestReconciler(nil)
+
+	if err := r.reconcileSecurityHeaders(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileSecurityHeaders: %v", err)
+	}
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-ui-security-headers"}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		t.Fatalf("ConfigMap was not created: %v", err)
+	}
+	if configMap.Data["default.conf"] != securityHeadersConfig(skyflo.Spec.UI.SecurityHeaders) {
+		t.Errorf("ConfigMap holds %q", configMap.Data["default.conf"])
+	}
+
+	ui := components(skyflo)[0]
+	deployment := r.deployment(skyflo, ui)
+	var sidecars []corev1.Container
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == securityHeadersContainer {
+			sidecars = append(sidecars, container)
+		}
+	}
+	if len(sidecars) != 1 || sidecars[0].Image != defaultSecurityHeadersImage {
+		t.Fatalf("want one %s sidecar with the default image, got %+v", securityHeadersContainer, sidecars)
+	}
+	before := deployment.Spec.Template.Annotations[securityHeadersHashKey]
+	skyflo.Spec.UI.SecurityHeaders["X-Frame-Options"] = "SAMEORIGIN"
+	changed := r.deployment(skyflo, ui)
+	if changed.Spec.Template.Annotations[securityHeadersHashKey] == before {
+		t.Error("changing a header did not change the pod template hash")
+	}
+
+	skyflo.Spec.UI.SecurityHeaders = nil
+	if err := r.reconcileSecurityHeaders(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileSecurityHeaders: %v", err)
+	}
+	if err := r.Get(ctx, key, configMap); err == nil {
+		t.Error("ConfigMap was kept after the headers were removed")
+	}
+}
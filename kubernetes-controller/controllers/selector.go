@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// selectorVersionAnnotation selects the labeling scheme of the component pods.
+// Deployment selectors are immutable, so changing it migrates every component
+// to a new Deployment: the new Deployment is rolled out next to the old one,
+// the Service is flipped once it is ready, and only then is the old Deployment
+// deleted.
+const selectorVersionAnnotation = "skyflo.ai/selector-version"
+
+const (
+	// selectorV1 selects pods by app=<name>-<component>.
+	selectorV1 = "1"
+	// selectorV2 selects pods by the app.kubernetes.io instance and component
+	// labels, from Deployments named <name>-<component>-v2.
+	selectorV2 = "2"
+)
+
+// selectorVersion returns the labeling scheme requested for the SkyfloAI.
+func selectorVersion(skyflo *skyflov1.SkyfloAI) string {
+	if skyflo.Annotations[selectorVersionAnnotation] == selectorV2 {
+		return selectorV2
+	}
+	return selectorV1
+}
+
+// otherSelectorVersion returns the labeling scheme being migrated away from.
+func otherSelectorVersion(version string) string {
+	if version == selectorV2 {
+		return selectorV1
+	}
+	return selectorV2
+}
+
+// deploymentName returns the name of a component Deployment under the given
+// labeling scheme.
+func deploymentName(skyflo *skyflov1.SkyfloAI, name, version string) string {
+	if version == selectorV2 {
+		return skyflo.Name + "-" + name + "-v2"
+	}
+	return skyflo.Name + "-" + name
+}
+
+// podLabels returns the labels selecting a component's pods under the given
+// labeling scheme.
+func podLabels(skyflo *skyflov1.SkyfloAI, name, version string) map[string]string {
+	if version == selectorV2 {
+		return map[string]string{
+			"app.kubernetes.io/name":      "skyflo",
+			"app.kubernetes.io/instance":  skyflo.Name,
+			"app.kubernetes.io/component": name,
+		}
+	}
+	return map[string]string{
+		"app": skyflo.Name + "-" + name,
+	}
+}
+
+// podTemplateLabels returns the labels of a component's pod template: the
+// common labels and the selector labels of the given scheme. The common
+// labels are kept from completing the other scheme's selector, so while a
+// selector migration runs both Deployments, the Service, PodDisruptionBudget
+// and autoscaler of each only select its own pods: version 1 pods do not
+// carry app.kubernetes.io/component, and no pod carries an app label from
+// spec.commonLabels.
+func podTemplateLabels(skyflo *skyflov1.SkyfloAI, name, version string) map[string]string {
+	labels := commonLabels(skyflo, name)
+	delete(labels, "app")
+	if version == selectorV1 {
+		delete(labels, "app.kubernetes.io/component")
+	}
+	return mergeMaps(labels, podLabels(skyflo, name, version))
+}
+
+// serviceSelector points the component Service at the old Deployment's pods
+// while a selector migration waits for the new Deployment to become ready. It
+// reports whether the migration, if any, has completed.
+func (r *SkyfloAIReconciler) serviceSelector(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component, service *corev1.Service) (bool, error) {
+	version := selectorVersion(skyflo)
+	old := otherSelectorVersion(version)
+
+	oldDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, old), Namespace: skyflo.Namespace}, oldDeployment)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, version), Namespace: skyflo.Namespace}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil && rolledOut(current) {
+		return true, nil
+	}
+
+	log.FromContext(ctx).Info("waiting for the migrated Deployment to become ready before switching the Service",
+		"component", c.displayName, "selectorVersion", version)
+	service.Spec.Selector = podLabels(skyflo, c.name, old)
+	return false, nil
+}
+
+// deleteOldDeployment removes a component's Deployment under the labeling
+// scheme being migrated away from.
+func (r *SkyfloAIReconciler) deleteOldDeployment(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component) error {
+	old := otherSelectorVersion(selectorVersion(skyflo))
+	return r.deleteIfOwned(ctx, skyflo, &appsv1.Deployment{}, deploymentName(skyflo, c.name, old))
+}
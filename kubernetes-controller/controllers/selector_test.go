@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestSelectorMigration(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	reconcileOnce(t, r)
+
+	skyflo := &skyflov1.SkyfloAI{}
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	if err := r.Get(ctx, key, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	skyflo.Annotations = map[string]string{selectorVersionAnnotation: selectorV2}
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	oldKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	newKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine-v2"}
+	serviceKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	migrated := &appsv1.Deployment{}
+	if err := r.Get(ctx, newKey, migrated); err != nil {
+		t.Fatalf("migrated Deployment was not created: %v", err)
+	}
+	if want := podLabels(skyflo, "engine", selectorV2); !reflect.DeepEqual(migrated.Spec.Selector.MatchLabels, want) {
+		t.Errorf("migrated selector = %v, want %v", migrated.Spec.Selector.MatchLabels, want)
+	}
+	if err := r.Get(ctx, oldKey, &appsv1.Deployment{}); err != nil {
+		t.Errorf("old Deployment removed before the migrated one is ready: %v", err)
+	}
+	service := &corev1.Service{}
+	if err := r.Get(ctx, serviceKey, service); err != nil {
+		t.Fatal(err)
+	}
+	if want := podLabels(skyflo, "engine", selectorV1); !reflect.DeepEqual(service.Spec.Selector, want) {
+		t.Errorf("Service selector while migrating = %v, want the old pods %v", service.Spec.Selector, want)
+	}
+
+	migrated.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: migrated.Generation,
+		UpdatedReplicas:    *migrated.Spec.Replicas,
+		AvailableReplicas:  *migrated.Spec.Replicas,
+	}
+	if err := r.Status().Update(ctx, migrated); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	if err := r.Get(ctx, serviceKey, service); err != nil {
+		t.Fatal(err)
+	}
+	if want := podLabels(skyflo, "engine", selectorV2); !reflect.DeepEqual(service.Spec.Selector, want) {
+		t.Errorf("Service selector after migrating = %v, want the new pods %v", service.Spec.Selector, want)
+	}
+	if err := r.Get(ctx, oldKey, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Errorf("old Deployment still present after the migration: %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// create writes a new child object or, with ServerSideApply, applies it.
+func (r *SkyfloAIReconciler) create(ctx context.Context, obj client.Object) error {
+	if r.ServerSideApply {
+		return r.apply(ctx, obj)
+	}
+	return r.Create(ctx, obj, r.fieldOwner())
+}
+
+// update replaces a child object or, with ServerSideApply, applies it.
+func (r *SkyfloAIReconciler) update(ctx context.Context, obj client.Object) error {
+	if r.ServerSideApply {
+		return r.apply(ctx, obj)
+	}
+	return r.Update(ctx, obj, r.fieldOwner())
+}
+
+// apply writes obj with server-side apply without forcing ownership. When
+// other field managers own some of its fields, the fields listed in
+// ForceApplyFields are taken over and the others are dropped from the
+// applied configuration and left to their managers; those are reported in a
+// fieldManagerConflictError once the rest of obj has been applied.
+func (r *SkyfloAIReconciler) apply(ctx context.Context, obj client.Object) error {
+	config, err := r.applyConfiguration(obj)
+	if err != nil {
+		return err
+	}
+	err = r.Patch(ctx, config, client.Apply, r.fieldOwner())
+	conflicts := fieldManagerConflicts(err)
+	if conflicts == nil {
+		if err != nil {
+			return err
+		}
+		return r.readBack(config, obj)
+	}
+
+	var yielded []fieldConflict
+	for _, conflict := range conflicts {
+		if r.forceApplied(conflict.field) {
+			continue
+		}
+		yielded = append(yielded, conflict)
+	}
+	conflictErr := &fieldManagerConflictError{
+		kind:      config.GetKind(),
+		name:      obj.GetName(),
+		conflicts: yielded,
+	}
+	for _, conflict := range yielded {
+		if !removeField(config.Object, conflict.field) {
+			// The field cannot be left out; keep the object as it is.
+			return conflictErr
+		}
+	}
+	if err := r.Patch(ctx, config, client.Apply, r.fieldOwner(), client.ForceOwnership); err != nil {
+		return err
+	}
+	if err := r.readBack(config, obj); err != nil {
+		return err
+	}
+	if len(yielded) > 0 {
+		return conflictErr
+	}
+	return nil
+}
+
+// applyConfiguration converts obj to the unstructured configuration sent with
+// server-side apply, without the fields the server sets.
+func (r *SkyfloAIReconciler) applyConfiguration(obj client.Object) (*unstructured.Unstructured, error) {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	config := &unstructured.Unstructured{Object: content}
+	config.SetGroupVersionKind(gvk)
+	config.SetResourceVersion("")
+	config.SetManagedFields(nil)
+	unstructured.RemoveNestedField(config.Object, "status")
+	removeNulls(config.Object)
+	return config, nil
+}
+
+// removeNulls drops the null fields typed objects serialize for unset
+// values, such as creationTimestamp, which an apply would otherwise claim.
+func removeNulls(node interface{}) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if value == nil {
+				delete(node, key)
+				continue
+			}
+			removeNulls(value)
+		}
+	case []interface{}:
+		for _, item := range node {
+			removeNulls(item)
+		}
+	}
+}
+
+// readBack copies the object returned by the apply into obj, as Create and
+// Update would have.
+func (r *SkyfloAIReconciler) readBack(config *unstructured.Unstructured, obj client.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		u.Object = config.Object
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(config.Object, obj)
+}
+
+// forceApplied reports whether field, or a field containing it, is listed in
+// ForceApplyFields.
+func (r *SkyfloAIReconciler) forceApplied(field string) bool {
+	for _, forced := range r.ForceApplyFields {
+		if field == forced || strings.HasPrefix(field, forced+".") || strings.HasPrefix(field, forced+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldConflict is a field of an applied object owned by another manager.
+type fieldConflict struct {
+	field   string
+	manager string
+}
+
+// fieldManagerConflictError reports the fields of a child object left to other
+// field managers instead of being overwritten.
+type fieldManagerConflictError struct {
+	kind      string
+	name      string
+	conflicts []fieldConflict
+}
+
+func (e *fieldManagerConflictError) Error() string {
+	return fmt.Sprintf("%s %s: fields owned by other managers: %s", e.kind, e.name, e.fields())
+}
+
+func (e *fieldManagerConflictError) fields() string {
+	fields := make([]string, 0, len(e.conflicts))
+	for _, conflict := range e.conflicts {
+		fields = append(fields, fmt.Sprintf("%s (%s)", conflict.field, conflict.manager))
+	}
+	return strings.Join(fields, ", ")
+}
+
+var conflictManager = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// fieldManagerConflicts returns the conflicts an apply was rejected for, or
+// nil when err is not an apply conflict.
+func fieldManagerConflicts(err error) []fieldConflict {
+	if !apierrors.IsConflict(err) {
+		return nil
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+	var conflicts []fieldConflict
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflict := fieldConflict{field: cause.Field, manager: cause.Message}
+		if match := conflictManager.FindStringSubmatch(cause.Message); match != nil {
+			conflict.manager = match[1]
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// setFieldManagerConflictCondition reports the fields left to other field
+// managers, if any.
+func setFieldManagerConflictCondition(skyflo *skyflov1.SkyfloAI, errs []error) {
+	var conflicts []string
+	for _, err := range errs {
+		var conflict *fieldManagerConflictError
+		if errors.As(err, &conflict) {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s: %s", conflict.kind, conflict.name, conflict.fields()))
+		}
+	}
+	if len(conflicts) == 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "FieldManagerConflict")
+		return
+	}
+
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:               "FieldManagerConflict",
+		Status:             metav1.ConditionTrue,
+		Reason:             "FieldsOwnedElsewhere",
+		Message:            fmt.Sprintf("left to other field managers: %s", strings.Join(conflicts, "; ")),
+		ObservedGeneration: skyflo.Generation,
+	})
+}
+
+// pathElement is one step of a field path as reported in apply conflicts,
+// such as .spec, [name="engine"], [="value"] or [0].
+type pathElement struct {
+	field string
+	keys  map[string]interface{}
+	value interface{}
+	index int
+	kind  byte
+}
+
+const (
+	fieldElement = 'f'
+	keyElement   = 'k'
+	valueElement = 'v'
+	indexElement = 'i'
+)
+
+// parsePath parses a field path such as
+// .spec.template.spec.containers[name="engine"].image.
+func parsePath(path string) ([]pathElement, bool) {
+	var elements []pathElement
+	for path != "" {
+		switch path[0] {
+		case '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			elements = append(elements, pathElement{kind: fieldElement, field: path[1 : end+1]})
+			path = path[end+1:]
+		case '[':
+			element, rest, ok := parseSelector(path[1:])
+			if !ok {
+				return nil, false
+			}
+			elements = append(elements, element)
+			path = rest
+		default:
+			return nil, false
+		}
+	}
+	return elements, len(elements) > 0
+}
+
+// parseSelector parses the list selector following a '[' and returns the
+// remainder of the path after the closing ']'.
+func parseSelector(path string) (pathElement, string, bool) {
+	if end := strings.IndexByte(path, ']'); end > 0 {
+		if index, err := strconv.Atoi(path[:end]); err == nil {
+			return pathElement{kind: indexElement, index: index}, path[end+1:], true
+		}
+	}
+	if strings.HasPrefix(path, "=") {
+		value, rest, ok := parseJSONValue(path[1:])
+		if !ok || !strings.HasPrefix(rest, "]") {
+			return pathElement{}, "", false
+		}
+		return pathElement{kind: valueElement, value: value}, rest[1:], true
+	}
+
+	element := pathElement{kind: keyElement, keys: map[string]interface{}{}}
+	for {
+		eq := strings.IndexByte(path, '=')
+		if eq <= 0 {
+			return pathElement{}, "", false
+		}
+		value, rest, ok := parseJSONValue(path[eq+1:])
+		if !ok {
+			return pathElement{}, "", false
+		}
+		element.keys[path[:eq]] = value
+		switch {
+		case strings.HasPrefix(rest, ","):
+			path = rest[1:]
+		case strings.HasPrefix(rest, "]"):
+			return element, rest[1:], true
+		default:
+			return pathElement{}, "", false
+		}
+	}
+}
+
+// parseJSONValue decodes the JSON value at the start of s.
+func parseJSONValue(s string) (interface{}, string, bool) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, "", false
+	}
+	return value, s[decoder.InputOffset():], true
+}
+
+// removeField deletes the field at path from an unstructured object,
+// reporting whether it was found.
+func removeField(obj map[string]interface{}, path string) bool {
+	elements, ok := parsePath(path)
+	if !ok {
+		return false
+	}
+	_, removed := removeElements(obj, elements)
+	return removed
+}
+
+func removeElements(node interface{}, elements []pathElement) (interface{}, bool) {
+	element := elements[0]
+	if element.kind == fieldElement {
+		fields, ok := node.(map[string]interface{})
+		if !ok {
+			return node, false
+		}
+		child, ok := fields[element.field]
+		if !ok {
+			return node, false
+		}
+		if len(elements) == 1 {
+			delete(fields, element.field)
+			return fields, true
+		}
+		child, removed := removeElements(child, elements[1:])
+		fields[element.field] = child
+		return fields, removed
+	}
+
+	items, ok := node.([]interface{})
+	if !ok {
+		return node, false
+	}
+	for i, item := range items {
+		if !element.matches(i, item) {
+			continue
+		}
+		if len(elements) == 1 {
+			return append(items[:i], items[i+1:]...), true
+		}
+		child, removed := removeElements(item, elements[1:])
+		items[i] = child
+		return items, removed
+	}
+	return node, false
+}
+
+// matches reports whether the list item at index i is the one selected.
+// JSON numbers decode as floats and unstructured ones are integers, so
+// values are compared by their printed form.
+func (e pathElement) matches(i int, item interface{}) bool {
+	switch e.kind {
+	case indexElement:
+		return i == e.index
+	case valueElement:
+		return fmt.Sprint(item) == fmt.Sprint(e.value)
+	}
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, value := range e.keys {
+		if fmt.Sprint(fields[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// fieldManagers returns interceptor funcs recording the field manager of
+// every create and update, and of every apply patch, which they accept
+// without writing since the fake client cannot apply.
+func fieldManagers(managers *[]string) interceptor.Funcs {
+	return interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			options := &client.CreateOptions{}
+			options.ApplyOptions(opts)
+			*managers = append(*managers, options.FieldManager)
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			options := &client.UpdateOptions{}
+			options.ApplyOptions(opts)
+			*managers = append(*managers, options.FieldManager)
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			options := &client.PatchOptions{}
+			options.ApplyOptions(opts)
+			*managers = append(*managers, options.FieldManager)
+			return nil
+		},
+	}
+}
+
+func TestFieldOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		fieldOwner string
+		want       string
+	}{
+		{name: "default", want: "skyflo-controller"},
+		{name: "configured", fieldOwner: "platform-skyflo", want: "platform-skyflo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var managers []string
+			r := newTestReconciler([]client.Object{testSkyfloAI()}, fieldManagers(&managers))
+			r.FieldOwner = tt.fieldOwner
+			reconcileOnce(t, r)
+
+			if len(managers) == 0 {
+				t.Fatal("reconcile wrote no objects")
+			}
+			for _, manager := range managers {
+				if manager != tt.want {
+					t.Errorf("write with field manager %q, want %q", manager, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldOwnerApply(t *testing.T) {
+	var managers []string
+	r := newTestReconciler(nil, fieldManagers(&managers))
+	r.ServerSideApply = true
+	r.FieldOwner = "platform-skyflo"
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "skyflo-ui", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	}
+	if err := r.create(context.Background(), service); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(managers) != 1 || managers[0] != "platform-skyflo" {
+		t.Errorf("apply field managers = %v, want [platform-skyflo]", managers)
+	}
+}
+
+// conflictCause returns the apply conflict cause the API server reports for
+// a field owned by manager.
+func conflictCause(field, manager string) metav1.StatusCause {
+	return metav1.StatusCause{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: fmt.Sprintf("conflict with %q using apps/v1", manager),
+		Field:   field,
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	ctx := context.Background()
+	replicas := conflictCause(".spec.replicas", "hpa-controller")
+	image := conflictCause(`.spec.template.spec.containers[name="engine"].image`, "kubectl-edit")
+	// applied holds the forced apply configuration of the Engine Deployment.
+	var applied *unstructured.Unstructured
+	conflicting := true
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			if obj.GetName() != "skyflo-engine" || obj.GetObjectKind().GroupVersionKind().Kind != "Deployment" {
+				return nil
+			}
+			options := &client.PatchOptions{}
+			options.ApplyOptions(opts)
+			if conflicting && (options.Force == nil || !*options.Force) {
+				return apierrors.NewApplyConflict([]metav1.StatusCause{replicas, image}, "Apply failed with 2 conflicts")
+			}
+			applied = obj.(*unstructured.Unstructured).DeepCopy()
+			return nil
+		},
+	})
+	r.ServerSideApply = true
+	r.ForceApplyFields = []string{".spec.template.spec.containers"}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+
+	if _, err := r.Reconcile(ctx, req); err == nil || !strings.Contains(err.Error(), ".spec.replicas (hpa-controller)") {
+		t.Fatalf("Reconcile error = %v, want the conflict on .spec.replicas", err)
+	}
+	if applied == nil {
+		t.Fatal("Engine Deployment not applied after the conflict")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(applied.Object, "spec", "replicas"); found {
+		t.Errorf("forced apply claims .spec.replicas, want it left to hpa-controller")
+	}
+	containers, _, _ := unstructured.NestedSlice(applied.Object, "spec", "template", "spec", "containers")
+	if len(containers) == 0 || containers[0].(map[string]interface{})["image"] != "skyflo/engine:test" {
+		t.Errorf("forced apply containers = %v, want the force-listed image kept", containers)
+	}
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, "FieldManagerConflict")
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "FieldsOwnedElsewhere" {
+		t.Fatalf("FieldManagerConflict = %+v, want True", condition)
+	}
+	if want := "Deployment skyflo-engine: .spec.replicas (hpa-controller)"; !strings.Contains(condition.Message, want) {
+		t.Errorf("FieldManagerConflict message = %q, want it to contain %q", condition.Message, want)
+	}
+	if strings.Contains(condition.Message, "kubectl-edit") {
+		t.Errorf("FieldManagerConflict message = %q, want the forced image left out", condition.Message)
+	}
+
+	// Once the other manager lets go, the condition is removed.
+	conflicting = false
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	if condition := meta.FindStatusCondition(got.Status.Conditions, "FieldManagerConflict"); condition != nil {
+		t.Errorf("FieldManagerConflict = %+v, want it removed without conflicts", condition)
+	}
+}
+
+func TestFieldManagerConflicts(t *testing.T) {
+	err := apierrors.NewApplyConflict([]metav1.StatusCause{
+		conflictCause(".spec.replicas", "hpa-controller"),
+		{Type: metav1.CauseTypeFieldValueInvalid, Field: ".spec.selector"},
+		{Type: metav1.CauseTypeFieldManagerConflict, Field: ".metadata.labels.team", Message: "unexpected format"},
+	}, "Apply failed")
+	want := []fieldConflict{
+		{field: ".spec.replicas", manager: "hpa-controller"},
+		{field: ".metadata.labels.team", manager: "unexpected format"},
+	}
+	if got := fieldManagerConflicts(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("fieldManagerConflicts = %+v, want %+v", got, want)
+	}
+	if got := fieldManagerConflicts(apierrors.NewNotFound(corev1.Resource("services"), "skyflo-ui")); got != nil {
+		t.Errorf("fieldManagerConflicts of a NotFound = %+v, want nil", got)
+	}
+	if got := fieldManagerConflicts(nil); got != nil {
+		t.Errorf("fieldManagerConflicts(nil) = %+v, want nil", got)
+	}
+}
+
+func TestRemoveField(t *testing.T) {
+	object := func() map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"app": "skyflo", "team": "ml"},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(2),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "engine", "image": "skyflo/engine:test"},
+							map[string]interface{}{"name": "proxy", "image": "envoy"},
+						},
+					},
+				},
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80), "protocol": "TCP", "name": "http"},
+					map[string]interface{}{"port": int64(80), "protocol": "UDP", "name": "dns"},
+				},
+				"finalizers": []interface{}{"a", "b"},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		path    string
+		check   []string
+		want    interface{}
+		removed bool
+	}{
+		{name: "field", path: ".spec.replicas", check: []string{"spec", "replicas"}, removed: true},
+		{name: "map key", path: ".metadata.labels.team", check: []string{"metadata", "labels"}, want: map[string]interface{}{"app": "skyflo"}, removed: true},
+		{
+			name:    "keyed list item field",
+			path:    `.spec.template.spec.containers[name="proxy"].image`,
+			check:   []string{"spec", "template", "spec", "containers"},
+			want:    []interface{}{map[string]interface{}{"name": "engine", "image": "skyflo/engine:test"}, map[string]interface{}{"name": "proxy"}},
+			removed: true,
+		},
+		{
+			name:    "multi-key list item",
+			path:    `.spec.ports[port=80,protocol="UDP"]`,
+			check:   []string{"spec", "ports"},
+			want:    []interface{}{map[string]interface{}{"port": int64(80), "protocol": "TCP", "name": "http"}},
+			removed: true,
+		},
+		{name: "set value", path: `.spec.finalizers[="a"]`, check: []string{"spec", "finalizers"}, want: []interface{}{"b"}, removed: true},
+		{name: "index", path: ".spec.finalizers[1]", check: []string{"spec", "finalizers"}, want: []interface{}{"a"}, removed: true},
+		{name: "missing field", path: ".spec.paused"},
+		{name: "missing list item", path: `.spec.template.spec.containers[name="sidecar"].image`},
+		{name: "malformed", path: "spec.replicas"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := object()
+			if removed := removeField(obj, tt.path); removed != tt.removed {
+				t.Fatalf("removeField(%s) = %v, want %v", tt.path, removed, tt.removed)
+			}
+			if !tt.removed {
+				if !reflect.DeepEqual(obj, object()) {
+					t.Errorf("object changed although nothing was removed: %v", obj)
+				}
+				return
+			}
+			got, found, err := unstructured.NestedFieldNoCopy(obj, tt.check...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if found {
+					t.Errorf("%v = %v, want it removed", tt.check, got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v = %v, want %v", tt.check, got, tt.want)
+			}
+		})
+	}
+}
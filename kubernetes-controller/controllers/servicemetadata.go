@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The keys of the annotations and labels the controller sets on a Service
+// are recorded in these annotations, so that on the next write it can tell
+// its own keys, which it may remove, from those added by users or other
+// controllers, which it keeps.
+const (
+	managedAnnotationsAnnotation = "skyflo.ai/managed-annotations"
+	managedLabelsAnnotation      = "skyflo.ai/managed-labels"
+)
+
+// stampManagedMetadata records the annotation and label keys the desired
+// Service sets.
+func stampManagedMetadata(service *corev1.Service) {
+	annotationKeys := sortedKeys(service.Annotations)
+	labelKeys := sortedKeys(service.Labels)
+	if service.Annotations == nil {
+		service.Annotations = map[string]string{}
+	}
+	service.Annotations[managedAnnotationsAnnotation] = strings.Join(annotationKeys, ",")
+	service.Annotations[managedLabelsAnnotation] = strings.Join(labelKeys, ",")
+}
+
+// keepForeignMetadata copies onto the desired Service the annotations and
+// labels of the live one that the controller did not set in its last write.
+// Keys it set before but no longer wants are dropped.
+func keepForeignMetadata(service, found *corev1.Service) {
+	ownAnnotations := keySet(found.Annotations[managedAnnotationsAnnotation])
+	ownAnnotations[managedAnnotationsAnnotation] = true
+	ownAnnotations[managedLabelsAnnotation] = true
+	for key, value := range found.Annotations {
+		if _, desired := service.Annotations[key]; !desired && !ownAnnotations[key] {
+			service.Annotations[key] = value
+		}
+	}
+
+	ownLabels := keySet(found.Annotations[managedLabelsAnnotation])
+	for key, value := range found.Labels {
+		if _, desired := service.Labels[key]; desired || ownLabels[key] {
+			continue
+		}
+		if service.Labels == nil {
+			service.Labels = map[string]string{}
+		}
+		service.Labels[key] = value
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func keySet(joined string) map[string]bool {
+	set := map[string]bool{}
+	for _, key := range strings.Split(joined, ",") {
+		if key != "" {
+			set[key] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// isImmutableFieldError reports whether a Service update was rejected for
+// changing a field that is fixed once the Service exists, such as its cluster
+// IP, IP families or some type transitions.
+func isImmutableFieldError(err error) bool {
+	if !errors.IsInvalid(err) {
+		return false
+	}
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return false
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if strings.Contains(cause.Message, "immutable") || strings.Contains(cause.Message, "may not change") {
+			return true
+		}
+		if cause.Type == metav1.CauseType(field.ErrorTypeForbidden) && strings.HasPrefix(cause.Field, "spec.ipFamilies") {
+			return true
+		}
+	}
+	return false
+}
+
+// recreateService replaces a Service whose desired spec changes immutable
+// fields. The existing Service is deleted, guarded by its UID, and the
+// desired one created with fresh cluster IPs; its node ports are kept so
+// external clients keep reaching it.
+func (r *SkyfloAIReconciler) recreateService(ctx context.Context, skyflo *skyflov1.SkyfloAI, desired, found *corev1.Service, trafficDistribution *string, cause error) error {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(skyflo, corev1.EventTypeWarning, "ServiceRecreated",
+			"recreating Service %s: the update changes immutable fields: %v", found.Name, cause)
+	}
+
+	err := r.Delete(ctx, found, client.Preconditions{UID: &found.UID})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("deleting Service %s to recreate it: %w", found.Name, err)
+	}
+
+	desired.ResourceVersion = ""
+	desired.Spec.ClusterIP = ""
+	desired.Spec.ClusterIPs = nil
+	preserveNodePorts(desired, found)
+	obj, err := serviceObject(desired, trafficDistribution)
+	if err != nil {
+		return err
+	}
+	return r.create(ctx, obj)
+}
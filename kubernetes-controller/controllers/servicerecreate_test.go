@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// immutableServiceError is the rejection of an update changing the cluster
+// IP of a Service.
+func immutableServiceError(name string) error {
+	return apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, name, field.ErrorList{
+		field.Invalid(field.NewPath("spec", "clusterIPs").Index(0), "", "may not change once set"),
+	})
+}
+
+func TestIsImmutableFieldError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "cluster IP", err: immutableServiceError("skyflo-ui"), want: true},
+		{name: "immutable field", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "skyflo-ui", field.ErrorList{
+			field.Invalid(field.NewPath("spec", "clusterIP"), "None", "field is immutable"),
+		}), want: true},
+		{name: "IP families", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "skyflo-ui", field.ErrorList{
+			field.Forbidden(field.NewPath("spec", "ipFamilies").Index(0), "primary ipFamily can not be changed"),
+		}), want: true},
+		{name: "other invalid field", err: apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "skyflo-ui", field.ErrorList{
+			field.Invalid(field.NewPath("spec", "ports").Index(0).Child("port"), 0, "must be between 1 and 65535"),
+		})},
+		{name: "conflict", err: apierrors.NewConflict(schema.GroupResource{Resource: "services"}, "skyflo-ui", errors.New("stale"))},
+		{name: "not an API error", err: errors.New("field is immutable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isImmutableFieldError(tt.err); got != tt.want {
+				t.Errorf("isImmutableFieldError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceRecreate(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.ServiceType = corev1.ServiceTypeNodePort
+	reject, deleted := false, false
+	r := newTestReconciler([]client.Object{skyflo}, interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*corev1.Service); ok && obj.GetName() == "skyflo-ui" {
+				deleted = true
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*corev1.Service); ok && reject && obj.GetName() == "skyflo-ui" {
+				reject = false
+				return immutableServiceError(obj.GetName())
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	})
+	recorder := record.NewFakeRecorder(100)
+	r.Recorder = recorder
+	reconcileOnce(t, r)
+
+	// Stand in for the cluster allocating the Service's IP and node port.
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-ui"}
+	found := &corev1.Service{}
+	if err := r.Get(ctx, key, found); err != nil {
+		t.Fatal(err)
+	}
+	found.Spec.ClusterIP = "10.0.0.5"
+	found.Spec.ClusterIPs = []string{"10.0.0.5"}
+	found.Spec.Ports[0].NodePort = 30080
+	if err := r.Update(ctx, found); err != nil {
+		t.Fatal(err)
+	}
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+
+	reject = true
+	reconcileOnce(t, r)
+	if reject {
+		t.Fatal("the Service was not updated")
+	}
+
+	recreated := &corev1.Service{}
+	if err := r.Get(ctx, key, recreated); err != nil {
+		t.Fatalf("Service not recreated: %v", err)
+	}
+	if !deleted {
+		t.Error("Service not deleted, want it deleted and created anew")
+	}
+	if recreated.Spec.ClusterIP == "10.0.0.5" {
+		t.Error("recreated Service asks for the old cluster IP, want a fresh one")
+	}
+	if got := recreated.Spec.Ports[0].NodePort; got != 30080 {
+		t.Errorf("node port = %d, want the allocated 30080 preserved", got)
+	}
+
+	var warned bool
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		warned = warned || strings.HasPrefix(event, "Warning ServiceRecreated recreating Service skyflo-ui")
+	}
+	if !warned {
+		t.Error("no ServiceRecreated warning event")
+	}
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const sharedMemoryVolume = "shm"
+
+// addSharedMemory replaces the container runtime's 64Mi /dev/shm with a
+// memory-backed emptyDir of the given size. Pages written to it are charged
+// to the container's memory, so they count against its memory limit.
+func addSharedMemory(size resource.Quantity, deployment *appsv1.Deployment) {
+	spec := &deployment.Spec.Template.Spec
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: sharedMemoryVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMediumMemory,
+				SizeLimit: &size,
+			},
+		},
+	})
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      sharedMemoryVolume,
+		MountPath: "/dev/shm",
+	})
+}
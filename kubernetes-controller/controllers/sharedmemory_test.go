@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+func TestSharedMemory(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.SharedMemory = ptr.To(resource.MustParse("1Gi"))
+
+	spec := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec
+	var volume *corev1.Volume
+	for i := range spec.Volumes {
+		if spec.Volumes[i].Name == sharedMemoryVolume {
+			volume = &spec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.EmptyDir == nil {
+		t.Fatalf("volumes = %v, want a shm emptyDir", spec.Volumes)
+	}
+	if volume.EmptyDir.Medium != corev1.StorageMediumMemory {
+		t.Errorf("shm medium = %q, want Memory", volume.EmptyDir.Medium)
+	}
+	if limit := volume.EmptyDir.SizeLimit; limit == nil || limit.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("shm size limit = %v, want 1Gi", limit)
+	}
+	var mounted bool
+	for _, mount := range spec.Containers[0].VolumeMounts {
+		mounted = mounted || mount.Name == sharedMemoryVolume && mount.MountPath == "/dev/shm"
+	}
+	if !mounted {
+		t.Errorf("engine mounts = %v, want shm at /dev/shm", spec.Containers[0].VolumeMounts)
+	}
+
+	for _, c := range []component{components(skyflo)[0], components(skyflo)[2]} {
+		for _, volume := range r.deployment(skyflo, c).Spec.Template.Spec.Volumes {
+			if volume.Name == sharedMemoryVolume {
+				t.Errorf("%s has a shm volume, want it only on the Engine", c.name)
+			}
+		}
+	}
+}
@@ -2,15 +2,41 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -18,385 +44,1402 @@ import (
 	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
 )
 
+// restartAnnotationPrefix prefixes the pod template annotations that record
+// the revision of the components a dependent component was restarted for.
+const restartAnnotationPrefix = "skyflo.ai/restarted-for-"
+
+// schemaVersionAnnotation declares the SkyfloAI schema revision an object was
+// written against.
+const schemaVersionAnnotation = "skyflo.ai/schema-version"
+
+// specHashAnnotation records on each generated Deployment the hash of its
+// fully resolved spec, so only changes that reach the component change it.
+const specHashAnnotation = "skyflo.ai/spec-hash"
+
 // SkyfloAIReconciler reconciles a SkyfloAI object
 type SkyfloAIReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// ValidateScheduling enables checking that at least one node can host
+	// each component before reporting it as merely not ready.
+	ValidateScheduling bool
+
+	// ServerVersion is the API server version detected at startup. It gates
+	// Service fields that older clusters do not serve; nil assumes they are
+	// supported.
+	ServerVersion *version.Info
+
+	// Clock provides the time certificates are issued and rotated at.
+	// Defaults to the system clock.
+	Clock clock.PassiveClock
+
+	// FreezeUntil ends a change freeze during which no SkyfloAI is
+	// reconciled. The zero time disables the freeze.
+	FreezeUntil time.Time
+
+	// ReconcileDebounce delays reconciles triggered by SkyfloAI edits so
+	// that rapid successive edits collapse into one. Zero reconciles every
+	// edit immediately.
+	ReconcileDebounce time.Duration
+
+	// DisablePruning orphans, instead of deleting, the resources of every
+	// SkyfloAI that are no longer needed, whatever its PruningPolicy.
+	DisablePruning bool
+
+	// FieldOwner is the field manager recorded for every write, so other
+	// controllers managing the same objects can tell our fields apart.
+	// Defaults to skyflo-controller.
+	FieldOwner string
+
+	// MaxReplicasPerComponent caps the replicas of every component, whatever
+	// the spec requests. Zero disables the cap.
+	MaxReplicasPerComponent int32
+
+	// Recorder emits the events narrating a SkyfloAI's bring-up. No events
+	// are emitted when nil.
+	Recorder record.EventRecorder
+
+	// APIReader reads objects the controller does not cache, such as the
+	// events gathered into diagnostics bundles. Defaults to the client.
+	APIReader client.Reader
+
+	// KubeconfigChecker, when set, verifies that the API server of the MCP
+	// kubeconfig answers before the MCP is rolled out. The kubeconfig is
+	// only parsed when nil.
+	KubeconfigChecker KubeconfigChecker
+
+	// MCPClusterRBACNamespaces lists the namespaces whose SkyfloAIs may have
+	// the controller generate an MCP ClusterRole and binding, or "*" for
+	// every namespace. Such a SkyfloAI grants cluster-wide permissions to
+	// pods it defines, so it is limited to namespaces the cluster admin
+	// trusts. Empty allows none.
+	MCPClusterRBACNamespaces []string
+
+	// ServerSideApply writes child objects with server-side apply without
+	// forcing ownership: fields other field managers own are left to them
+	// and reported in a FieldManagerConflict condition.
+	ServerSideApply bool
+
+	// ForceApplyFields lists the field paths, such as .spec.replicas, taken
+	// over from other field managers with ServerSideApply. A path covers
+	// the fields nested under it.
+	ForceApplyFields []string
+
+	// backoff pauses every reconcile while the API server throttles
+	// requests.
+	backoff apiBackoff
+
+	// DigestResolver resolves the digests of images whose component sets
+	// TrackTag. Tag tracking is disabled when nil.
+	DigestResolver DigestResolver
+
+	// DigestPollInterval is how often objects with tracked tags are
+	// reconciled to poll for new digests.
+	DigestPollInterval time.Duration
 }
 
 //+kubebuilder:rbac:groups=skyflo.ai,resources=skyfloais,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=skyflo.ai,resources=skyfloais/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=skyflo.ai,resources=skyfloais/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch;delete;escalate;bind
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=limitranges,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=events,verbs=get;list;create;patch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get;list;watch
 
 func (r *SkyfloAIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String("skyfloai.namespace", req.Namespace),
+		attribute.String("skyfloai.name", req.Name),
+	))
+	if wait, held := r.backoff.remaining(r.now()); held {
+		span.SetAttributes(attribute.String("requeue_after", wait.String()))
+		endSpan(span, nil)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	result, err := r.reconcile(ctx, req)
+	if retryAfter, throttled := throttledBy(err); throttled {
+		wait := r.backoff.throttle(r.now(), retryAfter)
+		log.FromContext(ctx).Info("API server is throttling requests; pausing reconciles", "backoff", wait.String())
+		result, err = ctrl.Result{RequeueAfter: wait}, nil
+	} else if err == nil {
+		r.backoff.recover()
+	}
+	span.SetAttributes(attribute.String("requeue_after", result.RequeueAfter.String()))
+	endSpan(span, err)
+	return result, err
+}
+
+func (r *SkyfloAIReconciler) reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := log.FromContext(ctx)
 
 	skyflo := &skyflov1.SkyfloAI{}
-	err := r.Get(ctx, req.NamespacedName, skyflo)
+	err = r.Get(ctx, req.NamespacedName, skyflo)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			forgetComponentMetrics(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileUI(ctx, skyflo); err != nil {
-		log.Error(err, "failed to reconcile UI component")
+	if !skyflo.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, skyflo)
+	}
+	if err := r.ensureCleanupFinalizer(ctx, skyflo); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileEngine(ctx, skyflo); err != nil {
-		log.Error(err, "failed to reconcile Engine component")
-		return ctrl.Result{}, err
+	// Every step records its part of the status on skyflo; the status is
+	// written once, when the loop ends, and only if it changed.
+	observed := skyflo.Status.DeepCopy()
+	defer func() {
+		if statusErr := r.writeStatus(ctx, skyflo, observed); statusErr != nil {
+			log.Error(statusErr, "failed to update SkyfloAI status")
+			err = utilerrors.NewAggregate([]error{err, statusErr})
+			return
+		}
+		r.recordProgress(skyflo, observed)
+	}()
+
+	if result, frozen := r.holdForFreeze(ctx, skyflo); frozen {
+		return result, nil
 	}
 
-	if err := r.reconcileMCP(ctx, skyflo); err != nil {
-		log.Error(err, "failed to reconcile MCP component")
+	if reason, err := checkSchemaVersion(skyflo); err != nil {
+		log.Info("refusing to reconcile SkyfloAI with unsupported schema", "reason", err.Error())
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "UnsupportedSchema",
+			Status:             metav1.ConditionTrue,
+			Reason:             reason,
+			Message:            err.Error(),
+			ObservedGeneration: skyflo.Generation,
+		})
+		return ctrl.Result{}, nil
+	}
+	meta.RemoveStatusCondition(&skyflo.Status.Conditions, "UnsupportedSchema")
+
+	if err := r.migrateToAutoscaling(ctx, skyflo); err != nil {
+		log.Error(err, "failed to migrate components to autoscaling")
 		return ctrl.Result{}, err
 	}
 
+	// A failing step must not keep the others from running, so errors are
+	// collected and returned once status has been computed.
+	var errs []error
+	var failed []string
+	if err := r.reconcileSecurityHeaders(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile UI security headers")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileMCPClusterRBAC(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile the MCP cluster RBAC")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileLimitRange(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile the namespace LimitRange")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileInternalTLS(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile internal TLS certificates")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileEngineStorage(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile Engine storage")
+		errs = append(errs, err)
+	}
+
+	databaseReady, err := r.databaseReady(ctx, skyflo)
+	if err != nil {
+		log.Error(err, "failed to check the Engine database dependency")
+		errs = append(errs, err)
+	}
+
+	kubeconfigReady, err := r.kubeconfigReady(ctx, skyflo)
+	if err != nil {
+		log.Error(err, "failed to check the MCP kubeconfig")
+		errs = append(errs, err)
+	}
+
+	for _, c := range components(skyflo) {
+		if c.name == "engine" && !databaseReady {
+			log.Info("waiting for the database dependency before rolling out the Engine")
+			continue
+		}
+		if c.name == "mcp" && !kubeconfigReady {
+			log.Info("waiting for a usable kubeconfig before rolling out the MCP")
+			continue
+		}
+		if err := r.reconcileComponent(ctx, skyflo, c); err != nil {
+			log.Error(err, "failed to reconcile component", "component", c.displayName)
+			r.recordComponentFailure(skyflo, c, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.displayName, err))
+			failed = append(failed, c.displayName)
+		}
+	}
+	setComponentsReconciledCondition(skyflo, failed)
+	setForeignResourceCondition(skyflo, errs)
+	r.setReplicaCapCondition(skyflo)
+	setTotalConcurrencyCondition(skyflo)
+
+	if err := r.reconcileWarmup(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile the Engine warmup Job")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileUIIngress(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile the UI Ingress")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcilePublishedEndpoints(ctx, skyflo); err != nil {
+		log.Error(err, "failed to publish the component endpoints")
+		errs = append(errs, err)
+	}
+
+	if err := r.pruneComponents(ctx, skyflo); err != nil {
+		log.Error(err, "failed to prune removed components")
+		errs = append(errs, err)
+	}
+
+	if err := r.reconcileMonitoring(ctx, skyflo); err != nil {
+		log.Error(err, "failed to reconcile monitoring resources")
+		errs = append(errs, err)
+	}
+
+	if err := r.checkZoneCoverage(ctx, skyflo); err != nil {
+		log.Error(err, "failed to check zone coverage")
+		errs = append(errs, err)
+	}
+
+	if r.ValidateScheduling {
+		if err := r.checkSchedulable(ctx, skyflo); err != nil {
+			log.Error(err, "failed to check component schedulability")
+			errs = append(errs, err)
+		}
+	}
+
+	setFieldManagerConflictCondition(skyflo, errs)
+
 	if err := r.updateStatus(ctx, skyflo); err != nil {
-		log.Error(err, "failed to update SkyfloAI status")
-		return ctrl.Result{}, err
+		log.Error(err, "failed to compute SkyfloAI status")
+		errs = append(errs, err)
 	}
+	setAvailabilityConditions(skyflo, failed)
 
-	return ctrl.Result{}, nil
+	if err := r.collectDiagnostics(ctx, skyflo); err != nil {
+		log.Error(err, "failed to collect diagnostics")
+		errs = append(errs, err)
+	}
+
+	requeue := r.digestPollRequeue(skyflo)
+	if (!databaseReady || !kubeconfigReady) && (requeue == 0 || requeue > dependencyPollInterval) {
+		requeue = dependencyPollInterval
+	}
+	if internalTLSEnabled(skyflo) && (requeue == 0 || requeue > internalTLSRecheckInterval) {
+		requeue = internalTLSRecheckInterval
+	}
+	return ctrl.Result{RequeueAfter: requeue}, utilerrors.NewAggregate(errs)
+}
+
+// digestPollRequeue returns the poll interval when a component tracks its
+// image tag, and zero otherwise.
+func (r *SkyfloAIReconciler) digestPollRequeue(skyflo *skyflov1.SkyfloAI) time.Duration {
+	if r.DigestResolver == nil {
+		return 0
+	}
+	for _, c := range components(skyflo) {
+		if c.spec.TrackTag {
+			return r.DigestPollInterval
+		}
+	}
+	return 0
 }
 
-func (r *SkyfloAIReconciler) reconcileUI(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
-	uiDeployment := r.uiDeployment(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, uiDeployment, r.Scheme); err != nil {
+// setComponentsReconciledCondition records which components, if any, failed
+// to reconcile.
+func setComponentsReconciledCondition(skyflo *skyflov1.SkyfloAI, failed []string) {
+	if len(failed) == 0 {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "ComponentsReconciled",
+			Status:             metav1.ConditionTrue,
+			Reason:             "ReconcileSucceeded",
+			Message:            "every component was reconciled",
+			ObservedGeneration: skyflo.Generation,
+		})
+		return
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:               "ComponentsReconciled",
+		Status:             metav1.ConditionFalse,
+		Reason:             "ReconcileFailed",
+		Message:            fmt.Sprintf("failed to reconcile: %s", strings.Join(failed, ", ")),
+		ObservedGeneration: skyflo.Generation,
+	})
+}
+
+// checkSchemaVersion rejects objects written against a newer schema revision
+// than this operator understands, or declaring a malformed one, returning the
+// condition reason with the error.
+func checkSchemaVersion(skyflo *skyflov1.SkyfloAI) (string, error) {
+	declared, ok := skyflo.Annotations[schemaVersionAnnotation]
+	if !ok {
+		return "", nil
+	}
+
+	version, err := strconv.Atoi(declared)
+	if err != nil {
+		return "InvalidSchemaVersion", fmt.Errorf("invalid %s annotation %q: %w", schemaVersionAnnotation, declared, err)
+	}
+	if version > skyflov1.SchemaVersion {
+		return "SchemaVersionTooNew", fmt.Errorf("schema version %d is newer than the supported version %d; upgrade the operator", version, skyflov1.SchemaVersion)
+	}
+	return "", nil
+}
+
+func (r *SkyfloAIReconciler) reconcileComponent(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component) (err error) {
+	ctx, span := tracer.Start(ctx, "reconcileComponent", trace.WithAttributes(attribute.String("component", c.name)))
+	defer func() { endSpan(span, err) }()
+
+	deployment := r.deployment(skyflo, c)
+	deployment.Spec.Template.Spec.Containers[0].ResizePolicy = r.resizePolicy(ctx, c)
+	service := r.service(skyflo, c)
+	if err := applyTargetContainer(c, deployment, service); err != nil {
 		return err
 	}
-	if err := r.createOrUpdateDeployment(ctx, uiDeployment); err != nil {
+	if err := r.stampDependentRestarts(ctx, skyflo, c.name, deployment); err != nil {
 		return err
 	}
-
-	uiService := r.uiService(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, uiService, r.Scheme); err != nil {
+	if err := r.stampImageDigest(ctx, c, deployment); err != nil {
+		return err
+	}
+	if err := r.mountInternalTLS(ctx, skyflo, c, deployment); err != nil {
+		return err
+	}
+	if err := r.pauseRollout(ctx, skyflo, deployment); err != nil {
+		return err
+	}
+	if err := r.keepScaledReplicas(ctx, c, deployment); err != nil {
+		return err
+	}
+	sortEnv(&deployment.Spec.Template)
+	if err := stampSpecHash(deployment); err != nil {
+		return err
+	}
+	if err := r.own(skyflo, deployment); err != nil {
+		return err
+	}
+	operation, err := r.createOrUpdateDeployment(ctx, deployment)
+	if err != nil {
 		return err
 	}
-	if err := r.createOrUpdateService(ctx, uiService); err != nil {
+	r.recordDeploymentWrite(skyflo, c, operation)
+	if err := r.reconcileAutoscaler(ctx, skyflo, c); err != nil {
+		return err
+	}
+	if err := r.reconcileDisruptionBudget(ctx, skyflo, c); err != nil {
 		return err
 	}
 
+	migrated, err := r.serviceSelector(ctx, skyflo, c, service)
+	if err != nil {
+		return err
+	}
+	if c.noService {
+		if err := r.deleteIfOwned(ctx, skyflo, &corev1.Service{}, service.Name); err != nil {
+			return err
+		}
+	} else {
+		if err := r.own(skyflo, service); err != nil {
+			return err
+		}
+		if err := r.createOrUpdateService(ctx, skyflo, service, r.trafficDistribution(ctx, c)); err != nil {
+			return err
+		}
+	}
+
+	if migrated {
+		return r.deleteOldDeployment(ctx, skyflo, c)
+	}
 	return nil
 }
 
-func (r *SkyfloAIReconciler) reconcileEngine(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
-	engineDeployment := r.engineDeployment(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, engineDeployment, r.Scheme); err != nil {
-		return err
+// stampDependentRestarts annotates the pod template of a dependent component
+// with the revision of every component it depends on per
+// RestartDependentsOnChange. A new revision is only recorded once the source
+// Deployment has fully rolled out; until then the previously recorded value is
+// kept so the dependent restarts after, not alongside, its source.
+func (r *SkyfloAIReconciler) stampDependentRestarts(ctx context.Context, skyflo *skyflov1.SkyfloAI, component string, deployment *appsv1.Deployment) error {
+	sources := make([]string, 0, len(skyflo.Spec.RestartDependentsOnChange))
+	for source, dependents := range skyflo.Spec.RestartDependentsOnChange {
+		for _, dependent := range dependents {
+			if dependent == component && source != component {
+				sources = append(sources, source)
+				break
+			}
+		}
 	}
-	if err := r.createOrUpdateDeployment(ctx, engineDeployment); err != nil {
-		return err
+	if len(sources) == 0 {
+		return nil
 	}
+	sort.Strings(sources)
 
-	engineService := r.engineService(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, engineService, r.Scheme); err != nil {
+	current := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, current)
+	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
-	if err := r.createOrUpdateService(ctx, engineService); err != nil {
-		return err
+
+	for _, source := range sources {
+		key := restartAnnotationPrefix + source + "-revision"
+		revision := current.Spec.Template.Annotations[key]
+
+		sourceDeployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, source, selectorVersion(skyflo)), Namespace: skyflo.Namespace}, sourceDeployment)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && rolledOut(sourceDeployment) {
+			revision = templateRevision(&sourceDeployment.Spec.Template)
+		}
+
+		if revision == "" {
+			continue
+		}
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		deployment.Spec.Template.Annotations[key] = revision
 	}
 
 	return nil
 }
 
-func (r *SkyfloAIReconciler) reconcileMCP(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
-	mcpDeployment := r.mcpDeployment(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, mcpDeployment, r.Scheme); err != nil {
+// rolledOut reports whether every replica of the Deployment runs its latest
+// pod template and is available.
+func rolledOut(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
+
+// templateRevision hashes the images and environment of a pod template's
+// containers, which together identify a component's rollout.
+func templateRevision(template *corev1.PodTemplateSpec) string {
+	hash := sha256.New()
+	for _, container := range template.Spec.Containers {
+		fmt.Fprintf(hash, "%s=%s\n", container.Name, container.Image)
+		for _, env := range container.Env {
+			fmt.Fprintf(hash, "%s=%s\n", env.Name, env.Value)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// updateStatus records the observed component states, endpoints and applied
+// spec hash on skyflo. The status is written by writeStatus.
+func (r *SkyfloAIReconciler) updateStatus(ctx context.Context, skyflo *skyflov1.SkyfloAI) (err error) {
+	ctx, span := tracer.Start(ctx, "updateStatus")
+	defer func() { endSpan(span, err) }()
+
+	var customStatuses []skyflov1.NamedComponentStatus
+	for _, c := range components(skyflo) {
+		var previous skyflov1.ComponentStatus
+		if c.custom {
+			previous = previousCustomStatus(skyflo, c.name)
+		} else {
+			previous = *statusFor(skyflo, c.name)
+		}
+
+		status, found, err := r.componentStatus(ctx, skyflo, c, previous)
+		if err != nil {
+			return err
+		}
+
+		if c.custom {
+			if !found {
+				status = previous
+			}
+			customStatuses = append(customStatuses, skyflov1.NamedComponentStatus{Name: c.name, ComponentStatus: status})
+			continue
+		}
+		if found {
+			*statusFor(skyflo, c.name) = status
+		}
+	}
+	skyflo.Status.ComponentStatuses = customStatuses
+	if err := r.gateOnWarmup(ctx, skyflo); err != nil {
 		return err
 	}
-	if err := r.createOrUpdateDeployment(ctx, mcpDeployment); err != nil {
+	recordComponentMetrics(skyflo)
+
+	if err := r.setRolloutPausedCondition(ctx, skyflo); err != nil {
 		return err
 	}
 
-	mcpService := r.mcpService(skyflo)
-	if err := controllerutil.SetControllerReference(skyflo, mcpService, r.Scheme); err != nil {
+	endpoints, err := r.accessEndpoints(ctx, skyflo)
+	if err != nil {
 		return err
 	}
-	if err := r.createOrUpdateService(ctx, mcpService); err != nil {
+	skyflo.Status.AccessEndpoints = endpoints
+
+	hash, err := r.appliedSpecHash(ctx, skyflo)
+	if err != nil {
 		return err
 	}
+	skyflo.Status.AppliedSpecHash = hash
+	return nil
+}
+
+// writeStatus issues the single status write of a reconcile, skipping it when
+// nothing changed since the status was read.
+func (r *SkyfloAIReconciler) writeStatus(ctx context.Context, skyflo *skyflov1.SkyfloAI, observed *skyflov1.SkyfloAIStatus) error {
+	if equality.Semantic.DeepEqual(observed, &skyflo.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, skyflo, r.fieldOwner())
+}
 
+// stampSpecHash records the hash of the resolved Deployment spec on it. Map
+// keys are encoded in sorted order, so the hash is stable across reconciles.
+func stampSpecHash(deployment *appsv1.Deployment) error {
+	data, err := json.Marshal(deployment.Spec)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[specHashAnnotation] = hex.EncodeToString(sum[:])[:16]
 	return nil
 }
 
-func (r *SkyfloAIReconciler) updateStatus(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
-	uiDeployment := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: skyflo.Name + "-ui", Namespace: skyflo.Namespace}, uiDeployment)
-	if err == nil {
-		skyflo.Status.UIStatus = skyflov1.ComponentStatus{
-			Phase:           getPhase(uiDeployment),
-			ReadyReplicas:   uiDeployment.Status.ReadyReplicas,
-			DesiredReplicas: *uiDeployment.Spec.Replicas,
+// appliedSpecHash combines the spec hashes of the component Deployments, in
+// component order, into the hash of the spec last applied. Components whose
+// Deployment does not exist yet are skipped.
+func (r *SkyfloAIReconciler) appliedSpecHash(ctx context.Context, skyflo *skyflov1.SkyfloAI) (string, error) {
+	hash := sha256.New()
+	for _, c := range components(skyflo) {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, selectorVersion(skyflo)), Namespace: skyflo.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
 		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s=%s\n", c.name, deployment.Annotations[specHashAnnotation])
 	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
 
-	engineDeployment := &appsv1.Deployment{}
-	err = r.Get(ctx, types.NamespacedName{Name: skyflo.Name + "-engine", Namespace: skyflo.Namespace}, engineDeployment)
-	if err == nil {
-		skyflo.Status.EngineStatus = skyflov1.ComponentStatus{
-			Phase:           getPhase(engineDeployment),
-			ReadyReplicas:   engineDeployment.Status.ReadyReplicas,
-			DesiredReplicas: *engineDeployment.Spec.Replicas,
+// componentStatus observes the component Deployment, carrying the rollout
+// record over from the previous status. found is false when the Deployment
+// does not exist yet.
+func (r *SkyfloAIReconciler) componentStatus(ctx context.Context, skyflo *skyflov1.SkyfloAI, c component, previous skyflov1.ComponentStatus) (skyflov1.ComponentStatus, bool, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, c.name, selectorVersion(skyflo)), Namespace: skyflo.Namespace}, deployment)
+	if err != nil {
+		return skyflov1.ComponentStatus{}, false, client.IgnoreNotFound(err)
+	}
+
+	status := skyflov1.ComponentStatus{
+		Phase:           getPhase(deployment),
+		ReadyReplicas:   deployment.Status.ReadyReplicas,
+		DesiredReplicas: *deployment.Spec.Replicas,
+	}
+	recordRollout(&status, previous, deployment)
+	nodes, err := r.componentNodes(ctx, deployment)
+	if err != nil {
+		return skyflov1.ComponentStatus{}, false, err
+	}
+	status.Nodes = nodes
+	return status, true, nil
+}
+
+// recordRollout records the image of a completed rollout and when it
+// completed. Until the Deployment has fully rolled out, the previously
+// recorded image and time are kept.
+func recordRollout(status *skyflov1.ComponentStatus, previous skyflov1.ComponentStatus, deployment *appsv1.Deployment) {
+	status.RolledOutImage = previous.RolledOutImage
+	status.LastRolloutTime = previous.LastRolloutTime
+	if !rolledOut(deployment) {
+		return
+	}
+
+	image := deployment.Spec.Template.Spec.Containers[0].Image
+	if digest := deployment.Spec.Template.Annotations[imageDigestAnnotation]; digest != "" {
+		image += "@" + digest
+	}
+	if image != status.RolledOutImage {
+		now := metav1.Now()
+		status.RolledOutImage = image
+		status.LastRolloutTime = &now
+	}
+}
+
+// previousCustomStatus returns the last reported status of an additional
+// component.
+func previousCustomStatus(skyflo *skyflov1.SkyfloAI, name string) skyflov1.ComponentStatus {
+	for _, status := range skyflo.Status.ComponentStatuses {
+		if status.Name == name {
+			return status.ComponentStatus
 		}
 	}
+	return skyflov1.ComponentStatus{}
+}
+
+// componentNodes returns the sorted, deduplicated names of the nodes running
+// the Deployment's pods. Pods that are not scheduled yet are skipped.
+func (r *SkyfloAIReconciler) componentNodes(ctx context.Context, deployment *appsv1.Deployment) ([]string, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(deployment.Namespace),
+		client.MatchingLabels(deployment.Spec.Selector.MatchLabels),
+	); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var nodes []string
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || seen[pod.Spec.NodeName] {
+			continue
+		}
+		seen[pod.Spec.NodeName] = true
+		nodes = append(nodes, pod.Spec.NodeName)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
 
-	mcpDeployment := &appsv1.Deployment{}
-	err = r.Get(ctx, types.NamespacedName{Name: skyflo.Name + "-mcp", Namespace: skyflo.Namespace}, mcpDeployment)
+// accessEndpoints resolves the externally reachable UI URLs. Ingress addresses
+// take precedence over LoadBalancer Service addresses; addresses that are
+// still pending allocation are skipped.
+func (r *SkyfloAIReconciler) accessEndpoints(ctx context.Context, skyflo *skyflov1.SkyfloAI) ([]string, error) {
+	key := types.NamespacedName{Name: skyflo.Name + "-ui", Namespace: skyflo.Namespace}
+
+	ingress := &networkingv1.Ingress{}
+	err := r.Get(ctx, key, ingress)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
 	if err == nil {
-		skyflo.Status.MCPStatus = skyflov1.ComponentStatus{
-			Phase:           getPhase(mcpDeployment),
-			ReadyReplicas:   mcpDeployment.Status.ReadyReplicas,
-			DesiredReplicas: *mcpDeployment.Spec.Replicas,
+		scheme := "http"
+		if len(ingress.Spec.TLS) > 0 {
+			scheme = "https"
+		}
+
+		var endpoints []string
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" && len(ingress.Status.LoadBalancer.Ingress) > 0 {
+				endpoints = append(endpoints, scheme+"://"+rule.Host)
+			}
 		}
+		if len(endpoints) == 0 {
+			for _, lb := range ingress.Status.LoadBalancer.Ingress {
+				if address := lbAddress(lb.Hostname, lb.IP); address != "" {
+					endpoints = append(endpoints, scheme+"://"+address)
+				}
+			}
+		}
+		return endpoints, nil
 	}
 
-	return r.Status().Update(ctx, skyflo)
+	service := &corev1.Service{}
+	if err := r.Get(ctx, key, service); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return nil, nil
+	}
+
+	var endpoints []string
+	for _, lb := range service.Status.LoadBalancer.Ingress {
+		address := lbAddress(lb.Hostname, lb.IP)
+		if address == "" {
+			continue
+		}
+		for _, port := range service.Spec.Ports {
+			switch port.Port {
+			case 80:
+				endpoints = append(endpoints, "http://"+address)
+			case 443:
+				endpoints = append(endpoints, "https://"+address)
+			default:
+				endpoints = append(endpoints, "http://"+net.JoinHostPort(address, strconv.Itoa(int(port.Port))))
+			}
+		}
+	}
+	return endpoints, nil
 }
 
-func (r *SkyfloAIReconciler) uiDeployment(skyflo *skyflov1.SkyfloAI) *appsv1.Deployment {
-	replicas := int32(1)
-	if skyflo.Spec.UI.Replicas != nil {
-		replicas = *skyflo.Spec.UI.Replicas
+// lbAddress prefers the hostname of a load balancer ingress point over its IP.
+func lbAddress(hostname, ip string) string {
+	if hostname != "" {
+		return hostname
 	}
+	return ip
+}
+
+func (r *SkyfloAIReconciler) deployment(skyflo *skyflov1.SkyfloAI, c component) *appsv1.Deployment {
+	replicas, _ := r.replicas(c)
 
-	return &appsv1.Deployment{
+	version := selectorVersion(skyflo)
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-ui",
+			Name:      deploymentName(skyflo, c.name, version),
 			Namespace: skyflo.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
+			Paused:   c.spec.Paused,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": skyflo.Name + "-ui",
-				},
+				MatchLabels: podLabels(skyflo, c.name, version),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": skyflo.Name + "-ui",
-					},
+					Labels:      podTemplateLabels(skyflo, c.name, version),
+					Annotations: mergeMaps(skyflo.Spec.CommonAnnotations, nil),
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  "ui",
-							Image: skyflo.Spec.UI.Image,
+							Name:            c.name,
+							Image:           c.spec.Image,
+							ImagePullPolicy: c.spec.ImagePullPolicy,
 							Ports: []corev1.ContainerPort{
 								{
-									ContainerPort: 3000,
+									ContainerPort: c.port,
 									Name:          "http",
 								},
 							},
-							Resources: skyflo.Spec.UI.Resources,
-							Env:       skyflo.Spec.UI.Env,
+							Resources:      resourcesFor(c.spec.Resources, c.spec.Size),
+							LivenessProbe:  c.spec.LivenessProbe,
+							ReadinessProbe: c.spec.ReadinessProbe,
+							Env:            c.componentEnv(replicas),
 						},
 					},
-					ImagePullSecrets: skyflo.Spec.ImagePullSecrets,
-					NodeSelector:     skyflo.Spec.NodeSelector,
-					Tolerations:      skyflo.Spec.Tolerations,
-					Affinity:         skyflo.Spec.Affinity,
+					ImagePullSecrets:   skyflo.Spec.ImagePullSecrets,
+					NodeSelector:       skyflo.Spec.NodeSelector,
+					Tolerations:        skyflo.Spec.Tolerations,
+					Affinity:           skyflo.Spec.Affinity,
+					SchedulerName:      c.spec.SchedulerName,
+					PriorityClassName:  c.spec.PriorityClassName,
+					PreemptionPolicy:   c.spec.PreemptionPolicy,
+					EnableServiceLinks: boolOrDefault(c.spec.EnableServiceLinks, false),
 				},
 			},
 		},
 	}
+	if dns := c.spec.CustomDNS; dns != nil {
+		deployment.Spec.Template.Spec.DNSPolicy = corev1.DNSNone
+		deployment.Spec.Template.Spec.DNSConfig = &corev1.PodDNSConfig{
+			Nameservers: dns.Nameservers,
+			Searches:    dns.Searches,
+			Options:     dns.Options,
+		}
+	}
+	deployment.Spec.MinReadySeconds = c.spec.MinReadySeconds
+	if spread := c.spec.ZoneSpread; spread != nil {
+		addZoneSpread(spread, podLabels(skyflo, c.name, version), deployment)
+	}
+	if c.spec.Strategy != nil {
+		deployment.Spec.Strategy = *c.spec.Strategy.DeepCopy()
+	} else if c.spec.MaxSurge != nil || c.spec.MaxUnavailable != nil {
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       c.spec.MaxSurge,
+				MaxUnavailable: c.spec.MaxUnavailable,
+			},
+		}
+	}
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	if c.noService {
+		container.Ports = nil
+	}
+	container.Ports = append(container.Ports, c.spec.ExtraPorts...)
+	addBuildInfoLabels(c.spec.BuildInfo, deployment)
+	if c.decorate != nil {
+		c.decorate(deployment)
+	}
+	if c.spec.Spot {
+		addSpotTolerations(deployment)
+	}
+	if skyflo.Spec.Vault != nil {
+		addVaultAgent(skyflo.Spec.Vault, deployment)
+	}
+	addCommonMetadata(skyflo, deployment, c.name)
+	return deployment
 }
 
-func (r *SkyfloAIReconciler) uiService(skyflo *skyflov1.SkyfloAI) *corev1.Service {
-	return &corev1.Service{
+func (r *SkyfloAIReconciler) service(skyflo *skyflov1.SkyfloAI, c component) *corev1.Service {
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-ui",
-			Namespace: skyflo.Namespace,
+			Name:        skyflo.Name + "-" + c.name,
+			Namespace:   skyflo.Namespace,
+			Annotations: mergeMaps(c.serviceAnnotations, c.spec.ServiceAnnotations),
+			Labels:      mergeMaps(nil, c.spec.ServiceLabels),
 		},
 		Spec: corev1.ServiceSpec{
+			Type:           c.spec.ServiceType,
+			IPFamilyPolicy: c.spec.IPFamilyPolicy,
+			IPFamilies:     c.spec.IPFamilies,
 			Ports: []corev1.ServicePort{
 				{
 					Port:       80,
-					TargetPort: intstr.FromInt(3000),
+					TargetPort: intstr.FromInt(int(c.serviceTargetPort())),
 					Name:       "http",
 				},
 			},
-			Selector: map[string]string{
-				"app": skyflo.Name + "-ui",
-			},
+			Selector: podLabels(skyflo, c.name, selectorVersion(skyflo)),
 		},
 	}
+	addCommonMetadata(skyflo, service, c.name)
+	for _, port := range c.spec.ExtraPorts {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       port.Name,
+			Protocol:   port.Protocol,
+			Port:       port.ContainerPort,
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+		})
+	}
+	if c.decorateService != nil {
+		c.decorateService(service)
+	}
+	if exposed(service) {
+		service.Spec.Ports[0].NodePort = c.spec.NodePort
+	} else {
+		clearExposure(service)
+	}
+	return service
+}
+
+// exposed reports whether the Service is reachable from outside the cluster
+// through node ports.
+func exposed(service *corev1.Service) bool {
+	return service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer
+}
+
+// clearExposure drops the fields only NodePort and LoadBalancer Services may
+// set, so switching a Service back to ClusterIP passes validation.
+func clearExposure(service *corev1.Service) {
+	for i := range service.Spec.Ports {
+		service.Spec.Ports[i].NodePort = 0
+	}
+	service.Spec.ExternalTrafficPolicy = ""
+	service.Spec.HealthCheckNodePort = 0
+	service.Spec.AllocateLoadBalancerNodePorts = nil
+	service.Spec.LoadBalancerClass = nil
+	service.Spec.LoadBalancerSourceRanges = nil
 }
 
-func (r *SkyfloAIReconciler) engineDeployment(skyflo *skyflov1.SkyfloAI) *appsv1.Deployment {
+// defaultFieldOwner is the field manager used when none is configured.
+const defaultFieldOwner = "skyflo-controller"
+
+// fieldOwner returns the write option recording the controller's field
+// manager.
+func (r *SkyfloAIReconciler) fieldOwner() client.FieldOwner {
+	if r.FieldOwner == "" {
+		return defaultFieldOwner
+	}
+	return client.FieldOwner(r.FieldOwner)
+}
+
+// replicas returns the effective replica count of a component and whether it
+// was clamped to MaxReplicasPerComponent.
+func (r *SkyfloAIReconciler) replicas(c component) (int32, bool) {
 	replicas := int32(1)
-	if skyflo.Spec.Engine.Replicas != nil {
-		replicas = *skyflo.Spec.Engine.Replicas
+	if c.spec.Replicas != nil {
+		replicas = *c.spec.Replicas
 	}
+	return r.capReplicas(replicas)
+}
 
-	return &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-engine",
-			Namespace: skyflo.Namespace,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": skyflo.Name + "-engine",
-				},
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": skyflo.Name + "-engine",
-					},
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  "engine",
-							Image: skyflo.Spec.Engine.Image,
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 8081,
-									Name:          "http",
-								},
-							},
-							Resources: skyflo.Spec.Engine.Resources,
-							Env:       skyflo.Spec.Engine.Env,
-						},
-					},
-					ImagePullSecrets: skyflo.Spec.ImagePullSecrets,
-					NodeSelector:     skyflo.Spec.NodeSelector,
-					Tolerations:      skyflo.Spec.Tolerations,
-					Affinity:         skyflo.Spec.Affinity,
-				},
-			},
-		},
+// capReplicas clamps a replica count to MaxReplicasPerComponent and reports
+// whether it was clamped.
+func (r *SkyfloAIReconciler) capReplicas(replicas int32) (int32, bool) {
+	if r.MaxReplicasPerComponent > 0 && replicas > r.MaxReplicasPerComponent {
+		return r.MaxReplicasPerComponent, true
 	}
+	return replicas, false
 }
 
-func (r *SkyfloAIReconciler) engineService(skyflo *skyflov1.SkyfloAI) *corev1.Service {
-	return &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-engine",
-			Namespace: skyflo.Namespace,
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Port:       80,
-					TargetPort: intstr.FromInt(8081),
-					Name:       "http",
-				},
-			},
-			Selector: map[string]string{
-				"app": skyflo.Name + "-engine",
-			},
-		},
+// setReplicaCapCondition records which components had their replicas, or
+// their autoscaler's replica bounds, clamped to the cluster-wide cap.
+func (r *SkyfloAIReconciler) setReplicaCapCondition(skyflo *skyflov1.SkyfloAI) {
+	if r.MaxReplicasPerComponent <= 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "ReplicaCapApplied")
+		return
+	}
+
+	var capped []string
+	for _, c := range components(skyflo) {
+		clamped := false
+		if spec := autoscaling(c); spec != nil {
+			_, clamped = r.maxReplicas(spec)
+		} else {
+			_, clamped = r.replicas(c)
+		}
+		if clamped {
+			capped = append(capped, c.displayName)
+		}
 	}
+
+	if len(capped) == 0 {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "ReplicaCapApplied",
+			Status:             metav1.ConditionFalse,
+			Reason:             "WithinCap",
+			Message:            fmt.Sprintf("every component requests at most %d replicas", r.MaxReplicasPerComponent),
+			ObservedGeneration: skyflo.Generation,
+		})
+		return
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "ReplicaCapApplied",
+		Status: metav1.ConditionTrue,
+		Reason: "ReplicasClamped",
+		Message: fmt.Sprintf("replicas clamped to the cluster cap of %d for: %s",
+			r.MaxReplicasPerComponent, strings.Join(capped, ", ")),
+		ObservedGeneration: skyflo.Generation,
+	})
 }
 
-func (r *SkyfloAIReconciler) mcpDeployment(skyflo *skyflov1.SkyfloAI) *appsv1.Deployment {
-	replicas := int32(1)
-	if skyflo.Spec.MCP.Replicas != nil {
-		replicas = *skyflo.Spec.MCP.Replicas
+// externalDNSAnnotations renders the external-dns annotations for the UI's
+// published hostname.
+func externalDNSAnnotations(ui skyflov1.UISpec) map[string]string {
+	if ui.DNSName == "" {
+		return nil
 	}
 
-	return &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-mcp",
-			Namespace: skyflo.Namespace,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": skyflo.Name + "-mcp",
-				},
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": skyflo.Name + "-mcp",
-					},
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  "mcp",
-							Image: skyflo.Spec.MCP.Image,
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 8000,
-									Name:          "http",
-								},
-							},
-							Resources: skyflo.Spec.MCP.Resources,
-							Env:       skyflo.Spec.MCP.Env,
-						},
-					},
-					ImagePullSecrets: skyflo.Spec.ImagePullSecrets,
-					NodeSelector:     skyflo.Spec.NodeSelector,
-					Tolerations:      skyflo.Spec.Tolerations,
-					Affinity:         skyflo.Spec.Affinity,
-				},
-			},
-		},
+	annotations := map[string]string{
+		"external-dns.alpha.kubernetes.io/hostname": ui.DNSName,
+	}
+	if ui.DNSTTL != nil {
+		annotations["external-dns.alpha.kubernetes.io/ttl"] = strconv.Itoa(int(*ui.DNSTTL))
 	}
+	return annotations
 }
 
-func (r *SkyfloAIReconciler) mcpService(skyflo *skyflov1.SkyfloAI) *corev1.Service {
-	return &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      skyflo.Name + "-mcp",
-			Namespace: skyflo.Namespace,
-		},
-		Spec: corev1.ServiceSpec{
-			Ports: []corev1.ServicePort{
-				{
-					Port:       80,
-					TargetPort: intstr.FromInt(8000),
-					Name:       "http",
-				},
-			},
-			Selector: map[string]string{
-				"app": skyflo.Name + "-mcp",
+// engineEnv returns the environment variables the controller derives from
+// the Engine spec. replicas is the replica target the pods are sized for.
+func engineEnv(skyflo *skyflov1.SkyfloAI, replicas int32) []corev1.EnvVar {
+	var env []corev1.EnvVar
+
+	if total := skyflo.Spec.Engine.TotalConcurrency; total != nil && !totalConcurrencyIgnored(skyflo) {
+		env = append(env, corev1.EnvVar{
+			Name:  "WORKER_CONCURRENCY",
+			Value: strconv.Itoa(int(workerConcurrency(*total, replicas))),
+		})
+	}
+
+	if db := skyflo.Spec.Engine.DatabaseConfig; db != nil {
+		env = append(env, databaseEnv(db)...)
+	}
+
+	if db := skyflo.Spec.Engine.DatabaseConfig; db != nil && db.ConnectionPool != nil {
+		pool := db.ConnectionPool
+		if pool.MaxConnections != nil {
+			env = append(env, corev1.EnvVar{Name: "DB_POOL_MAX", Value: strconv.Itoa(int(*pool.MaxConnections))})
+		}
+		if pool.MinConnections != nil {
+			env = append(env, corev1.EnvVar{Name: "DB_POOL_MIN", Value: strconv.Itoa(int(*pool.MinConnections))})
+		}
+		if pool.MaxIdleTime != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "DB_POOL_MAX_IDLE_TIME",
+				Value: strconv.Itoa(int(pool.MaxIdleTime.Seconds())),
+			})
+		}
+	}
+
+	if queues := skyflo.Spec.Engine.Queues; len(queues) > 0 {
+		env = append(env, corev1.EnvVar{Name: "QUEUES", Value: strings.Join(queues, ",")})
+	}
+
+	if deadlock := skyflo.Spec.Engine.DeadlockDetection; deadlock != nil {
+		env = append(env, corev1.EnvVar{Name: "DEADLOCK_SENTINEL_FILE", Value: sentinelFile(deadlock)})
+	}
+	if signal := skyflo.Spec.Engine.StopSignal; signal != "" {
+		env = append(env, corev1.EnvVar{Name: "STOP_SIGNAL", Value: signal})
+	}
+	if timeout := skyflo.Spec.Engine.ShutdownTimeout; timeout != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "SHUTDOWN_TIMEOUT",
+			Value: strconv.Itoa(int(timeout.Seconds())),
+		})
+	}
+
+	return env
+}
+
+// shutdownGracePadding is how much longer than the Engine shutdown timeout
+// its pods are given before they are killed.
+const shutdownGracePadding = 10
+
+// engineTerminationGracePeriod returns the Engine pod termination grace
+// period: the explicit value, or the shutdown timeout plus padding.
+func engineTerminationGracePeriod(engine skyflov1.EngineSpec) *int64 {
+	if engine.TerminationGracePeriodSeconds != nil {
+		return engine.TerminationGracePeriodSeconds
+	}
+	if engine.ShutdownTimeout == nil {
+		return nil
+	}
+	seconds := int64(math.Ceil(engine.ShutdownTimeout.Seconds())) + shutdownGracePadding
+	return &seconds
+}
+
+// databaseEnv renders the Engine database connection settings. The
+// credentials are read from the username and password keys of the
+// database Secret.
+func databaseEnv(db *skyflov1.DatabaseConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if db.Host != "" {
+		env = append(env, corev1.EnvVar{Name: "DB_HOST", Value: db.Host})
+	}
+	if db.Port != 0 {
+		env = append(env, corev1.EnvVar{Name: "DB_PORT", Value: strconv.Itoa(int(db.Port))})
+	}
+	if db.Database != "" {
+		env = append(env, corev1.EnvVar{Name: "DB_NAME", Value: db.Database})
+	}
+	if db.SecretName != "" {
+		env = append(env,
+			secretEnv("DB_USER", db.SecretName, "username"),
+			secretEnv("DB_PASSWORD", db.SecretName, "password"))
+	}
+	return env
+}
+
+func secretEnv(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  key,
 			},
 		},
 	}
 }
 
-func (r *SkyfloAIReconciler) createOrUpdateDeployment(ctx context.Context, deployment *appsv1.Deployment) error {
+// totalConcurrencyIgnored reports whether the Engine sets totalConcurrency
+// while autoscaling is enabled. No fixed per-pod share keeps the total at the
+// autoscaler's changing replica count, and following that count would
+// restart every Engine pod on each scaling step, so WORKER_CONCURRENCY is
+// left unset instead.
+func totalConcurrencyIgnored(skyflo *skyflov1.SkyfloAI) bool {
+	return skyflo.Spec.Engine.TotalConcurrency != nil &&
+		autoscaling(component{spec: &skyflo.Spec.Engine.ComponentSpec}) != nil
+}
+
+// setTotalConcurrencyCondition reports a totalConcurrency that is not applied
+// because the Engine autoscales. The webhook rejects the combination, but it
+// is optional and an object admitted earlier may still carry it.
+func setTotalConcurrencyCondition(skyflo *skyflov1.SkyfloAI) {
+	if !totalConcurrencyIgnored(skyflo) {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "TotalConcurrencyIgnored")
+		return
+	}
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "TotalConcurrencyIgnored",
+		Status: metav1.ConditionTrue,
+		Reason: "AutoscalingEnabled",
+		Message: "spec.engine.totalConcurrency is not applied while spec.engine.autoscaling is enabled; " +
+			"WORKER_CONCURRENCY is left unset",
+		ObservedGeneration: skyflo.Generation,
+	})
+}
+
+// workerConcurrency splits a total concurrency target evenly across replicas,
+// rounding up so the stack never runs below the requested total.
+func workerConcurrency(total, replicas int32) int32 {
+	if replicas < 1 {
+		replicas = 1
+	}
+	perPod := (total + replicas - 1) / replicas
+	if perPod < 1 {
+		perPod = 1
+	}
+	return perPod
+}
+
+// createOrUpdateDeployment writes the Deployment unless the live one is
+// already up to date, so unchanged reconciles issue no writes. It reports
+// which write, if any, was issued.
+func (r *SkyfloAIReconciler) createOrUpdateDeployment(ctx context.Context, deployment *appsv1.Deployment) (controllerutil.OperationResult, error) {
+	if err := stampDesiredHash(deployment); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
 	found := &appsv1.Deployment{}
 	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return r.Create(ctx, deployment)
+			return controllerutil.OperationResultCreated, r.create(ctx, deployment)
+		}
+		return controllerutil.OperationResultNone, err
+	}
+	if err := checkAdoptable(found, deployment, "Deployment"); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if deploymentUpToDate(deployment, found) {
+		return controllerutil.OperationResultNone, nil
+	}
+	if r.ServerSideApply && deployment.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType && found.Spec.Strategy.RollingUpdate != nil {
+		// An apply cannot clear the rolling update bounds the API server
+		// defaulted, which the Recreate strategy rejects.
+		patch := client.RawPatch(types.MergePatchType, []byte(`{"spec":{"strategy":{"type":"Recreate","rollingUpdate":null}}}`))
+		if err := r.Patch(ctx, found, patch, r.fieldOwner()); err != nil {
+			return controllerutil.OperationResultNone, err
 		}
-		return err
 	}
 
 	deployment.ResourceVersion = found.ResourceVersion
-	return r.Update(ctx, deployment)
+	return controllerutil.OperationResultUpdated, r.update(ctx, deployment)
 }
 
-func (r *SkyfloAIReconciler) createOrUpdateService(ctx context.Context, service *corev1.Service) error {
+// createOrUpdateService writes the Service, adding trafficDistribution when
+// set. The field is newer than the client's Service type, so such Services
+// are written as unstructured objects.
+func (r *SkyfloAIReconciler) createOrUpdateService(ctx context.Context, skyflo *skyflov1.SkyfloAI, service *corev1.Service, trafficDistribution *string) error {
+	stampManagedMetadata(service)
 	found := &corev1.Service{}
 	err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return r.Create(ctx, service)
+			obj, err := serviceObject(service, trafficDistribution)
+			if err != nil {
+				return err
+			}
+			return r.create(ctx, obj)
 		}
 		return err
 	}
+	if err := checkAdoptable(found, service, "Service"); err != nil {
+		return err
+	}
 
+	keepForeignMetadata(service, found)
+	desired := service.DeepCopy()
 	service.ResourceVersion = found.ResourceVersion
 	service.Spec.ClusterIP = found.Spec.ClusterIP
-	return r.Update(ctx, service)
+	service.Spec.ClusterIPs = found.Spec.ClusterIPs
+	preserveNodePorts(service, found)
+	if service.Spec.IPFamilyPolicy == nil {
+		service.Spec.IPFamilyPolicy = found.Spec.IPFamilyPolicy
+	}
+	if len(service.Spec.IPFamilies) == 0 {
+		service.Spec.IPFamilies = found.Spec.IPFamilies
+	}
+	// Downgrading to single-stack releases the secondary family; the primary
+	// cluster IP and family are immutable and must be kept.
+	if policy := service.Spec.IPFamilyPolicy; policy != nil && *policy == corev1.IPFamilyPolicySingleStack {
+		if len(service.Spec.ClusterIPs) > 1 {
+			service.Spec.ClusterIPs = service.Spec.ClusterIPs[:1]
+		}
+		if len(service.Spec.IPFamilies) > 1 {
+			service.Spec.IPFamilies = service.Spec.IPFamilies[:1]
+		}
+	}
+
+	obj, err := serviceObject(service, trafficDistribution)
+	if err != nil {
+		return err
+	}
+	if err := r.update(ctx, obj); err != nil {
+		if isImmutableFieldError(err) {
+			return r.recreateService(ctx, skyflo, desired, found, trafficDistribution, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// preserveNodePorts keeps the node ports the cluster allocated to an exposed
+// Service, so updates never move the ports firewall rules point at.
+func preserveNodePorts(service, found *corev1.Service) {
+	if !exposed(service) {
+		return
+	}
+	for i := range service.Spec.Ports {
+		port := &service.Spec.Ports[i]
+		if port.NodePort != 0 {
+			continue
+		}
+		for _, existing := range found.Spec.Ports {
+			if existing.Name == port.Name {
+				port.NodePort = existing.NodePort
+			}
+		}
+	}
+	if service.Spec.HealthCheckNodePort == 0 && service.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+		service.Spec.HealthCheckNodePort = found.Spec.HealthCheckNodePort
+	}
+}
+
+// serviceObject returns the Service as is, or as an unstructured object
+// carrying spec.trafficDistribution when one is set.
+func serviceObject(service *corev1.Service, trafficDistribution *string) (client.Object, error) {
+	if trafficDistribution == nil {
+		return service, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(service)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+	if err := unstructured.SetNestedField(obj.Object, *trafficDistribution, "spec", "trafficDistribution"); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// trafficDistribution returns the component's Service traffic distribution,
+// or nil when it is unset or the cluster predates the field.
+func (r *SkyfloAIReconciler) trafficDistribution(ctx context.Context, c component) *string {
+	if c.spec.TrafficDistribution == nil {
+		return nil
+	}
+	if r.ServerVersion != nil {
+		serverVersion, err := utilversion.ParseGeneric(r.ServerVersion.GitVersion)
+		if err == nil && !serverVersion.AtLeast(trafficDistributionMinVersion) {
+			log.FromContext(ctx).Info("trafficDistribution requires Kubernetes 1.31 or newer; ignoring",
+				"component", c.displayName, "serverVersion", r.ServerVersion.GitVersion)
+			return nil
+		}
+	}
+	return c.spec.TrafficDistribution
+}
+
+// trafficDistributionMinVersion is the first release serving
+// spec.trafficDistribution on Services by default.
+var trafficDistributionMinVersion = utilversion.MajorMinor(1, 31)
+
+// componentSizes maps each t-shirt size to its container resources.
+var componentSizes = map[skyflov1.ComponentSize]corev1.ResourceRequirements{
+	skyflov1.ComponentSizeSmall: {
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	},
+	skyflov1.ComponentSizeMedium: {
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	},
+	skyflov1.ComponentSizeLarge: {
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	},
+}
+
+// resourcesFor returns the explicit resources when any are set, otherwise the
+// resources of the given size.
+func resourcesFor(explicit corev1.ResourceRequirements, size skyflov1.ComponentSize) corev1.ResourceRequirements {
+	if len(explicit.Requests) > 0 || len(explicit.Limits) > 0 || len(explicit.Claims) > 0 {
+		return explicit
+	}
+	if sized, ok := componentSizes[size]; ok {
+		return *sized.DeepCopy()
+	}
+	return explicit
+}
+
+// boolOrDefault returns a pointer to the value of b, or to def when b is nil.
+func boolOrDefault(b *bool, def bool) *bool {
+	if b != nil {
+		def = *b
+	}
+	return &def
+}
+
+// mergeMaps returns a copy of generated overlaid with user, or nil when both
+// are empty.
+func mergeMaps(generated, user map[string]string) map[string]string {
+	if len(generated) == 0 && len(user) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(generated)+len(user))
+	for key, value := range generated {
+		merged[key] = value
+	}
+	for key, value := range user {
+		merged[key] = value
+	}
+	return merged
+}
+
+// mergeEnv appends user-provided env vars to the generated ones. A user entry
+// with the same name as a generated entry replaces it in place.
+func mergeEnv(generated, user []corev1.EnvVar) []corev1.EnvVar {
+	if len(generated) == 0 {
+		return user
+	}
+
+	merged := make([]corev1.EnvVar, 0, len(generated)+len(user))
+	index := make(map[string]int, len(generated))
+	for _, env := range generated {
+		index[env.Name] = len(merged)
+		merged = append(merged, env)
+	}
+	for _, env := range user {
+		if i, ok := index[env.Name]; ok {
+			merged[i] = env
+			continue
+		}
+		merged = append(merged, env)
+	}
+	return merged
 }
 
 func getPhase(deployment *appsv1.Deployment) string {
@@ -411,9 +1454,23 @@ func getPhase(deployment *appsv1.Deployment) string {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SkyfloAIReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&skyflov1.SkyfloAI{}).
+	b := ctrl.NewControllerManagedBy(mgr)
+	if r.ReconcileDebounce > 0 {
+		b = b.For(&skyflov1.SkyfloAI{}, builder.WithPredicates(ignoreUpdates)).
+			Watches(&skyflov1.SkyfloAI{}, r.debouncedUpdates())
+	} else {
+		b = b.For(&skyflov1.SkyfloAI{})
+	}
+	return b.
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.LimitRange{}).
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
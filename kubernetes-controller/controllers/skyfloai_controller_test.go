@@ -0,0 +1,803 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestEngineShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   *metav1.Duration
+		grace     *int64
+		wantEnv   string
+		wantGrace *int64
+	}{
+		{name: "unset"},
+		{name: "grace derived from timeout", timeout: &metav1.Duration{Duration: 30 * time.Second}, wantEnv: "30", wantGrace: ptr.To[int64](40)},
+		{name: "fractional timeout rounds the grace up", timeout: &metav1.Duration{Duration: 1500 * time.Millisecond}, wantEnv: "1", wantGrace: ptr.To[int64](12)},
+		{name: "explicit grace wins", timeout: &metav1.Duration{Duration: 30 * time.Second}, grace: ptr.To[int64](60), wantEnv: "30", wantGrace: ptr.To[int64](60)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.ShutdownTimeout = tt.timeout
+			skyflo.Spec.Engine.TerminationGracePeriodSeconds = tt.grace
+
+			if got := envValue(engineEnv(skyflo, 1), "SHUTDOWN_TIMEOUT"); got != tt.wantEnv {
+				t.Errorf("SHUTDOWN_TIMEOUT = %q, want %q", got, tt.wantEnv)
+			}
+			got := engineTerminationGracePeriod(skyflo.Spec.Engine)
+			if ptr.Deref(got, -1) != ptr.Deref(tt.wantGrace, -1) {
+				t.Errorf("terminationGracePeriodSeconds = %v, want %v", ptr.Deref(got, -1), ptr.Deref(tt.wantGrace, -1))
+			}
+		})
+	}
+}
+
+// reconcileOnce runs a reconcile of the test SkyfloAI and fails the test on
+// error.
+func reconcileOnce(t *testing.T, r *SkyfloAIReconciler) {
+	t.Helper()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+}
+
+// specHashes returns the spec hash annotation of each component Deployment
+// and the applied spec hash of the test SkyfloAI.
+func specHashes(t *testing.T, r *SkyfloAIReconciler) (map[string]string, string) {
+	t.Helper()
+	ctx := context.Background()
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]string{}
+	for _, name := range []string{"ui", "engine", "mcp"} {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-" + name}, deployment); err != nil {
+			t.Fatal(err)
+		}
+		hashes[name] = deployment.Annotations[specHashAnnotation]
+	}
+	return hashes, skyflo.Status.AppliedSpecHash
+}
+
+func TestSpecHash(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	reconcileOnce(t, r)
+	first, firstApplied := specHashes(t, r)
+	if firstApplied == "" {
+		t.Fatal("appliedSpecHash is empty")
+	}
+
+	reconcileOnce(t, r)
+	second, secondApplied := specHashes(t, r)
+	if !reflect.DeepEqual(first, second) || firstApplied != secondApplied {
+		t.Errorf("hashes changed on an unchanged spec: %v %s, then %v %s", first, firstApplied, second, secondApplied)
+	}
+
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	skyflo.Spec.UI.Image = "skyflo/ui:next"
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	third, thirdApplied := specHashes(t, r)
+	if third["ui"] == second["ui"] {
+		t.Error("UI hash did not change with the UI image")
+	}
+	if third["engine"] != second["engine"] || third["mcp"] != second["mcp"] {
+		t.Errorf("a UI change altered other components' hashes: %v, then %v", second, third)
+	}
+	if thirdApplied == secondApplied {
+		t.Error("appliedSpecHash did not change with the spec")
+	}
+}
+
+func TestEnginePoolEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		pool *skyflov1.PoolSpec
+		want map[string]string
+	}{
+		{name: "no pool", want: map[string]string{"DB_POOL_MAX": "", "DB_POOL_MIN": "", "DB_POOL_MAX_IDLE_TIME": ""}},
+		{
+			name: "every setting",
+			pool: &skyflov1.PoolSpec{
+				MaxConnections: ptr.To[int32](20),
+				MinConnections: ptr.To[int32](2),
+				MaxIdleTime:    &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			want: map[string]string{"DB_POOL_MAX": "20", "DB_POOL_MIN": "2", "DB_POOL_MAX_IDLE_TIME": "300"},
+		},
+		{
+			name: "max only",
+			pool: &skyflov1.PoolSpec{MaxConnections: ptr.To[int32](10)},
+			want: map[string]string{"DB_POOL_MAX": "10", "DB_POOL_MIN": "", "DB_POOL_MAX_IDLE_TIME": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.DatabaseConfig = &skyflov1.DatabaseConfig{
+				Host: "postgres", Port: 5432, Database: "skyflo", SecretName: "db", ConnectionPool: tt.pool,
+			}
+			env := engineEnv(skyflo, 1)
+			for name, want := range tt.want {
+				if got := envValue(env, name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		wantReason string
+	}{
+		{name: "undeclared"},
+		{name: "supported", version: strconv.Itoa(skyflov1.SchemaVersion)},
+		{name: "newer", version: strconv.Itoa(skyflov1.SchemaVersion + 1), wantReason: "SchemaVersionTooNew"},
+		{name: "malformed", version: "v2", wantReason: "InvalidSchemaVersion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := testSkyfloAI()
+			if tt.version != "" {
+				skyflo.Annotations = map[string]string{schemaVersionAnnotation: tt.version}
+			}
+			r := newTestReconciler([]client.Object{skyflo})
+			reconcileOnce(t, r)
+
+			if err := r.Get(ctx, client.ObjectKeyFromObject(skyflo), skyflo); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(skyflo.Status.Conditions, "UnsupportedSchema")
+			deployments := &appsv1.DeploymentList{}
+			if err := r.List(ctx, deployments); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantReason == "" {
+				if condition != nil {
+					t.Errorf("unexpected condition %+v", condition)
+				}
+				if len(deployments.Items) == 0 {
+					t.Error("no Deployments were created")
+				}
+				return
+			}
+			if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != tt.wantReason {
+				t.Errorf("condition = %+v, want reason %s", condition, tt.wantReason)
+			}
+			if len(deployments.Items) != 0 {
+				t.Errorf("refused SkyfloAI created %d Deployments", len(deployments.Items))
+			}
+		})
+	}
+}
+
+func TestWorkerConcurrency(t *testing.T) {
+	tests := []struct {
+		total, replicas, want int32
+	}{
+		{total: 10, replicas: 1, want: 10},
+		{total: 10, replicas: 2, want: 5},
+		{total: 10, replicas: 3, want: 4},
+		{total: 10, replicas: 4, want: 3},
+		{total: 3, replicas: 5, want: 1},
+		{total: 10, replicas: 0, want: 10},
+	}
+	for _, tt := range tests {
+		if got := workerConcurrency(tt.total, tt.replicas); got != tt.want {
+			t.Errorf("workerConcurrency(%d, %d) = %d, want %d", tt.total, tt.replicas, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerConcurrencyEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    *int32
+		autoscaling *skyflov1.AutoscalingSpec
+		want        string
+	}{
+		{name: "default replicas", want: "12"},
+		{name: "three replicas", replicas: ptr.To[int32](3), want: "4"},
+		{name: "five replicas", replicas: ptr.To[int32](5), want: "3"},
+		{name: "autoscaled", replicas: ptr.To[int32](3), autoscaling: &skyflov1.AutoscalingSpec{MaxReplicas: 6}},
+		{
+			name:        "autoscaling disabled",
+			replicas:    ptr.To[int32](3),
+			autoscaling: &skyflov1.AutoscalingSpec{Enabled: ptr.To(false), MaxReplicas: 6},
+			want:        "4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.TotalConcurrency = ptr.To[int32](12)
+			skyflo.Spec.Engine.Replicas = tt.replicas
+			skyflo.Spec.Engine.Autoscaling = tt.autoscaling
+			r := newTestReconciler(nil)
+			deployment := r.deployment(skyflo, components(skyflo)[1])
+			if got := envValue(deployment.Spec.Template.Spec.Containers[0].Env, "WORKER_CONCURRENCY"); got != tt.want {
+				t.Errorf("WORKER_CONCURRENCY = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalConcurrencyIgnored(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.TotalConcurrency = ptr.To[int32](100)
+	skyflo.Spec.Engine.Autoscaling = &skyflov1.AutoscalingSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 10}
+	r := newTestReconciler([]client.Object{skyflo})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+	engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(got.Status.Conditions, "TotalConcurrencyIgnored")
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != "AutoscalingEnabled" {
+		t.Errorf("TotalConcurrencyIgnored = %+v, want True with reason AutoscalingEnabled", cond)
+	}
+	engine := &appsv1.Deployment{}
+	if err := r.Get(ctx, engineKey, engine); err != nil {
+		t.Fatal(err)
+	}
+	if got := envValue(engine.Spec.Template.Spec.Containers[0].Env, "WORKER_CONCURRENCY"); got != "" {
+		t.Errorf("autoscaled WORKER_CONCURRENCY = %q, want it unset", got)
+	}
+
+	got.Spec.Engine.Autoscaling = nil
+	got.Spec.Engine.Replicas = ptr.To[int32](4)
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+	if err := r.Get(ctx, key, got); err != nil {
+		t.Fatal(err)
+	}
+	if cond := meta.FindStatusCondition(got.Status.Conditions, "TotalConcurrencyIgnored"); cond != nil {
+		t.Errorf("TotalConcurrencyIgnored = %+v after disabling autoscaling, want it removed", cond)
+	}
+	if err := r.Get(ctx, engineKey, engine); err != nil {
+		t.Fatal(err)
+	}
+	if got := envValue(engine.Spec.Template.Spec.Containers[0].Env, "WORKER_CONCURRENCY"); got != "25" {
+		t.Errorf("WORKER_CONCURRENCY = %q, want 25", got)
+	}
+}
+
+func TestEngineQueuesEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		queues []string
+		want   string
+	}{
+		{name: "unset"},
+		{name: "one queue", queues: []string{"default"}, want: "default"},
+		{name: "order kept", queues: []string{"urgent", "default", "batch"}, want: "urgent,default,batch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.Queues = tt.queues
+			r := newTestReconciler(nil)
+			for i := 0; i < 2; i++ {
+				env := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec.Containers[0].Env
+				if got := envValue(env, "QUEUES"); got != tt.want {
+					t.Errorf("QUEUES = %q, want %q", got, tt.want)
+				}
+			}
+			for _, c := range []component{components(skyflo)[0], components(skyflo)[2]} {
+				if got := envValue(r.deployment(skyflo, c).Spec.Template.Spec.Containers[0].Env, "QUEUES"); got != "" {
+					t.Errorf("%s QUEUES = %q, want it only on the Engine", c.displayName, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPausedPerComponent(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.Paused = true
+	r := newTestReconciler(nil)
+
+	want := map[string]bool{"ui": true, "engine": false, "mcp": false}
+	for _, c := range components(skyflo) {
+		if got := r.deployment(skyflo, c).Spec.Paused; got != want[c.name] {
+			t.Errorf("%s paused = %v, want %v", c.name, got, want[c.name])
+		}
+	}
+}
+
+func TestEnableServiceLinks(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.EnableServiceLinks = ptr.To(true)
+	skyflo.Spec.MCP.EnableServiceLinks = ptr.To(false)
+	r := newTestReconciler(nil)
+
+	want := map[string]bool{"ui": false, "engine": true, "mcp": false}
+	for _, c := range components(skyflo) {
+		got := r.deployment(skyflo, c).Spec.Template.Spec.EnableServiceLinks
+		if got == nil || *got != want[c.name] {
+			t.Errorf("%s enableServiceLinks = %v, want %v", c.name, got, want[c.name])
+		}
+	}
+}
+
+func TestComponentSize(t *testing.T) {
+	resources := func(cpu, memory, cpuLimit, memoryLimit string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpuLimit),
+				corev1.ResourceMemory: resource.MustParse(memoryLimit),
+			},
+		}
+	}
+	explicit := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+	}
+	tests := []struct {
+		name      string
+		size      skyflov1.ComponentSize
+		resources corev1.ResourceRequirements
+		want      corev1.ResourceRequirements
+	}{
+		{name: "unset"},
+		{name: "small", size: skyflov1.ComponentSizeSmall, want: resources("100m", "128Mi", "500m", "512Mi")},
+		{name: "medium", size: skyflov1.ComponentSizeMedium, want: resources("250m", "512Mi", "1", "1Gi")},
+		{name: "large", size: skyflov1.ComponentSizeLarge, want: resources("500m", "1Gi", "2", "4Gi")},
+		{name: "explicit resources win", size: skyflov1.ComponentSizeLarge, resources: explicit, want: explicit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.MCP.Size = tt.size
+			skyflo.Spec.MCP.Resources = tt.resources
+			r := newTestReconciler(nil)
+
+			deployment := r.deployment(skyflo, components(skyflo)[2])
+			got := deployment.Spec.Template.Spec.Containers[0].Resources
+			if !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("resources = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponentNodes(t *testing.T) {
+	skyflo := testSkyfloAI()
+	selector := newTestReconciler(nil).deployment(skyflo, components(skyflo)[1]).Spec.Selector.MatchLabels
+	pod := func(name, node string) client.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: selector},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+	r := newTestReconciler([]client.Object{
+		skyflo,
+		pod("engine-1", "node-b"),
+		pod("engine-2", "node-a"),
+		pod("engine-3", "node-b"),
+		pod("engine-4", ""),
+	})
+	reconcileOnce(t, r)
+
+	got := &skyflov1.SkyfloAI{}
+	if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"node-a", "node-b"}; !reflect.DeepEqual(got.Status.EngineStatus.Nodes, want) {
+		t.Errorf("engine nodes = %v, want %v", got.Status.EngineStatus.Nodes, want)
+	}
+	if got.Status.UIStatus.Nodes != nil {
+		t.Errorf("ui nodes = %v, want none", got.Status.UIStatus.Nodes)
+	}
+}
+
+func TestTrafficDistribution(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   *string
+		version *version.Info
+		want    *string
+	}{
+		{name: "unset"},
+		{name: "unknown server version", value: ptr.To("PreferClose"), want: ptr.To("PreferClose")},
+		{name: "supported", value: ptr.To("PreferClose"), version: &version.Info{GitVersion: "v1.31.2"}, want: ptr.To("PreferClose")},
+		{name: "too old", value: ptr.To("PreferClose"), version: &version.Info{GitVersion: "v1.30.4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.TrafficDistribution = tt.value
+			r := newTestReconciler(nil)
+			r.ServerVersion = tt.version
+
+			got := r.trafficDistribution(context.Background(), components(skyflo)[1])
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trafficDistribution = %v, want %v", ptr.Deref(got, "<nil>"), ptr.Deref(tt.want, "<nil>"))
+			}
+
+			obj, err := serviceObject(r.service(skyflo, components(skyflo)[1]), got)
+			if err != nil {
+				t.Fatalf("serviceObject: %v", err)
+			}
+			u, ok := obj.(*unstructured.Unstructured)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("Service written as unstructured without a traffic distribution")
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Service with a traffic distribution written as %T", obj)
+			}
+			value, _, _ := unstructured.NestedString(u.Object, "spec", "trafficDistribution")
+			if value != *tt.want {
+				t.Errorf("spec.trafficDistribution = %q, want %q", value, *tt.want)
+			}
+		})
+	}
+}
+
+func TestPartialComponentFailure(t *testing.T) {
+	ctx := context.Background()
+	errUI := errors.New("injected UI failure")
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*appsv1.Deployment); ok && obj.GetName() == "skyflo-ui" {
+				return errUI
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	if _, err := r.Reconcile(ctx, req); !errors.Is(err, errUI) {
+		t.Fatalf("Reconcile error = %v, want the UI failure", err)
+	}
+
+	for _, name := range []string{"skyflo-engine", "skyflo-mcp"} {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &appsv1.Deployment{}); err != nil {
+			t.Errorf("Deployment %s was not reconciled: %v", name, err)
+		}
+	}
+
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, req.NamespacedName, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if skyflo.Status.EngineStatus.Phase == "" || skyflo.Status.MCPStatus.Phase == "" {
+		t.Errorf("engine and MCP status not updated: %+v, %+v", skyflo.Status.EngineStatus, skyflo.Status.MCPStatus)
+	}
+	if skyflo.Status.UIStatus.Phase != "" {
+		t.Errorf("UI status = %+v, want none for the failed component", skyflo.Status.UIStatus)
+	}
+	condition := meta.FindStatusCondition(skyflo.Status.Conditions, "ComponentsReconciled")
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Message != "failed to reconcile: UI" {
+		t.Errorf("ComponentsReconciled = %+v, want False naming the UI", condition)
+	}
+}
+
+func TestSchedulerName(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.SchedulerName = "gpu-scheduler"
+	skyflo.Spec.MCP.SchedulerName = "volcano"
+	r := newTestReconciler(nil)
+
+	want := map[string]string{"ui": "", "engine": "gpu-scheduler", "mcp": "volcano"}
+	for _, c := range components(skyflo) {
+		if got := r.deployment(skyflo, c).Spec.Template.Spec.SchedulerName; got != want[c.name] {
+			t.Errorf("%s schedulerName = %q, want %q", c.name, got, want[c.name])
+		}
+	}
+}
+
+func TestCustomDNS(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.CustomDNS = &skyflov1.CustomDNSSpec{
+		Nameservers: []string{"10.0.0.53"},
+		Searches:    []string{"corp.internal"},
+		Options:     []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("2")}},
+	}
+	r := newTestReconciler(nil)
+
+	for _, c := range components(skyflo) {
+		podSpec := r.deployment(skyflo, c).Spec.Template.Spec
+		if c.name != "engine" {
+			if podSpec.DNSPolicy != "" || podSpec.DNSConfig != nil {
+				t.Errorf("%s dns = %q %+v, want the cluster default", c.name, podSpec.DNSPolicy, podSpec.DNSConfig)
+			}
+			continue
+		}
+		if podSpec.DNSPolicy != corev1.DNSNone {
+			t.Errorf("engine dnsPolicy = %q, want None", podSpec.DNSPolicy)
+		}
+		want := &corev1.PodDNSConfig{
+			Nameservers: []string{"10.0.0.53"},
+			Searches:    []string{"corp.internal"},
+			Options:     []corev1.PodDNSConfigOption{{Name: "ndots", Value: ptr.To("2")}},
+		}
+		if !reflect.DeepEqual(podSpec.DNSConfig, want) {
+			t.Errorf("engine dnsConfig = %+v, want %+v", podSpec.DNSConfig, want)
+		}
+	}
+}
+
+func TestPreemptionPolicy(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.PriorityClassName = "skyflo-critical"
+	skyflo.Spec.Engine.PreemptionPolicy = ptr.To(corev1.PreemptLowerPriority)
+	skyflo.Spec.UI.PriorityClassName = "skyflo-background"
+	skyflo.Spec.UI.PreemptionPolicy = ptr.To(corev1.PreemptNever)
+	r := newTestReconciler(nil)
+
+	// The MCP is deliberately not defaulted to Never: without a matching
+	// PriorityClass the API server would reject its pods.
+	want := map[string]*corev1.PreemptionPolicy{
+		"ui":     ptr.To(corev1.PreemptNever),
+		"engine": ptr.To(corev1.PreemptLowerPriority),
+		"mcp":    nil,
+	}
+	wantClass := map[string]string{"ui": "skyflo-background", "engine": "skyflo-critical", "mcp": ""}
+	for _, c := range components(skyflo) {
+		podSpec := r.deployment(skyflo, c).Spec.Template.Spec
+		if !reflect.DeepEqual(podSpec.PreemptionPolicy, want[c.name]) {
+			t.Errorf("%s preemptionPolicy = %v, want %v", c.name,
+				ptr.Deref(podSpec.PreemptionPolicy, "<nil>"), ptr.Deref(want[c.name], "<nil>"))
+		}
+		if podSpec.PriorityClassName != wantClass[c.name] {
+			t.Errorf("%s priorityClassName = %q, want %q", c.name, podSpec.PriorityClassName, wantClass[c.name])
+		}
+	}
+}
+
+func TestRecordRollout(t *testing.T) {
+	deployment := func(image string, done bool) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](2),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: image}}}},
+			},
+			Status: appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 2},
+		}
+		if done {
+			d.Status.UpdatedReplicas = 2
+		}
+		return d
+	}
+
+	var status skyflov1.ComponentStatus
+	observe := func(d *appsv1.Deployment) {
+		var next skyflov1.ComponentStatus
+		recordRollout(&next, status, d)
+		status = next
+	}
+
+	observe(deployment("skyflo/engine:v1", false))
+	if status.RolledOutImage != "" || status.LastRolloutTime != nil {
+		t.Fatalf("in-progress rollout recorded: %+v", status)
+	}
+
+	observe(deployment("skyflo/engine:v1", true))
+	if status.RolledOutImage != "skyflo/engine:v1" || status.LastRolloutTime == nil {
+		t.Fatalf("completed rollout not recorded: %+v", status)
+	}
+	first := status.LastRolloutTime
+
+	observe(deployment("skyflo/engine:v1", true))
+	if status.LastRolloutTime != first {
+		t.Errorf("rollout time reset without an image change: %v, then %v", first, status.LastRolloutTime)
+	}
+
+	observe(deployment("skyflo/engine:v2", false))
+	if status.RolledOutImage != "skyflo/engine:v1" || status.LastRolloutTime != first {
+		t.Errorf("in-progress rollout of v2 replaced the record: %+v", status)
+	}
+
+	observe(deployment("skyflo/engine:v2", true))
+	if status.RolledOutImage != "skyflo/engine:v2" || status.LastRolloutTime == first {
+		t.Errorf("completed rollout of v2 not recorded: %+v", status)
+	}
+
+	tracked := deployment("skyflo/engine:stable", true)
+	tracked.Spec.Template.Annotations = map[string]string{imageDigestAnnotation: "sha256:aaa"}
+	observe(tracked)
+	if status.RolledOutImage != "skyflo/engine:stable@sha256:aaa" {
+		t.Errorf("tracked rollout image = %q, want the tag with its digest", status.RolledOutImage)
+	}
+}
+
+func TestAgentlessMCP(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler([]client.Object{testSkyfloAI()})
+	reconcileOnce(t, r)
+
+	key := types.NamespacedName{Namespace: "default", Name: "skyflo-mcp"}
+	if err := r.Get(ctx, key, &corev1.Service{}); err != nil {
+		t.Fatalf("MCP Service before agent mode: %v", err)
+	}
+
+	skyflo := &skyflov1.SkyfloAI{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo"}, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	skyflo.Spec.MCP.Agentless = true
+	skyflo.Spec.MCP.Image = "skyflo/mcp:agent"
+	if err := r.Update(ctx, skyflo); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, r)
+
+	if err := r.Get(ctx, key, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("MCP Service in agent mode: err = %v, want NotFound", err)
+	}
+	for _, name := range []string{"skyflo-ui", "skyflo-engine"} {
+		if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &corev1.Service{}); err != nil {
+			t.Errorf("%s Service: %v", name, err)
+		}
+	}
+
+	mcp := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, mcp); err != nil {
+		t.Fatal(err)
+	}
+	container := mcp.Spec.Template.Spec.Containers[0]
+	if container.Image != "skyflo/mcp:agent" {
+		t.Errorf("MCP image = %q, want the Deployment still reconciled", container.Image)
+	}
+	if len(container.Ports) > 0 {
+		t.Errorf("MCP ports = %v, want none in agent mode", container.Ports)
+	}
+	if container.LivenessProbe != nil || container.ReadinessProbe != nil {
+		t.Errorf("MCP probes = %v, %v, want no default probes on the dropped http port", container.LivenessProbe, container.ReadinessProbe)
+	}
+}
+
+func TestExtraPorts(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.ExtraPorts = []corev1.ContainerPort{
+		{Name: "admin", ContainerPort: 9000, Protocol: corev1.ProtocolTCP},
+		{Name: "debug", ContainerPort: 9229, Protocol: corev1.ProtocolTCP},
+	}
+	engine := components(skyflo)[1]
+
+	var containerPorts []string
+	for _, port := range r.deployment(skyflo, engine).Spec.Template.Spec.Containers[0].Ports {
+		containerPorts = append(containerPorts, port.Name+":"+strconv.Itoa(int(port.ContainerPort)))
+	}
+	if want := []string{"http:8081", "admin:9000", "debug:9229"}; !reflect.DeepEqual(containerPorts, want) {
+		t.Errorf("container ports = %v, want %v", containerPorts, want)
+	}
+
+	service := r.service(skyflo, engine)
+	for _, extra := range skyflo.Spec.Engine.ExtraPorts {
+		var found bool
+		for _, port := range service.Spec.Ports {
+			if port.Name == extra.Name {
+				found = true
+				if port.Port != extra.ContainerPort || port.TargetPort.IntValue() != int(extra.ContainerPort) || port.Protocol != extra.Protocol {
+					t.Errorf("Service port %s = %+v, want %d targeting %d", extra.Name, port, extra.ContainerPort, extra.ContainerPort)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Service ports %v, want %s", servicePortNames(service), extra.Name)
+		}
+	}
+
+	if ports := r.deployment(skyflo, components(skyflo)[2]).Spec.Template.Spec.Containers[0].Ports; len(ports) != 1 {
+		t.Errorf("MCP ports = %v, want only its http port", ports)
+	}
+}
+
+func TestRolloutBounds(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.MaxSurge = ptr.To(intstr.FromString("50%"))
+	skyflo.Spec.Engine.MaxUnavailable = ptr.To(intstr.FromString("10%"))
+	skyflo.Spec.MCP.MaxUnavailable = ptr.To(intstr.FromInt32(0))
+
+	engine := r.deployment(skyflo, components(skyflo)[1]).Spec.Strategy
+	want := appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       ptr.To(intstr.FromString("50%")),
+			MaxUnavailable: ptr.To(intstr.FromString("10%")),
+		},
+	}
+	if !equality.Semantic.DeepEqual(engine, want) {
+		t.Errorf("engine strategy = %+v, want %+v", engine, want)
+	}
+
+	mcp := r.deployment(skyflo, components(skyflo)[2]).Spec.Strategy
+	if mcp.RollingUpdate == nil || mcp.RollingUpdate.MaxSurge != nil || mcp.RollingUpdate.MaxUnavailable.IntValue() != 0 ||
+		mcp.RollingUpdate.MaxUnavailable.Type != intstr.Int {
+		t.Errorf("MCP strategy = %+v, want only maxUnavailable 0", mcp)
+	}
+
+	if ui := r.deployment(skyflo, components(skyflo)[0]).Spec.Strategy; ui.RollingUpdate != nil {
+		t.Errorf("UI strategy = %+v, want the Deployment default", ui)
+	}
+}
+
+func TestSingleStatusWrite(t *testing.T) {
+	writes := 0
+	countStatus := func(obj client.Object) {
+		if _, ok := obj.(*skyflov1.SkyfloAI); ok {
+			writes++
+		}
+	}
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			countStatus(obj)
+			return c.SubResource(subResource).Update(ctx, obj, opts...)
+		},
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			countStatus(obj)
+			return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	reconcileOnce(t, r)
+	if writes != 1 {
+		t.Errorf("first reconcile wrote status %d times, want 1", writes)
+	}
+
+	markReady(t, r, "skyflo-ui", "skyflo-engine", "skyflo-mcp")
+	writes = 0
+	reconcileOnce(t, r)
+	if writes != 1 {
+		t.Errorf("reconcile of ready components wrote status %d times, want 1", writes)
+	}
+
+	writes = 0
+	reconcileOnce(t, r)
+	if writes != 0 {
+		t.Errorf("reconcile without status changes wrote status %d times, want 0", writes)
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// spotTaints are the taints providers put on spot and preemptible nodes.
+// Their values vary, so any value is tolerated.
+var spotTaints = []string{
+	"cloud.google.com/gke-spot",
+	"cloud.google.com/gke-preemptible",
+	"kubernetes.azure.com/scalesetpriority",
+}
+
+// addSpotTolerations lets the pods schedule onto spot nodes and caps their
+// termination grace period, which defaults to 30 seconds, so they exit
+// before the node is reclaimed.
+func addSpotTolerations(deployment *appsv1.Deployment) {
+	spec := &deployment.Spec.Template.Spec
+	tolerations := make([]corev1.Toleration, 0, len(spec.Tolerations)+len(spotTaints))
+	tolerations = append(tolerations, spec.Tolerations...)
+	for _, key := range spotTaints {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      key,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		})
+	}
+	spec.Tolerations = tolerations
+
+	if spec.TerminationGracePeriodSeconds == nil || *spec.TerminationGracePeriodSeconds > skyflov1.SpotTerminationGracePeriodSeconds {
+		spec.TerminationGracePeriodSeconds = ptr.To[int64](skyflov1.SpotTerminationGracePeriodSeconds)
+	}
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// hasSpotTolerations reports whether tolerations tolerate every spot taint.
+func hasSpotTolerations(tolerations []corev1.Toleration) bool {
+	for _, key := range spotTaints {
+		taint := &corev1.Taint{Key: key, Value: "true", Effect: corev1.TaintEffectNoSchedule}
+		tolerated := false
+		for i := range tolerations {
+			if tolerations[i].ToleratesTaint(taint) {
+				tolerated = true
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSpot(t *testing.T) {
+	gpu := corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Tolerations = []corev1.Toleration{gpu}
+	skyflo.Spec.UI.Spot = true
+	skyflo.Spec.MCP.Spot = true
+	r := newTestReconciler(nil)
+
+	for _, c := range []component{components(skyflo)[0], components(skyflo)[2]} {
+		spec := r.deployment(skyflo, c).Spec.Template.Spec
+		if !hasSpotTolerations(spec.Tolerations) {
+			t.Errorf("%s tolerations = %+v, want the spot taints tolerated", c.displayName, spec.Tolerations)
+		}
+		if len(spec.Tolerations) != len(spotTaints)+1 || spec.Tolerations[0] != gpu {
+			t.Errorf("%s tolerations = %+v, want spec.tolerations kept first", c.displayName, spec.Tolerations)
+		}
+		if got := ptr.Deref(spec.TerminationGracePeriodSeconds, 0); got != skyflov1.SpotTerminationGracePeriodSeconds {
+			t.Errorf("%s terminationGracePeriodSeconds = %d, want %d", c.displayName, got, skyflov1.SpotTerminationGracePeriodSeconds)
+		}
+	}
+	if got := skyflo.Spec.Tolerations; len(got) != 1 {
+		t.Errorf("spec.tolerations = %+v, want it left unchanged", got)
+	}
+
+	engine := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec
+	if hasSpotTolerations(engine.Tolerations) {
+		t.Errorf("Engine without spot tolerates the spot taints: %+v", engine.Tolerations)
+	}
+	if grace := engine.TerminationGracePeriodSeconds; grace != nil && *grace <= skyflov1.SpotTerminationGracePeriodSeconds {
+		t.Errorf("Engine without spot has terminationGracePeriodSeconds %d, want the default", *grace)
+	}
+}
+
+func TestSpotEngineGracePeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		grace   *int64
+		timeout time.Duration
+		want    int64
+	}{
+		{name: "default", want: skyflov1.SpotTerminationGracePeriodSeconds},
+		{name: "shorter grace kept", grace: ptr.To[int64](10), want: 10},
+		{name: "padded shutdown timeout within the cap", timeout: 10 * time.Second, want: 20},
+		{name: "padded shutdown timeout over the cap", timeout: 20 * time.Second, want: skyflov1.SpotTerminationGracePeriodSeconds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.Spot = true
+			skyflo.Spec.Engine.TerminationGracePeriodSeconds = tt.grace
+			if tt.timeout != 0 {
+				skyflo.Spec.Engine.ShutdownTimeout = &metav1.Duration{Duration: tt.timeout}
+			}
+			r := newTestReconciler(nil)
+			spec := r.deployment(skyflo, components(skyflo)[1]).Spec.Template.Spec
+			if !hasSpotTolerations(spec.Tolerations) {
+				t.Errorf("spot Engine tolerations = %+v, want the spot taints tolerated", spec.Tolerations)
+			}
+			if got := ptr.Deref(spec.TerminationGracePeriodSeconds, 0); got != tt.want {
+				t.Errorf("terminationGracePeriodSeconds = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// deletePVCAnnotation opts a SkyfloAI into deleting the Engine claim when its
+// storage is removed. The claim is never deleted without it.
+const deletePVCAnnotation = "skyflo.ai/delete-pvc"
+
+const (
+	engineDataVolume       = "data"
+	defaultEngineMountPath = "/data"
+)
+
+// reconcileEngineStorage creates the Engine PersistentVolumeClaim. Once the
+// storage is removed the claim is kept unless deletion was explicitly
+// requested, so removing the field never deletes data by accident. A retained
+// claim, the default, records its SkyfloAI in owner labels instead of an
+// owner reference, so it also outlives the SkyfloAI; otherwise the SkyfloAI
+// owns it and it is garbage collected along with it.
+func (r *SkyfloAIReconciler) reconcileEngineStorage(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	name := skyflo.Name + "-engine-data"
+	engine := skyflo.Spec.Engine
+	retain := *boolOrDefault(engine.RetainStorage, true)
+
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: skyflo.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	ours := exists && (metav1.IsControlledBy(found, skyflo) || ownsClusterObject(skyflo, found))
+
+	if engine.Storage == nil {
+		if !ours || skyflo.Annotations[deletePVCAnnotation] != "true" {
+			return nil
+		}
+		log.FromContext(ctx).Info("deleting Engine storage claim", "claim", name)
+		return client.IgnoreNotFound(r.Delete(ctx, found))
+	}
+
+	if exists {
+		if !ours {
+			return nil
+		}
+		if err := r.setClaimRetention(ctx, skyflo, found, retain); err != nil {
+			return err
+		}
+		return r.expandClaim(ctx, found, engine.Storage.Size)
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: skyflo.Namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: engine.Storage.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: engine.Storage.Size,
+				},
+			},
+		},
+	}
+	if retain {
+		claim.Labels = clusterOwnerLabels(skyflo)
+		addCommonMetadata(skyflo, claim, "")
+	} else if err := r.own(skyflo, claim); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("creating Engine storage claim", "claim", name)
+	return r.create(ctx, claim)
+}
+
+// setClaimRetention moves an existing claim between the owner labels of a
+// retained claim and the owner reference of one deleted with the SkyfloAI,
+// when retainStorage changed.
+func (r *SkyfloAIReconciler) setClaimRetention(ctx context.Context, skyflo *skyflov1.SkyfloAI, claim *corev1.PersistentVolumeClaim, retain bool) error {
+	owned := metav1.IsControlledBy(claim, skyflo)
+	if retain != owned {
+		return nil
+	}
+
+	patched := claim.DeepCopy()
+	labels := patched.GetLabels()
+	if retain {
+		var refs []metav1.OwnerReference
+		for _, ref := range patched.OwnerReferences {
+			if ref.UID != skyflo.UID {
+				refs = append(refs, ref)
+			}
+		}
+		patched.OwnerReferences = refs
+		patched.Labels = mergeMaps(labels, clusterOwnerLabels(skyflo))
+	} else {
+		delete(labels, ownerNamespaceLabel)
+		delete(labels, ownerNameLabel)
+		patched.Labels = labels
+		if err := controllerutil.SetControllerReference(skyflo, patched, r.Scheme); err != nil {
+			return err
+		}
+	}
+	return r.Patch(ctx, patched, client.MergeFrom(claim), r.fieldOwner())
+}
+
+// expandClaim raises the storage request of an existing claim to size. The
+// storage class must allow volume expansion; claims cannot shrink, so a
+// smaller size is left alone.
+func (r *SkyfloAIReconciler) expandClaim(ctx context.Context, claim *corev1.PersistentVolumeClaim, size resource.Quantity) error {
+	current := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	if size.Cmp(current) <= 0 {
+		return nil
+	}
+
+	patched := claim.DeepCopy()
+	if patched.Spec.Resources.Requests == nil {
+		patched.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	patched.Spec.Resources.Requests[corev1.ResourceStorage] = size
+	log.FromContext(ctx).Info("expanding Engine storage claim", "claim", claim.Name, "from", current.String(), "to", size.String())
+	return r.Patch(ctx, patched, client.MergeFrom(claim), r.fieldOwner())
+}
+
+// addEngineStorage mounts the Engine claim into the Engine container.
+func addEngineStorage(skyflo *skyflov1.SkyfloAI, deployment *appsv1.Deployment) {
+	mountPath := skyflo.Spec.Engine.Storage.MountPath
+	if mountPath == "" {
+		mountPath = defaultEngineMountPath
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: engineDataVolume,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: skyflo.Name + "-engine-data",
+			},
+		},
+	})
+	container := &podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      engineDataVolume,
+		MountPath: mountPath,
+	})
+}
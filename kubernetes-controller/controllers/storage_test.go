@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func storageSkyfloAI(size string) *skyflov1.SkyfloAI {
+	skyflo := testSkyfloAI()
+	if size != "" {
+		skyflo.Spec.Engine.Storage = &skyflov1.StorageSpec{Size: resource.MustParse(size)}
+	}
+	return skyflo
+}
+
+func getClaim(t *testing.T, r *SkyfloAIReconciler) (*corev1.PersistentVolumeClaim, bool) {
+	t.Helper()
+	claim := &corev1.PersistentVolumeClaim{}
+	err := r.Get(context.Background(), types.NamespacedName{Name: "skyflo-engine-data", Namespace: "default"}, claim)
+	if errors.IsNotFound(err) {
+		return nil, false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claim, true
+}
+
+func TestReconcileEngineStorageRetention(t *testing.T) {
+	tests := []struct {
+		name        string
+		retain      *bool
+		deleteClaim bool
+		wantClaim   bool
+	}{
+		{name: "retained by default", wantClaim: true},
+		{name: "retained without the annotation", retain: ptr.To(false), wantClaim: true},
+		{name: "deleted with the annotation", deleteClaim: true},
+		{name: "deleted with the annotation when owned", retain: ptr.To(false), deleteClaim: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			skyflo := storageSkyfloAI("1Gi")
+			skyflo.Spec.Engine.RetainStorage = tt.retain
+			r := newTestReconciler(nil)
+			if err := r.reconcileEngineStorage(ctx, skyflo); err != nil {
+				t.Fatalf("creating the claim: %v", err)
+			}
+			claim, ok := getClaim(t, r)
+			if !ok {
+				t.Fatal("claim was not created")
+			}
+			if owned := metav1.IsControlledBy(claim, skyflo); owned == *boolOrDefault(tt.retain, true) {
+				t.Errorf("claim controlled by the SkyfloAI = %v with retainStorage %v", owned, tt.retain)
+			}
+
+			skyflo.Spec.Engine.Storage = nil
+			if tt.deleteClaim {
+				skyflo.Annotations = map[string]string{deletePVCAnnotation: "true"}
+			}
+			if err := r.reconcileEngineStorage(ctx, skyflo); err != nil {
+				t.Fatalf("removing storage: %v", err)
+			}
+			if _, ok := getClaim(t, r); ok != tt.wantClaim {
+				t.Errorf("claim exists = %v, want %v", ok, tt.wantClaim)
+			}
+		})
+	}
+}
+
+func TestReconcileEngineStorageLeavesForeignClaims(t *testing.T) {
+	foreign := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "skyflo-engine-data", Namespace: "default"}}
+	r := newTestReconciler([]client.Object{foreign})
+	skyflo := storageSkyfloAI("")
+	skyflo.Annotations = map[string]string{deletePVCAnnotation: "true"}
+
+	if err := r.reconcileEngineStorage(context.Background(), skyflo); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := getClaim(t, r); !ok {
+		t.Error("a claim the SkyfloAI does not own was deleted")
+	}
+}
+
+func TestReconcileEngineStorageExpands(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{name: "grows", from: "1Gi", to: "5Gi", want: "5Gi"},
+		{name: "never shrinks", from: "5Gi", to: "1Gi", want: "5Gi"},
+		{name: "unchanged", from: "1Gi", to: "1Gi", want: "1Gi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestReconciler(nil)
+			if err := r.reconcileEngineStorage(ctx, storageSkyfloAI(tt.from)); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.reconcileEngineStorage(ctx, storageSkyfloAI(tt.to)); err != nil {
+				t.Fatal(err)
+			}
+			claim, _ := getClaim(t, r)
+			got := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			if got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("requested storage = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineStorageMount(t *testing.T) {
+	tests := []struct {
+		name, mountPath, want string
+	}{
+		{name: "default path", want: defaultEngineMountPath},
+		{name: "custom path", mountPath: "/var/lib/engine", want: "/var/lib/engine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := storageSkyfloAI("2Gi")
+			skyflo.Spec.Engine.Storage.StorageClassName = ptr.To("fast")
+			skyflo.Spec.Engine.Storage.MountPath = tt.mountPath
+			r := newTestReconciler([]client.Object{skyflo})
+			reconcileOnce(t, r)
+
+			claim, ok := getClaim(t, r)
+			if !ok {
+				t.Fatal("Engine claim not created")
+			}
+			size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			if size.Cmp(resource.MustParse("2Gi")) != 0 || ptr.Deref(claim.Spec.StorageClassName, "") != "fast" ||
+				len(claim.Spec.AccessModes) != 1 || claim.Spec.AccessModes[0] != corev1.ReadWriteOnce {
+				t.Errorf("claim spec = %+v, want 2Gi ReadWriteOnce of class fast", claim.Spec)
+			}
+
+			engine := &appsv1.Deployment{}
+			if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, engine); err != nil {
+				t.Fatal(err)
+			}
+			podSpec := engine.Spec.Template.Spec
+			var volume *corev1.Volume
+			for i, v := range podSpec.Volumes {
+				if v.Name == engineDataVolume {
+					volume = &podSpec.Volumes[i]
+				}
+			}
+			if volume == nil || volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != "skyflo-engine-data" {
+				t.Fatalf("Engine volumes = %+v, want the skyflo-engine-data claim", podSpec.Volumes)
+			}
+			var mount *corev1.VolumeMount
+			for i, m := range podSpec.Containers[0].VolumeMounts {
+				if m.Name == engineDataVolume {
+					mount = &podSpec.Containers[0].VolumeMounts[i]
+				}
+			}
+			if mount == nil || mount.MountPath != tt.want {
+				t.Errorf("data mount = %+v, want %s", mount, tt.want)
+			}
+		})
+	}
+}
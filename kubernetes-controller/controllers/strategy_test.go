@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// defaultStrategy defaults an unset Deployment strategy to a rolling update
+// with 25% bounds and validates it, as the API server does.
+func defaultStrategy(obj client.Object) error {
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil
+	}
+	strategy := &deployment.Spec.Strategy
+	if strategy.Type == "" {
+		strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if strategy.Type == appsv1.RollingUpdateDeploymentStrategyType && strategy.RollingUpdate == nil {
+		bound := intstr.FromString("25%")
+		strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{MaxSurge: &bound, MaxUnavailable: &bound}
+	}
+	if strategy.Type == appsv1.RecreateDeploymentStrategyType && strategy.RollingUpdate != nil {
+		return apierrors.NewInvalid(appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind(), deployment.Name, nil)
+	}
+	return nil
+}
+
+// strategyDefaulting returns interceptor funcs that default and validate
+// Deployment strategies like the API server, and emulate applies, which the
+// fake client cannot do. Like the API server, an apply keeps the live
+// rolling update bounds of a Deployment when it does not set them.
+// mergePatches records the merge patches of Deployments.
+func strategyDefaulting(mergePatches *[]string) interceptor.Funcs {
+	return interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if err := defaultStrategy(obj); err != nil {
+				return err
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if err := defaultStrategy(obj); err != nil {
+				return err
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*appsv1.Deployment); ok && patch.Type() == types.MergePatchType {
+				data, err := patch.Data(obj)
+				if err != nil {
+					return err
+				}
+				*mergePatches = append(*mergePatches, string(data))
+			}
+			config, ok := obj.(*unstructured.Unstructured)
+			if patch.Type() != types.ApplyPatchType || !ok {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+
+			typed, err := c.Scheme().New(config.GroupVersionKind())
+			if err != nil {
+				return err
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(config.Object, typed); err != nil {
+				return err
+			}
+			desired := typed.(client.Object)
+			live := desired.DeepCopyObject().(client.Object)
+			err = c.Get(ctx, client.ObjectKeyFromObject(desired), live)
+			if apierrors.IsNotFound(err) {
+				if err := defaultStrategy(desired); err != nil {
+					return err
+				}
+				return c.Create(ctx, desired)
+			}
+			if err != nil {
+				return err
+			}
+			if deployment, ok := desired.(*appsv1.Deployment); ok && deployment.Spec.Strategy.RollingUpdate == nil {
+				deployment.Spec.Strategy.RollingUpdate = live.(*appsv1.Deployment).Spec.Strategy.RollingUpdate
+			}
+			if err := defaultStrategy(desired); err != nil {
+				return err
+			}
+			desired.SetResourceVersion(live.GetResourceVersion())
+			return c.Update(ctx, desired)
+		},
+	}
+}
+
+func TestStrategySwitch(t *testing.T) {
+	for _, ssa := range []bool{false, true} {
+		t.Run(fmt.Sprintf("server-side apply %t", ssa), func(t *testing.T) {
+			ctx := context.Background()
+			var mergePatches []string
+			r := newTestReconciler([]client.Object{testSkyfloAI()}, strategyDefaulting(&mergePatches))
+			r.ServerSideApply = ssa
+			reconcileOnce(t, r)
+
+			key := types.NamespacedName{Namespace: "default", Name: "skyflo"}
+			engineKey := types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}
+			engine := &appsv1.Deployment{}
+			setStrategy := func(strategy *appsv1.DeploymentStrategy) {
+				t.Helper()
+				skyflo := &skyflov1.SkyfloAI{}
+				if err := r.Get(ctx, key, skyflo); err != nil {
+					t.Fatal(err)
+				}
+				skyflo.Spec.Engine.Strategy = strategy
+				if err := r.Update(ctx, skyflo); err != nil {
+					t.Fatal(err)
+				}
+				reconcileOnce(t, r)
+				if err := r.Get(ctx, engineKey, engine); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			setStrategy(&appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType})
+			if got := engine.Spec.Strategy; got.Type != appsv1.RecreateDeploymentStrategyType || got.RollingUpdate != nil {
+				t.Errorf("Engine strategy = %+v, want Recreate without rolling update bounds", got)
+			}
+			cleared := false
+			for _, patch := range mergePatches {
+				cleared = cleared || strings.Contains(patch, `"rollingUpdate":null`)
+			}
+			if cleared != ssa {
+				t.Errorf("merge patches %v, want rollingUpdate cleared by one only with server-side apply", mergePatches)
+			}
+
+			setStrategy(nil)
+			if got := engine.Spec.Strategy; got.Type != appsv1.RollingUpdateDeploymentStrategyType || got.RollingUpdate == nil {
+				t.Errorf("Engine strategy = %+v, want RollingUpdate with its bounds", got)
+			}
+		})
+	}
+}
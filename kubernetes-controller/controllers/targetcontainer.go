@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// applyTargetContainer moves the configured probes onto the container named
+// by the component's targetContainer, e.g. a proxy sidecar's admin port, and
+// points the Service at that container's first port. The container must be
+// part of the generated pod.
+func applyTargetContainer(c component, deployment *appsv1.Deployment, service *corev1.Service) error {
+	name := c.spec.TargetContainer
+	containers := deployment.Spec.Template.Spec.Containers
+	if name == "" || name == containers[0].Name {
+		return nil
+	}
+
+	var target *corev1.Container
+	for i := range containers {
+		if containers[i].Name == name {
+			target = &containers[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("targetContainer %q is not a container of the %s pod", name, c.displayName)
+	}
+	if len(target.Ports) == 0 {
+		return fmt.Errorf("targetContainer %q of the %s pod exposes no port", name, c.displayName)
+	}
+
+	primary := &containers[0]
+	if c.spec.LivenessProbe != nil {
+		primary.LivenessProbe, target.LivenessProbe = nil, c.spec.LivenessProbe
+	}
+	if c.spec.ReadinessProbe != nil {
+		primary.ReadinessProbe, target.ReadinessProbe = nil, c.spec.ReadinessProbe
+	}
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(int(target.Ports[0].ContainerPort))
+	return nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestTargetContainer(t *testing.T) {
+	readiness := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http-proxy")}},
+	}
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.SecurityHeaders = map[string]string{"X-Frame-Options": "DENY"}
+	skyflo.Spec.UI.TargetContainer = securityHeadersContainer
+	skyflo.Spec.UI.ReadinessProbe = readiness
+	r := newTestReconciler(nil)
+
+	ui := components(skyflo)[0]
+	deployment := r.deployment(skyflo, ui)
+	service := r.service(skyflo, ui)
+	if err := applyTargetContainer(ui, deployment, service); err != nil {
+		t.Fatalf("applyTargetContainer: %v", err)
+	}
+
+	containers := deployment.Spec.Template.Spec.Containers
+	if containers[0].ReadinessProbe != nil {
+		t.Errorf("UI container kept the readiness probe %+v", containers[0].ReadinessProbe)
+	}
+	if containers[0].LivenessProbe == nil {
+		t.Errorf("UI container lost its default liveness probe")
+	}
+	if containers[1].Name != securityHeadersContainer || containers[1].ReadinessProbe != readiness {
+		t.Errorf("%s readiness probe = %+v, want the configured probe", containers[1].Name, containers[1].ReadinessProbe)
+	}
+	if got := service.Spec.Ports[0].TargetPort; got != intstr.FromInt(securityHeadersPort) {
+		t.Errorf("Service targetPort = %v, want %d", got.String(), securityHeadersPort)
+	}
+}
+
+func TestTargetContainerUnknown(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.UI.TargetContainer = "envoy"
+	r := newTestReconciler([]client.Object{skyflo})
+
+	ui := components(skyflo)[0]
+	err := applyTargetContainer(ui, r.deployment(skyflo, ui), r.service(skyflo, ui))
+	if err == nil || !strings.Contains(err.Error(), `targetContainer "envoy" is not a container of the UI pod`) {
+		t.Fatalf("applyTargetContainer error = %v, want the unknown container rejected", err)
+	}
+	if err := r.reconcileComponent(context.Background(), skyflo, ui); err == nil {
+		t.Error("reconcileComponent accepted an unknown target container")
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	minThrottleBackoff = 5 * time.Second
+	maxThrottleBackoff = 5 * time.Minute
+)
+
+// apiBackoff pauses reconciling of every SkyfloAI while the API server is
+// shedding load. Each 429 Too Many Requests doubles the pause, starting at
+// minThrottleBackoff and honoring the server's Retry-After, and the first
+// reconcile that succeeds afterwards ends it. The zero value is ready to use.
+type apiBackoff struct {
+	mu    sync.Mutex
+	delay time.Duration
+	until time.Time
+}
+
+// remaining returns how long reconciles are still held, with jitter so held
+// objects do not all return at once.
+func (b *apiBackoff) remaining(now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !now.Before(b.until) {
+		return 0, false
+	}
+	wait := b.until.Sub(now)
+	return wait + time.Duration(rand.Int63n(int64(wait/4)+1)), true
+}
+
+// throttle extends the pause after a rejected request and returns it.
+func (b *apiBackoff) throttle(now time.Time, retryAfter time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delay *= 2
+	if b.delay < minThrottleBackoff {
+		b.delay = minThrottleBackoff
+	}
+	if b.delay < retryAfter {
+		b.delay = retryAfter
+	}
+	if b.delay > maxThrottleBackoff {
+		b.delay = maxThrottleBackoff
+	}
+	b.until = now.Add(b.delay)
+	apiThrottled.Set(1)
+	return b.delay
+}
+
+// recover ends the pause once requests go through again.
+func (b *apiBackoff) recover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delay = 0
+	b.until = time.Time{}
+	apiThrottled.Set(0)
+}
+
+// throttledBy reports whether err, or any error it aggregates, is a 429 Too
+// Many Requests, along with the longest Retry-After the server suggested.
+func throttledBy(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	var aggregate utilerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		var longest time.Duration
+		var throttled bool
+		for _, err := range aggregate.Errors() {
+			if retryAfter, ok := throttledBy(err); ok {
+				throttled = true
+				if retryAfter > longest {
+					longest = retryAfter
+				}
+			}
+		}
+		return longest, throttled
+	}
+	if !apierrors.IsTooManyRequests(err) {
+		return 0, false
+	}
+	seconds, _ := apierrors.SuggestsClientDelay(err)
+	return time.Duration(seconds) * time.Second, true
+}
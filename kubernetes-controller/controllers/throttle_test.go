@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// throttledGauge returns the value of the skyflo_api_throttled gauge.
+func throttledGauge(t *testing.T) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := apiThrottled.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestAPIThrottleBackoff(t *testing.T) {
+	ctx := context.Background()
+	var retryAfter int
+	throttling := true
+	var requests int
+	r := newTestReconciler([]client.Object{testSkyfloAI()}, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			requests++
+			return c.Get(ctx, key, obj, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			requests++
+			if _, ok := obj.(*appsv1.Deployment); ok && throttling {
+				return apierrors.NewTooManyRequests("the server is overloaded", retryAfter)
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+	clock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r.Clock = clock
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "skyflo"}}
+	t.Cleanup(r.backoff.recover)
+
+	reconcile := func() ctrl.Result {
+		t.Helper()
+		result, err := r.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile returned %v, want a throttled requeue instead of an error", err)
+		}
+		return result
+	}
+
+	// The first 429 pauses reconciles for the minimum backoff.
+	if got := reconcile().RequeueAfter; got != minThrottleBackoff {
+		t.Errorf("RequeueAfter = %v, want %v after a 429", got, minThrottleBackoff)
+	}
+	if got := throttledGauge(t); got != 1 {
+		t.Errorf("skyflo_api_throttled = %v, want 1 while paused", got)
+	}
+
+	// While paused, reconciles return without touching the API server.
+	clock.SetTime(clock.Now().Add(2 * time.Second))
+	requests = 0
+	got := reconcile().RequeueAfter
+	if requests != 0 {
+		t.Errorf("paused reconcile issued %d requests, want none", requests)
+	}
+	if remaining := 3 * time.Second; got < remaining || got > remaining+remaining/4 {
+		t.Errorf("paused RequeueAfter = %v, want the remaining %v plus jitter", got, remaining)
+	}
+
+	// Each further 429 doubles the pause.
+	clock.SetTime(clock.Now().Add(3 * time.Second))
+	if got := reconcile().RequeueAfter; got != 2*minThrottleBackoff {
+		t.Errorf("RequeueAfter = %v, want %v after a second 429", got, 2*minThrottleBackoff)
+	}
+
+	// A longer Retry-After wins over the doubled pause.
+	retryAfter = 60
+	clock.SetTime(clock.Now().Add(2 * minThrottleBackoff))
+	if got := reconcile().RequeueAfter; got != time.Minute {
+		t.Errorf("RequeueAfter = %v, want the 1m Retry-After", got)
+	}
+
+	// The first reconcile that goes through ends the pause.
+	throttling = false
+	clock.SetTime(clock.Now().Add(time.Minute))
+	if got := reconcile().RequeueAfter; got >= minThrottleBackoff {
+		t.Errorf("RequeueAfter = %v after recovering, want the regular requeue", got)
+	}
+	if got := throttledGauge(t); got != 0 {
+		t.Errorf("skyflo_api_throttled = %v, want 0 after recovering", got)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "skyflo-engine"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("Engine Deployment not created after recovering: %v", err)
+	}
+}
+
+func TestThrottledBy(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryAfter time.Duration
+		wantThrottled  bool
+	}{
+		{name: "nil"},
+		{name: "not found", err: apierrors.NewNotFound(appsv1.Resource("deployments"), "skyflo-ui")},
+		{name: "429", err: apierrors.NewTooManyRequests("slow down", 0), wantThrottled: true},
+		{name: "429 with Retry-After", err: apierrors.NewTooManyRequests("slow down", 30), wantRetryAfter: 30 * time.Second, wantThrottled: true},
+		{
+			name: "aggregated",
+			err: utilerrors.NewAggregate([]error{
+				apierrors.NewConflict(appsv1.Resource("deployments"), "skyflo-ui", nil),
+				apierrors.NewTooManyRequests("slow down", 10),
+				apierrors.NewTooManyRequests("slow down", 20),
+			}),
+			wantRetryAfter: 20 * time.Second,
+			wantThrottled:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryAfter, throttled := throttledBy(tt.err)
+			if retryAfter != tt.wantRetryAfter || throttled != tt.wantThrottled {
+				t.Errorf("throttledBy = %v, %v, want %v, %v", retryAfter, throttled, tt.wantRetryAfter, tt.wantThrottled)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer emits reconcile spans through the global tracer provider, which is a
+// no-op unless the manager configured an exporter.
+var tracer = otel.Tracer("github.com/skyflo-ai/skyflo/kubernetes-controller/controllers")
+
+// endSpan records err, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
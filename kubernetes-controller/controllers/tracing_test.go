@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	skyflo := testSkyfloAI()
+	r := newTestReconciler([]client.Object{skyflo})
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: skyflo.Namespace, Name: skyflo.Name}}
+	// Steps the fake client cannot serve fail, but their spans are still
+	// recorded; only the shape of the tree matters here.
+	_, _ = r.Reconcile(context.Background(), req)
+
+	spans := exporter.GetSpans()
+	var root *tracetest.SpanStub
+	for i := range spans {
+		if spans[i].Name == "Reconcile" {
+			root = &spans[i]
+		}
+	}
+	if root == nil {
+		t.Fatalf("no Reconcile span among %d spans", len(spans))
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("Reconcile span has a parent")
+	}
+
+	children := map[string]int{}
+	components := map[string]bool{}
+	for _, span := range spans {
+		if span.Name == "Reconcile" {
+			continue
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("span %s is not a child of Reconcile", span.Name)
+			continue
+		}
+		children[span.Name]++
+		for _, attr := range span.Attributes {
+			if attr.Key == "component" {
+				components[attr.Value.AsString()] = true
+			}
+		}
+	}
+	if children["updateStatus"] != 1 {
+		t.Errorf("got %d updateStatus spans, want 1", children["updateStatus"])
+	}
+	for _, name := range []string{"ui", "engine", "mcp"} {
+		if !components[name] {
+			t.Errorf("no reconcileComponent span for %s; got %v", name, components)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// vaultSecretsDir is where the Vault Agent injector renders secret files.
+const vaultSecretsDir = "/vault/secrets/"
+
+// addVaultAgent stamps the Vault Agent injector annotations on the pod
+// template and points each secret's env var, if any, at its rendered file.
+func addVaultAgent(vault *skyflov1.VaultSpec, deployment *appsv1.Deployment) {
+	template := &deployment.Spec.Template
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations["vault.hashicorp.com/agent-inject"] = "true"
+	template.Annotations["vault.hashicorp.com/role"] = vault.Role
+
+	var env []corev1.EnvVar
+	for _, secret := range vault.Secrets {
+		template.Annotations["vault.hashicorp.com/agent-inject-secret-"+secret.Name] = secret.Path
+		if secret.Template != "" {
+			template.Annotations["vault.hashicorp.com/agent-inject-template-"+secret.Name] = secret.Template
+		}
+		file := secret.Name
+		if secret.File != "" {
+			template.Annotations["vault.hashicorp.com/agent-inject-file-"+secret.Name] = secret.File
+			file = secret.File
+		}
+		if secret.Env != "" {
+			env = append(env, corev1.EnvVar{Name: secret.Env, Value: vaultSecretsDir + file})
+		}
+	}
+
+	container := &template.Spec.Containers[0]
+	container.Env = mergeEnv(env, container.Env)
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestVaultAgent(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Vault = &skyflov1.VaultSpec{
+		Role: "skyflo",
+		Secrets: []skyflov1.VaultSecret{
+			{
+				Name:     "database",
+				Path:     "secret/data/skyflo/database",
+				Template: `{{ with secret "secret/data/skyflo/database" }}{{ .Data.data.url }}{{ end }}`,
+				Env:      "DATABASE_URL_FILE",
+			},
+			{
+				Name: "openai",
+				Path: "secret/data/skyflo/openai",
+				File: "openai.key",
+				Env:  "OPENAI_API_KEY_FILE",
+			},
+		},
+	}
+	r := newTestReconciler(nil)
+
+	want := map[string]string{
+		"vault.hashicorp.com/agent-inject":                   "true",
+		"vault.hashicorp.com/role":                           "skyflo",
+		"vault.hashicorp.com/agent-inject-secret-database":   "secret/data/skyflo/database",
+		"vault.hashicorp.com/agent-inject-template-database": `{{ with secret "secret/data/skyflo/database" }}{{ .Data.data.url }}{{ end }}`,
+		"vault.hashicorp.com/agent-inject-secret-openai":     "secret/data/skyflo/openai",
+		"vault.hashicorp.com/agent-inject-file-openai":       "openai.key",
+	}
+	wantEnv := map[string]string{
+		"DATABASE_URL_FILE":   "/vault/secrets/database",
+		"OPENAI_API_KEY_FILE": "/vault/secrets/openai.key",
+	}
+	for _, c := range components(skyflo) {
+		template := r.deployment(skyflo, c).Spec.Template
+		for key, value := range want {
+			if got := template.Annotations[key]; got != value {
+				t.Errorf("%s annotation %s = %q, want %q", c.name, key, got, value)
+			}
+		}
+		for key := range template.Annotations {
+			if strings.HasPrefix(key, "vault.hashicorp.com/") && want[key] == "" {
+				t.Errorf("%s has unexpected annotation %s", c.name, key)
+			}
+		}
+		for name, value := range wantEnv {
+			if got := envValue(template.Spec.Containers[0].Env, name); got != value {
+				t.Errorf("%s env %s = %q, want %q", c.name, name, got, value)
+			}
+		}
+	}
+}
+
+func TestVaultAgentOptIn(t *testing.T) {
+	skyflo := testSkyfloAI()
+	r := newTestReconciler(nil)
+	for _, c := range components(skyflo) {
+		for key := range r.deployment(skyflo, c).Spec.Template.Annotations {
+			if strings.HasPrefix(key, "vault.hashicorp.com/") {
+				t.Errorf("%s has Vault annotation %s without a Vault config", c.name, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// warmupRevisionAnnotation records on the warmup Job the Engine rollout it
+// warms up, so each new rollout gets a fresh Job.
+const warmupRevisionAnnotation = "skyflo.ai/warmup-revision"
+
+const (
+	defaultWarmupImage       = "curlimages/curl:8.7.1"
+	defaultWarmupRequests    = 10
+	defaultWarmupConcurrency = 2
+	defaultWarmupTimeout     = 30 * time.Second
+
+	// warmupBackoffLimit is the number of times a failed warmup pod is
+	// retried.
+	warmupBackoffLimit = 2
+	// warmupStartupAllowance covers scheduling the warmup pod and pulling
+	// its image, on top of the time its requests may take.
+	warmupStartupAllowance = time.Minute
+)
+
+// warmupScript sends $REQUESTS requests to every URL passed as an argument,
+// $CONCURRENCY at a time, failing on the first error response. Certificates
+// are not verified: the requests only warm the Engine up.
+const warmupScript = `set -e
+for url in "$@"; do
+  seq "$REQUESTS" | xargs -n 1 -P "$CONCURRENCY" sh -c 'curl -fksS -o /dev/null --max-time "$TIMEOUT" "$0"' "$url"
+done`
+
+func warmupName(skyflo *skyflov1.SkyfloAI) string {
+	return skyflo.Name + "-engine-warmup"
+}
+
+// warmupRevision identifies an Engine rollout together with the warmup
+// settings, so changing either runs the warmup again.
+func warmupRevision(deployment *appsv1.Deployment, warmup *skyflov1.WarmupSpec) (string, error) {
+	settings, err := json.Marshal(warmup)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s", templateRevision(&deployment.Spec.Template),
+		deployment.Spec.Template.Annotations[imageDigestAnnotation], settings)
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+// reconcileWarmup runs the warmup Job once the Engine has fully rolled out.
+// A Job left from an earlier rollout is deleted first; its deletion triggers
+// the reconcile creating the new one.
+func (r *SkyfloAIReconciler) reconcileWarmup(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	warmup := skyflo.Spec.Engine.Warmup
+	if warmup == nil {
+		return r.deleteWarmupJob(ctx, skyflo, r.pruningPolicy(skyflo))
+	}
+
+	deployment, err := r.engineDeployment(ctx, skyflo)
+	if err != nil || deployment == nil || !rolledOut(deployment) {
+		return err
+	}
+	revision, err := warmupRevision(deployment, warmup)
+	if err != nil {
+		return err
+	}
+
+	found := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: warmupName(skyflo), Namespace: skyflo.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if err := checkAdoptable(found, found, "Job"); err != nil {
+			return err
+		}
+		if found.Annotations[warmupRevisionAnnotation] == revision || !found.DeletionTimestamp.IsZero() {
+			return nil
+		}
+		return r.deleteWarmupJob(ctx, skyflo, skyflov1.PruningPolicyDelete)
+	}
+
+	job := warmupJob(skyflo, warmup, revision)
+	if err := r.own(skyflo, job); err != nil {
+		return err
+	}
+	return r.create(ctx, job)
+}
+
+// warmupJob returns the Job sending the warmup requests to the Engine
+// Service.
+func warmupJob(skyflo *skyflov1.SkyfloAI, warmup *skyflov1.WarmupSpec, revision string) *batchv1.Job {
+	scheme := "http"
+	if internalTLSEnabled(skyflo) {
+		scheme = "https"
+	}
+	paths := warmup.Paths
+	if len(paths) == 0 {
+		paths = []string{pathOrDefault(skyflo.Spec.Engine.ReadinessPath, defaultEngineReadinessPath)}
+	}
+	var urls []string
+	for _, path := range paths {
+		urls = append(urls, fmt.Sprintf("%s://%s-engine.%s.svc:80%s", scheme, skyflo.Name, skyflo.Namespace, path))
+	}
+
+	image := warmup.Image
+	if image == "" {
+		image = defaultWarmupImage
+	}
+	timeout := defaultWarmupTimeout
+	if warmup.Timeout != nil {
+		timeout = warmup.Timeout.Duration
+	}
+	requests := ptr.Deref(warmup.Requests, defaultWarmupRequests)
+	concurrency := ptr.Deref(warmup.Concurrency, defaultWarmupConcurrency)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        warmupName(skyflo),
+			Namespace:   skyflo.Namespace,
+			Labels:      map[string]string{"app": warmupName(skyflo)},
+			Annotations: map[string]string{warmupRevisionAnnotation: revision},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(warmupBackoffLimit)),
+			ActiveDeadlineSeconds: ptr.To(warmupDeadline(len(paths), requests, concurrency, timeout)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      mergeMaps(commonLabels(skyflo, "engine-warmup"), map[string]string{"app": warmupName(skyflo)}),
+					Annotations: mergeMaps(skyflo.Spec.CommonAnnotations, nil),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: skyflo.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{{
+						Name:    "warmup",
+						Image:   image,
+						Command: append([]string{"sh", "-c", warmupScript, "warmup"}, urls...),
+						Env: []corev1.EnvVar{
+							{Name: "REQUESTS", Value: strconv.Itoa(int(requests))},
+							{Name: "CONCURRENCY", Value: strconv.Itoa(int(concurrency))},
+							{Name: "TIMEOUT", Value: strconv.Itoa(int(timeout.Seconds()))},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// warmupDeadline bounds the warmup Job, in seconds, by the longest its
+// requests can take: every path gets requests requests, concurrency at a
+// time, each up to timeout, in each of the attempts the backoff limit allows.
+// A Job hanging past it fails instead of holding the Engine in WarmingUp.
+func warmupDeadline(paths int, requests, concurrency int32, timeout time.Duration) int64 {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	batches := int64((requests + concurrency - 1) / concurrency)
+	attempt := time.Duration(int64(paths)*batches)*timeout + warmupStartupAllowance
+	return int64((attempt * (warmupBackoffLimit + 1)).Seconds())
+}
+
+// deleteWarmupJob deletes the warmup Job together with its pods or, under
+// the Orphan policy, releases it.
+func (r *SkyfloAIReconciler) deleteWarmupJob(ctx context.Context, skyflo *skyflov1.SkyfloAI, policy skyflov1.PruningPolicy) error {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: warmupName(skyflo), Namespace: skyflo.Namespace}, job)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(job, skyflo) {
+		return nil
+	}
+	if policy == skyflov1.PruningPolicyOrphan {
+		return r.prune(ctx, skyflo, job)
+	}
+	// Jobs orphan their pods unless told otherwise.
+	return client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// gateOnWarmup holds a ready Engine in the WarmingUp phase until the warmup
+// Job of its current rollout has succeeded, and reports WarmupFailed when
+// the Job failed. Delete the failed Job to retry.
+func (r *SkyfloAIReconciler) gateOnWarmup(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	warmup := skyflo.Spec.Engine.Warmup
+	status := &skyflo.Status.EngineStatus
+	if warmup == nil || status.Phase != "Ready" {
+		return nil
+	}
+
+	deployment, err := r.engineDeployment(ctx, skyflo)
+	if err != nil || deployment == nil {
+		return err
+	}
+	revision, err := warmupRevision(deployment, warmup)
+	if err != nil {
+		return err
+	}
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: warmupName(skyflo), Namespace: skyflo.Namespace}, job)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err != nil || job.Annotations[warmupRevisionAnnotation] != revision {
+		status.Phase = "WarmingUp"
+		status.Message = "waiting for the warmup Job to start"
+		return nil
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return nil
+		case batchv1.JobFailed:
+			status.Phase = "WarmupFailed"
+			status.Message = fmt.Sprintf("warmup Job %s failed: %s", job.Name, condition.Message)
+			return nil
+		}
+	}
+	status.Phase = "WarmingUp"
+	status.Message = fmt.Sprintf("warmup Job %s is running", job.Name)
+	return nil
+}
+
+// engineDeployment returns the Engine Deployment, or nil when it does not
+// exist yet.
+func (r *SkyfloAIReconciler) engineDeployment(ctx context.Context, skyflo *skyflov1.SkyfloAI) (*appsv1.Deployment, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(skyflo, "engine", selectorVersion(skyflo)), Namespace: skyflo.Namespace}, deployment)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return deployment, nil
+}
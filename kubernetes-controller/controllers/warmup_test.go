@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+func TestWarmupDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		paths       int
+		requests    int32
+		concurrency int32
+		timeout     time.Duration
+		want        int64
+	}{
+		{name: "defaults", paths: 1, requests: 10, concurrency: 2, timeout: 30 * time.Second, want: 3 * (5*30 + 60)},
+		{name: "uneven batches", paths: 1, requests: 5, concurrency: 2, timeout: 10 * time.Second, want: 3 * (3*10 + 60)},
+		{name: "several paths", paths: 3, requests: 4, concurrency: 4, timeout: 5 * time.Second, want: 3 * (3*5 + 60)},
+		{name: "zero concurrency", paths: 1, requests: 2, concurrency: 0, timeout: time.Second, want: 3 * (2 + 60)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warmupDeadline(tt.paths, tt.requests, tt.concurrency, tt.timeout); got != tt.want {
+				t.Errorf("warmupDeadline() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarmupJobDeadline(t *testing.T) {
+	skyflo := testSkyfloAI()
+	warmup := &skyflov1.WarmupSpec{
+		Paths:    []string{"/a", "/b"},
+		Requests: ptr.To[int32](6),
+		Timeout:  &metav1.Duration{Duration: 10 * time.Second},
+	}
+	job := warmupJob(skyflo, warmup, "rev")
+	if got, want := ptr.Deref(job.Spec.ActiveDeadlineSeconds, 0), warmupDeadline(2, 6, defaultWarmupConcurrency, 10*time.Second); got != want {
+		t.Errorf("activeDeadlineSeconds = %d, want %d", got, want)
+	}
+}
+
+// TestActiveDeadlineOnlyOnJobs checks that only the one-shot warmup pods are
+// bounded by a deadline: a ReplicaSet rejects pod templates setting
+// activeDeadlineSeconds, and serving pods must run indefinitely.
+func TestActiveDeadlineOnlyOnJobs(t *testing.T) {
+	r := newTestReconciler(nil)
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Warmup = &skyflov1.WarmupSpec{}
+	skyflo.Spec.Components = []skyflov1.CustomComponentSpec{{
+		Name:          "worker",
+		Port:          9000,
+		ComponentSpec: skyflov1.ComponentSpec{Image: "skyflo/worker:test"},
+	}}
+	for _, c := range components(skyflo) {
+		if deadline := r.deployment(skyflo, c).Spec.Template.Spec.ActiveDeadlineSeconds; deadline != nil {
+			t.Errorf("%s pods have activeDeadlineSeconds %d, want none on serving pods", c.name, *deadline)
+		}
+	}
+	if job := warmupJob(skyflo, skyflo.Spec.Engine.Warmup, "rev"); job.Spec.ActiveDeadlineSeconds == nil {
+		t.Error("warmup Job has no activeDeadlineSeconds")
+	}
+}
+
+// rolledOutEngine returns an Engine Deployment that has fully rolled out.
+func rolledOutEngine(skyflo *skyflov1.SkyfloAI) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: deploymentName(skyflo, "engine", selectorVersion(skyflo)), Namespace: skyflo.Namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "engine", Image: "skyflo/engine:test"}}}},
+		},
+		Status: appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+}
+
+func TestWarmupRunsAndGatesReadiness(t *testing.T) {
+	ctx := context.Background()
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Warmup = &skyflov1.WarmupSpec{}
+	r := newTestReconciler([]client.Object{rolledOutEngine(skyflo)})
+
+	if err := r.reconcileWarmup(ctx, skyflo); err != nil {
+		t.Fatalf("reconcileWarmup: %v", err)
+	}
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: warmupName(skyflo), Namespace: skyflo.Namespace}, job); err != nil {
+		t.Fatalf("warmup Job was not created: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		condition batchv1.JobConditionType
+		wantPhase string
+	}{
+		{name: "running", wantPhase: "WarmingUp"},
+		{name: "complete", condition: batchv1.JobComplete, wantPhase: "Ready"},
+		{name: "failed", condition: batchv1.JobFailed, wantPhase: "WarmupFailed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job.Status.Conditions = nil
+			if tt.condition != "" {
+				job.Status.Conditions = []batchv1.JobCondition{{Type: tt.condition, Status: corev1.ConditionTrue}}
+			}
+			if err := r.Status().Update(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			skyflo.Status.EngineStatus.Phase = "Ready"
+			if err := r.gateOnWarmup(ctx, skyflo); err != nil {
+				t.Fatalf("gateOnWarmup: %v", err)
+			}
+			if got := skyflo.Status.EngineStatus.Phase; got != tt.wantPhase {
+				t.Errorf("phase = %s, want %s", got, tt.wantPhase)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// addZoneSpread spreads the component pods, selected by their pod labels,
+// across zones.
+func addZoneSpread(spread *skyflov1.ZoneSpreadSpec, selector map[string]string, deployment *appsv1.Deployment) {
+	whenUnsatisfiable := spread.WhenUnsatisfiable
+	if whenUnsatisfiable == "" {
+		whenUnsatisfiable = corev1.DoNotSchedule
+	}
+	spec := &deployment.Spec.Template.Spec
+	spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+		MaxSkew:           ptr.Deref(spread.MaxSkew, 1),
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: selector},
+		MinDomains:        spread.MinDomains,
+	})
+}
+
+// checkZoneCoverage records in the InsufficientZones condition the
+// components whose zoneSpread.minDomains exceeds the number of zones with
+// schedulable nodes, whose extra pods would stay pending.
+func (r *SkyfloAIReconciler) checkZoneCoverage(ctx context.Context, skyflo *skyflov1.SkyfloAI) error {
+	var spread []component
+	for _, c := range components(skyflo) {
+		if c.spec.ZoneSpread != nil && c.spec.ZoneSpread.MinDomains != nil {
+			spread = append(spread, c)
+		}
+	}
+	if len(spread) == 0 {
+		meta.RemoveStatusCondition(&skyflo.Status.Conditions, "InsufficientZones")
+		return nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return err
+	}
+	zones := map[string]bool{}
+	for _, node := range nodes.Items {
+		if zone := node.Labels[corev1.LabelTopologyZone]; zone != "" && !node.Spec.Unschedulable {
+			zones[zone] = true
+		}
+	}
+
+	var short []string
+	for _, c := range spread {
+		if minDomains := *c.spec.ZoneSpread.MinDomains; int(minDomains) > len(zones) {
+			short = append(short, fmt.Sprintf("%s (minDomains %d)", c.displayName, minDomains))
+		}
+	}
+	if len(short) == 0 {
+		meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+			Type:               "InsufficientZones",
+			Status:             metav1.ConditionFalse,
+			Reason:             "ZonesAvailable",
+			Message:            fmt.Sprintf("%d zones cover every zoneSpread.minDomains", len(zones)),
+			ObservedGeneration: skyflo.Generation,
+		})
+		return nil
+	}
+
+	meta.SetStatusCondition(&skyflo.Status.Conditions, metav1.Condition{
+		Type:   "InsufficientZones",
+		Status: metav1.ConditionTrue,
+		Reason: "NotEnoughZones",
+		Message: fmt.Sprintf("the cluster has schedulable nodes in %d zones, fewer than required by: %s",
+			len(zones), strings.Join(short, ", ")),
+		ObservedGeneration: skyflo.Generation,
+	})
+	return nil
+}
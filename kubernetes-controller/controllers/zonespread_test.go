@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	skyflov1 "github.com/skyflo-ai/skyflo/kubernetes-controller/engine/v1"
+)
+
+// zoneNode returns a node in the given zone.
+func zoneNode(name, zone string, unschedulable bool) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelTopologyZone: zone}},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+	}
+}
+
+func TestZoneSpread(t *testing.T) {
+	skyflo := testSkyfloAI()
+	skyflo.Spec.Engine.Replicas = ptr.To[int32](3)
+	skyflo.Spec.Engine.ZoneSpread = &skyflov1.ZoneSpreadSpec{MinDomains: ptr.To[int32](3)}
+	r := newTestReconciler(nil)
+
+	engine := components(skyflo)[1]
+	constraints := r.deployment(skyflo, engine).Spec.Template.Spec.TopologySpreadConstraints
+	if len(constraints) != 1 {
+		t.Fatalf("topology spread constraints = %+v, want one", constraints)
+	}
+	got := constraints[0]
+	if got.TopologyKey != corev1.LabelTopologyZone || got.MaxSkew != 1 || got.WhenUnsatisfiable != corev1.DoNotSchedule {
+		t.Errorf("constraint = %+v, want zone key, maxSkew 1 and DoNotSchedule", got)
+	}
+	if ptr.Deref(got.MinDomains, 0) != 3 {
+		t.Errorf("minDomains = %v, want 3", got.MinDomains)
+	}
+	if got.LabelSelector == nil || got.LabelSelector.MatchLabels["app"] == "" {
+		t.Errorf("label selector = %+v, want the engine pod labels", got.LabelSelector)
+	}
+
+	ui := components(skyflo)[0]
+	if constraints := r.deployment(skyflo, ui).Spec.Template.Spec.TopologySpreadConstraints; len(constraints) != 0 {
+		t.Errorf("UI without zoneSpread has constraints %+v", constraints)
+	}
+}
+
+func TestZoneCoverage(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      []client.Object
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "enough zones",
+			nodes: []client.Object{
+				zoneNode("a", "zone-a", false), zoneNode("b", "zone-b", false), zoneNode("c", "zone-c", false),
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "ZonesAvailable",
+		},
+		{
+			name: "too few zones",
+			nodes: []client.Object{
+				zoneNode("a1", "zone-a", false), zoneNode("a2", "zone-a", false), zoneNode("b", "zone-b", false),
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "NotEnoughZones",
+		},
+		{
+			name: "cordoned zone",
+			nodes: []client.Object{
+				zoneNode("a", "zone-a", false), zoneNode("b", "zone-b", false), zoneNode("c", "zone-c", true),
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "NotEnoughZones",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skyflo := testSkyfloAI()
+			skyflo.Spec.Engine.Replicas = ptr.To[int32](3)
+			skyflo.Spec.Engine.ZoneSpread = &skyflov1.ZoneSpreadSpec{MinDomains: ptr.To[int32](3)}
+			r := newTestReconciler(append([]client.Object{skyflo}, tt.nodes...))
+			reconcileOnce(t, r)
+
+			got := &skyflov1.SkyfloAI{}
+			if err := r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "skyflo"}, got); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(got.Status.Conditions, "InsufficientZones")
+			if condition == nil || condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Errorf("InsufficientZones = %+v, want %s/%s", condition, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
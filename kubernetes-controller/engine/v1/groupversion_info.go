@@ -34,3 +34,8 @@ var (
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
 )
+
+// SchemaVersion is the newest SkyfloAI schema revision these types understand.
+// Objects declaring a newer revision through the skyflo.ai/schema-version
+// annotation carry fields this operator would silently drop.
+const SchemaVersion = 1
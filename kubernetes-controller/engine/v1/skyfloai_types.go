@@ -1,8 +1,13 @@
 package v1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // SkyfloAISpec defines the desired state of SkyfloAI
@@ -16,10 +21,38 @@ type SkyfloAISpec struct {
 	// MCP defines configuration for the Skyflo.ai MCP component
 	MCP MCPSpec `json:"mcp"`
 
+	// Components lists additional components, reconciled in order after the
+	// UI, Engine and MCP
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Components []CustomComponentSpec `json:"components,omitempty"`
+
 	// ImagePullSecrets is a list of references to secrets for pulling images
 	// +optional
 	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 
+	// ImagePullSecretsTarget selects where ImagePullSecrets are attached for
+	// components running under a ServiceAccount the operator manages, such
+	// as the MCP: the pod spec (Pod), the ServiceAccount (ServiceAccount) or
+	// both (PodAndServiceAccount). Other components always list them in the
+	// pod spec. Defaults to Pod.
+	// +optional
+	// +kubebuilder:validation:Enum=Pod;ServiceAccount;PodAndServiceAccount
+	ImagePullSecretsTarget ImagePullSecretsTarget `json:"imagePullSecretsTarget,omitempty"`
+
+	// CommonLabels are added to every object the controller creates for the
+	// SkyfloAI, including pod templates. They do not override the labels the
+	// controller sets itself, such as selector labels.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// CommonAnnotations are added to every object the controller creates for
+	// the SkyfloAI, including pod templates, without overriding annotations
+	// the controller sets itself
+	// +optional
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+
 	// NodeSelector is a selector which must be true for the pod to fit on a node
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
@@ -31,38 +64,449 @@ type SkyfloAISpec struct {
 	// Affinity defines pod affinity/anti-affinity rules
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// RestartDependentsOnChange maps a component (ui, engine, mcp or the name
+	// of an entry in Components) to the
+	// components that should be restarted once it has rolled out a new image
+	// or configuration, e.g. {"engine": ["ui"]}
+	// +optional
+	RestartDependentsOnChange map[string][]string `json:"restartDependentsOnChange,omitempty"`
+
+	// Vault configures Vault Agent injection of secrets into the component
+	// pods
+	// +optional
+	Vault *VaultSpec `json:"vault,omitempty"`
+
+	// PruningPolicy controls what happens to resources of removed components
+	// and disabled features: Delete removes them, Orphan leaves them in place
+	// without the SkyfloAI owner reference. Defaults to Delete.
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	PruningPolicy PruningPolicy `json:"pruningPolicy,omitempty"`
+
+	// Monitoring configures monitoring integrations for the stack
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+
+	// PublishEndpoints maintains a <name>-endpoints ConfigMap with the
+	// in-cluster host, port and URL of each component Service, for tools
+	// that discover the stack by reading it
+	// +optional
+	PublishEndpoints *PublishSpec `json:"publishEndpoints,omitempty"`
+
+	// NamespaceLimitRange maintains a LimitRange in the SkyfloAI namespace so
+	// containers without explicit resources get defaults
+	// +optional
+	NamespaceLimitRange *LimitRangeSpec `json:"namespaceLimitRange,omitempty"`
+
+	// InternalTLS issues every component a certificate from an internal CA
+	// for component-to-component mTLS
+	// +optional
+	InternalTLS *InternalTLSSpec `json:"internalTLS,omitempty"`
 }
 
-// UISpec defines configuration for the UI component
-type UISpec struct {
-	// Image is the UI component container image
+// VaultSpec defines the secrets the Vault Agent injector renders into every
+// component pod
+type VaultSpec struct {
+	// Role is the Vault Kubernetes auth role the agent logs in with
+	Role string `json:"role"`
+
+	// Secrets lists the secrets to render
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Secrets []VaultSecret `json:"secrets,omitempty"`
+}
+
+// VaultSecret defines one secret rendered by the Vault Agent
+type VaultSecret struct {
+	// Name identifies the secret in the agent annotations and is the default
+	// file name under /vault/secrets
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Path is the Vault path of the secret
+	Path string `json:"path"`
+
+	// Template is a Consul Template used to render the secret file
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// File overrides the name of the rendered file under /vault/secrets
+	// +optional
+	File string `json:"file,omitempty"`
+
+	// Env names an environment variable set to the path of the rendered file
+	// +optional
+	Env string `json:"env,omitempty"`
+}
+
+// ComponentSpec defines configuration shared by every component. It is
+// embedded inline in the UI, Engine and MCP specs and in each entry of
+// Components.
+type ComponentSpec struct {
+	// Image is the component container image
+	// +kubebuilder:validation:MinLength=1
 	Image string `json:"image"`
 
-	// Replicas is the number of UI pods to run
+	// TrackTag rolls the component out whenever its image tag is pushed to
+	// point at a new digest, as polled by the controller
+	// +optional
+	TrackTag bool `json:"trackTag,omitempty"`
+
+	// ImagePullPolicy sets the pull policy of the component container. The
+	// Kubernetes default applies when empty; TrackTag requires Always.
+	// +optional
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Replicas is the number of pods to run
 	// +optional
+	// +kubebuilder:validation:Minimum=0
 	Replicas *int32 `json:"replicas,omitempty"`
 
-	// Resources defines compute resources for the UI container
+	// ReplicaReconcilePolicy selects whether replica counts changed outside
+	// the spec, e.g. by kubectl scale, are reverted to Replicas (Enforce) or
+	// kept (IgnoreExternal). Replicas then only applies when the Deployment
+	// is created. Defaults to Enforce.
+	// +optional
+	// +kubebuilder:validation:Enum=Enforce;IgnoreExternal
+	ReplicaReconcilePolicy ReplicaReconcilePolicy `json:"replicaReconcilePolicy,omitempty"`
+
+	// Resources defines compute resources for the component container
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Size selects predefined compute resources for the component container.
+	// Explicit Resources take precedence.
+	// +optional
+	Size ComponentSize `json:"size,omitempty"`
+
+	// Paused freezes rollouts of the component Deployment while the
+	// controller keeps managing its other fields
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// MaxSurge is how many pods, as a number or a percentage of replicas such
+	// as "25%", a rollout may create above the desired count
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is how many pods, as a number or a percentage of
+	// replicas, may be unavailable during a rollout
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Strategy replaces the component Deployment strategy, e.g. Recreate for
+	// components whose surge pods cannot be scheduled. It may not be combined
+	// with MaxSurge or MaxUnavailable. Kubernetes defaults apply when unset.
+	// +optional
+	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+
+	// MinReadySeconds is how long a new pod must be ready before the
+	// rollout counts it as available
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+
+	// PodDisruptionBudget limits voluntary disruptions, such as node drains,
+	// of the component pods with a PodDisruptionBudget named like the
+	// Deployment's Service
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
+	// ZoneSpread spreads the component pods across zones with a
+	// topology.kubernetes.io/zone topology spread constraint
+	// +optional
+	ZoneSpread *ZoneSpreadSpec `json:"zoneSpread,omitempty"`
+
+	// Autoscaling scales the component with a HorizontalPodAutoscaler, which
+	// then owns the replica count in place of Replicas
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// ServiceAnnotations are added to the component Service, e.g. cloud
+	// load balancer settings. They take precedence over annotations the
+	// controller derives, such as the external-dns ones.
+	// +optional
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+
+	// ServiceLabels are added to the component Service
+	// +optional
+	ServiceLabels map[string]string `json:"serviceLabels,omitempty"`
+
+	// ServiceType is the type of the component Service: ClusterIP (default),
+	// NodePort or LoadBalancer
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// NodePort pins the node port of the component Service's http port when
+	// ServiceType is NodePort or LoadBalancer. Allocated by the cluster when
+	// unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// IPFamilyPolicy sets the IP family policy of the component Service
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+
+	// IPFamilies sets the IP families of the component Service
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// ResizePolicy sets how the component container reacts to resource
+	// changes, e.g. NotRequired to resize CPU in place without a restart.
+	// Only takes effect where in-place pod resize is enabled; ignored on
+	// clusters older than Kubernetes 1.27.
+	// +optional
+	ResizePolicy []corev1.ContainerResizePolicy `json:"resizePolicy,omitempty"`
+
+	// TrafficDistribution sets the traffic distribution preference of the
+	// component Service, e.g. PreferClose to keep traffic within the client's
+	// zone. Ignored on clusters older than Kubernetes 1.31.
+	// +optional
+	TrafficDistribution *string `json:"trafficDistribution,omitempty"`
+
+	// SchedulerName selects the scheduler for the component pods. The default
+	// scheduler is used when empty.
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// PriorityClassName sets the PriorityClass of the component pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Spot lets the component pods run on spot and preemptible nodes by
+	// tolerating the taints GKE and AKS put on them, and caps the pod
+	// termination grace period at SpotTerminationGracePeriodSeconds so pods
+	// exit before the node is reclaimed. Components run on on-demand nodes
+	// unless set.
+	// +optional
+	Spot bool `json:"spot,omitempty"`
+
+	// PreemptionPolicy sets whether the component pods may preempt pods of
+	// lower priority. It must match the policy of the PriorityClass, which
+	// the API server otherwise enforces by rejecting the pods.
+	// +optional
+	PreemptionPolicy *corev1.PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+
+	// CustomDNS resolves names through the given nameservers instead of
+	// cluster DNS by setting the pod dnsPolicy to None
+	// +optional
+	CustomDNS *CustomDNSSpec `json:"customDNS,omitempty"`
+
+	// EnableServiceLinks injects service-link environment variables into the
+	// component pods. Defaults to false.
+	// +optional
+	EnableServiceLinks *bool `json:"enableServiceLinks,omitempty"`
+
+	// ExtraPorts are auxiliary container ports, such as admin or debug
+	// ports, also exposed by the component Service under the same name and
+	// number
+	// +optional
+	ExtraPorts []corev1.ContainerPort `json:"extraPorts,omitempty"`
+
+	// TargetContainer names the container, such as a proxy sidecar, that
+	// receives LivenessProbe and ReadinessProbe and whose first port the
+	// component Service targets. Defaults to the component container.
+	// +optional
+	TargetContainer string `json:"targetContainer,omitempty"`
+
+	// LivenessProbe overrides the container's liveness probe. Any probe
+	// handler (httpGet, tcpSocket, grpc or exec) may be used.
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// ReadinessProbe overrides the container's readiness probe. Any probe
+	// handler (httpGet, tcpSocket, grpc or exec) may be used.
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// BuildInfo, such as GIT_SHA or BUILD_ID, is injected into the component
+	// container as environment variables and into the pod template as
+	// build.skyflo.ai/<name> labels, e.g. build.skyflo.ai/git-sha. Label
+	// values are sanitized to the label syntax; the environment keeps the raw
+	// values. Env takes precedence over BuildInfo.
+	// +optional
+	BuildInfo map[string]string `json:"buildInfo,omitempty"`
+
 	// Env defines additional environment variables
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
-// EngineSpec defines configuration for the Engine component
-type EngineSpec struct {
-	// Image is the Engine container image
-	Image string `json:"image"`
+// PodDisruptionBudgetSpec defines the PodDisruptionBudget of a component.
+// At most one of MinAvailable and MaxUnavailable may be set; MaxUnavailable
+// defaults to 1 when neither is.
+type PodDisruptionBudgetSpec struct {
+	// Enabled switches the PodDisruptionBudget on or off while keeping its
+	// settings. When false the PodDisruptionBudget is deleted. Defaults to
+	// true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
 
-	// Replicas is the number of Engine pods to run
+	// MinAvailable is the number or percentage of pods that must stay
+	// available during voluntary disruptions
 	// +optional
-	Replicas *int32 `json:"replicas,omitempty"`
+	// +kubebuilder:validation:XIntOrString
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
 
-	// Resources defines compute resources for the Engine container
+	// MaxUnavailable is the number or percentage of pods that may be
+	// disrupted at once
 	// +optional
-	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// ZoneSpreadSpec defines how component pods are spread across zones
+type ZoneSpreadSpec struct {
+	// MaxSkew is the largest allowed difference in pod count between two
+	// zones. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew *int32 `json:"maxSkew,omitempty"`
+
+	// MinDomains is the number of zones the pods must span. Pods stay
+	// pending rather than crowd fewer zones, so replicas must be at least
+	// MinDomains.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinDomains *int32 `json:"minDomains,omitempty"`
+
+	// WhenUnsatisfiable is DoNotSchedule (default) or ScheduleAnyway.
+	// MinDomains requires DoNotSchedule.
+	// +optional
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+// AutoscalingSpec defines the HorizontalPodAutoscaler of a component
+type AutoscalingSpec struct {
+	// Enabled switches the autoscaler on or off while keeping its settings.
+	// When false the HorizontalPodAutoscaler is deleted and Replicas applies
+	// again. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// MinReplicas is the lower replica bound. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper replica bound
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the average CPU utilization, relative
+	// to the requests, to scale at. Defaults to 80.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// Behavior tunes the scale-up and scale-down policies. Defaults to a
+	// 300s scale-down stabilization window.
+	// +optional
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// CustomDNSSpec defines the DNS configuration of pods with dnsPolicy None
+type CustomDNSSpec struct {
+	// Nameservers are the DNS servers the pods resolve through
+	// +kubebuilder:validation:MinItems=1
+	Nameservers []string `json:"nameservers"`
+
+	// Searches are the DNS search domains
+	// +optional
+	Searches []string `json:"searches,omitempty"`
+
+	// Options are resolver options such as ndots
+	// +optional
+	Options []corev1.PodDNSConfigOption `json:"options,omitempty"`
+}
+
+// UISpec defines configuration for the UI component
+type UISpec struct {
+	ComponentSpec `json:",inline"`
+
+	// DNSName is the hostname external-dns should publish for the UI
+	// +optional
+	DNSName string `json:"dnsName,omitempty"`
+
+	// DNSTTL is the TTL in seconds of the record published for DNSName
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DNSTTL *int32 `json:"dnsTTL,omitempty"`
+
+	// SecurityHeaders are HTTP response headers, such as
+	// Content-Security-Policy, added to every UI response. When set, an nginx
+	// sidecar fronts the UI container and the UI Service targets it.
+	// +optional
+	SecurityHeaders map[string]string `json:"securityHeaders,omitempty"`
+
+	// SecurityHeadersProxyImage overrides the image of the security headers
+	// sidecar. Defaults to nginxinc/nginx-unprivileged:1.27-alpine.
+	// +optional
+	SecurityHeadersProxyImage string `json:"securityHeadersProxyImage,omitempty"`
+
+	// LoadBalancer exposes the UI through a LoadBalancer Service
+	// +optional
+	LoadBalancer *LoadBalancerSpec `json:"loadBalancer,omitempty"`
+
+	// Ingress routes external traffic to the UI Service through an Ingress
+	// named <name>-ui
+	// +optional
+	Ingress *IngressSpec `json:"ingress,omitempty"`
+}
+
+// IngressSpec defines the UI Ingress
+type IngressSpec struct {
+	// Enabled creates the Ingress. It is deleted when disabled.
+	Enabled bool `json:"enabled"`
+
+	// ClassName is the IngressClass handling the Ingress. Defaults to the
+	// cluster's default IngressClass.
+	// +optional
+	ClassName *string `json:"className,omitempty"`
+
+	// Host is the hostname the Ingress serves. All hosts when empty.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// TLSSecretName is the Secret holding the certificate for Host. TLS is
+	// not terminated when empty.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Annotations are added to the Ingress, e.g. for the ingress controller
+	// or cert-manager
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// LoadBalancerSpec defines a LoadBalancer Service
+type LoadBalancerSpec struct {
+	// ExternalTrafficPolicy routes external traffic to node-local pods only
+	// when Local, preserving the client source IP. Defaults to Cluster.
+	// +optional
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// HealthCheckNodePort pins the node port the load balancer health checks
+	// with a Local ExternalTrafficPolicy. Allocated by the cluster when unset.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	HealthCheckNodePort *int32 `json:"healthCheckNodePort,omitempty"`
+}
+
+// EngineSpec defines configuration for the Engine component
+type EngineSpec struct {
+	ComponentSpec `json:",inline"`
 
 	// DatabaseConfig defines PostgreSQL database configuration
 	// +optional
@@ -72,46 +516,328 @@ type EngineSpec struct {
 	// +optional
 	RedisConfig *RedisConfig `json:"redisConfig,omitempty"`
 
-	// Env defines additional environment variables
+	// TotalConcurrency is the worker concurrency target for the whole Engine
+	// deployment. When set, each pod receives WORKER_CONCURRENCY derived as
+	// ceil(TotalConcurrency / replicas). It may not be combined with enabled
+	// autoscaling: following the autoscaler's replica count would restart
+	// every Engine pod on each scaling step. When combined anyway, the
+	// controller leaves WORKER_CONCURRENCY unset and reports a
+	// TotalConcurrencyIgnored condition. The stock Engine image runs no
+	// workers and ignores WORKER_CONCURRENCY; it is for images that do.
 	// +optional
-	Env []corev1.EnvVar `json:"env,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	TotalConcurrency *int32 `json:"totalConcurrency,omitempty"`
+
+	// Queues names the worker queues the Engine consumes, rendered in order
+	// as the comma-separated QUEUES. Like WORKER_CONCURRENCY, it is ignored
+	// by the stock Engine image.
+	// +optional
+	Queues []string `json:"queues,omitempty"`
+
+	// StopSignal is the signal the Engine entrypoint should treat as a
+	// shutdown request, rendered as STOP_SIGNAL
+	// +optional
+	StopSignal string `json:"stopSignal,omitempty"`
+
+	// ShutdownTimeout bounds how long the Engine drains in-flight work on
+	// shutdown, rendered as SHUTDOWN_TIMEOUT in seconds. The pod termination
+	// grace period defaults to ten seconds longer. Must not be negative.
+	// +optional
+	ShutdownTimeout *metav1.Duration `json:"shutdownTimeout,omitempty"`
+
+	// TerminationGracePeriodSeconds overrides the Engine pod termination
+	// grace period. It must not be shorter than ShutdownTimeout.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// LivenessPath is the shallow health endpoint of the default Engine
+	// liveness probe. Defaults to /healthz.
+	// +optional
+	LivenessPath string `json:"livenessPath,omitempty"`
+
+	// ReadinessPath is the deep readiness endpoint of the default Engine
+	// readiness probe. It verifies database and Redis connectivity, so an
+	// outage takes pods out of the Service endpoints without restarting them.
+	// Defaults to /ready.
+	// +optional
+	ReadinessPath string `json:"readinessPath,omitempty"`
+
+	// DeadlockDetection replaces the default Engine liveness probe with an
+	// exec probe that fails once the Engine stops refreshing a heartbeat file.
+	// An explicit LivenessProbe takes precedence.
+	// +optional
+	DeadlockDetection *DeadlockSpec `json:"deadlockDetection,omitempty"`
+
+	// BoundToken replaces the default service account token mount with a
+	// projected token bound to the given audience
+	// +optional
+	BoundToken *BoundTokenSpec `json:"boundToken,omitempty"`
+
+	// SharedMemory mounts a memory-backed /dev/shm of this size instead of
+	// the runtime's 64Mi default. It counts against the memory limit.
+	// +optional
+	SharedMemory *resource.Quantity `json:"sharedMemory,omitempty"`
+
+	// Storage provisions a ReadWriteOnce PersistentVolumeClaim mounted into
+	// the Engine pods. It is shared by every Engine pod, so running more than
+	// one Engine replica with storage is unsupported.
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// RetainStorage keeps the Engine PersistentVolumeClaim when the SkyfloAI
+	// is deleted. Defaults to true. When false, the SkyfloAI owns the claim
+	// and it is garbage collected along with it. Removing Storage only deletes
+	// the claim when the SkyfloAI carries the skyflo.ai/delete-pvc: "true"
+	// annotation, whatever this is set to.
+	// +optional
+	RetainStorage *bool `json:"retainStorage,omitempty"`
+
+	// DatabaseDependency names an object, such as a CloudNativePG Cluster,
+	// that must report ready before the Engine is rolled out
+	// +optional
+	DatabaseDependency *DependencyRef `json:"databaseDependency,omitempty"`
+
+	// Warmup runs a Job sending requests to the Engine after each rollout.
+	// The Engine is only reported Ready once the Job has succeeded.
+	// +optional
+	Warmup *WarmupSpec `json:"warmup,omitempty"`
+}
+
+// WarmupSpec defines the requests warming up a newly rolled out Engine
+type WarmupSpec struct {
+	// Paths are requested on the Engine Service. Defaults to the readiness
+	// path.
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+
+	// Requests is the number of requests sent to each path. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Requests *int32 `json:"requests,omitempty"`
+
+	// Concurrency is the number of requests in flight at once. Defaults to 2.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Concurrency *int32 `json:"concurrency,omitempty"`
+
+	// Timeout bounds each request. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Image runs the requests and must provide sh, seq, xargs and curl.
+	// Defaults to curlimages/curl.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// DependencyRef references an object in the SkyfloAI namespace and how to
+// tell it is ready
+type DependencyRef struct {
+	// APIVersion is the group/version of the object, e.g. postgresql.cnpg.io/v1
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the object, e.g. Cluster
+	Kind string `json:"kind"`
+
+	// Name is the name of the object
+	Name string `json:"name"`
+
+	// ReadyPath is a JSONPath into the object whose value signals readiness.
+	// Defaults to the status of the Ready condition.
+	// +optional
+	ReadyPath string `json:"readyPath,omitempty"`
+
+	// ReadyValue is the value ReadyPath has once the object is ready.
+	// Defaults to "True".
+	// +optional
+	ReadyValue string `json:"readyValue,omitempty"`
+}
+
+// DeadlockSpec defines the heartbeat-based deadlock liveness check
+type DeadlockSpec struct {
+	// SentinelFile is the heartbeat file the Engine refreshes while its work
+	// loop makes progress, passed to it as DEADLOCK_SENTINEL_FILE. Defaults to
+	// /tmp/skyflo-heartbeat.
+	// +optional
+	SentinelFile string `json:"sentinelFile,omitempty"`
+
+	// MaxAge is how stale the heartbeat may get before the Engine is
+	// considered deadlocked. Defaults to 60s.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// PeriodSeconds is how often the probe runs. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+
+	// FailureThreshold is how many consecutive failures restart the pod.
+	// Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// BoundTokenSpec defines a projected, audience-bound service account token
+type BoundTokenSpec struct {
+	// Audience is the intended audience of the token
+	Audience string `json:"audience"`
+
+	// ExpirationSeconds is the requested token lifetime. The kubelet rotates
+	// the token before it expires. Defaults to 3600.
+	// +optional
+	// +kubebuilder:validation:Minimum=600
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+
+	// MountPath is the directory the token file is mounted in. Defaults to
+	// /var/run/secrets/skyflo.ai/serviceaccount.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// StorageSpec defines a PersistentVolumeClaim for a component
+type StorageSpec struct {
+	// Size is the requested storage capacity. Raising it expands an existing
+	// claim when its storage class allows volume expansion; claims never
+	// shrink.
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName is the storage class of the claim. The cluster default
+	// is used when unset.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// MountPath is where the volume is mounted in the container. Defaults to
+	// /data.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
 }
 
 // MCPSpec defines configuration for the MCP component
 type MCPSpec struct {
-	// Image is the MCP container image
-	Image string `json:"image"`
+	ComponentSpec `json:",inline"`
 
-	// Replicas is the number of MCP pods to run
+	// KubeconfigSecret is the name of the secret containing kubeconfig. The
+	// kubeconfig, under the "kubeconfig" key, is mounted into the MCP with
+	// KUBECONFIG pointing at it, and the MCP is not rolled out while it
+	// cannot be parsed or, with --check-kubeconfig-reachability, while its
+	// API server does not answer.
 	// +optional
-	Replicas *int32 `json:"replicas,omitempty"`
+	KubeconfigSecret string `json:"kubeconfigSecret,omitempty"`
 
-	// Resources defines compute resources for the MCP container
+	// Agentless runs the MCP without inbound traffic, for agents that only
+	// poll outward: the container port and the MCP Service are dropped while
+	// the Deployment is still managed
 	// +optional
-	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	Agentless bool `json:"agentless,omitempty"`
 
-	// KubeconfigSecret is the name of the secret containing kubeconfig
+	// ClusterRBAC binds the MCP ServiceAccount to a generated aggregated
+	// ClusterRole, so cluster admins grant the MCP permissions by labeling
+	// ClusterRoles they own
 	// +optional
-	KubeconfigSecret string `json:"kubeconfigSecret,omitempty"`
+	ClusterRBAC *MCPClusterRBACSpec `json:"clusterRBAC,omitempty"`
 
-	// Env defines additional environment variables
+	// RBAC binds the MCP ServiceAccount to a generated ClusterRole holding
+	// explicit rules. It may not be combined with ClusterRBAC.
 	// +optional
-	Env []corev1.EnvVar `json:"env,omitempty"`
+	RBAC *MCPRBACSpec `json:"rbac,omitempty"`
 }
 
+// MCPRBACSpec configures the ClusterRole of the MCP from explicit rules
+type MCPRBACSpec struct {
+	// Create generates the ClusterRole and its binding to the MCP
+	// ServiceAccount. They are deleted when disabled.
+	Create bool `json:"create"`
+
+	// Rules are the permissions granted to the MCP
+	// +optional
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// MCPClusterRBACSpec configures the aggregated ClusterRole of the MCP
+type MCPClusterRBACSpec struct {
+	// AggregationLabels select the ClusterRoles whose rules are aggregated
+	// into the MCP ClusterRole. Defaults to skyflo.ai/aggregate-to-mcp: "true".
+	// +optional
+	AggregationLabels map[string]string `json:"aggregationLabels,omitempty"`
+}
+
+// CustomComponentSpec defines an additional component reconciled alongside
+// the UI, Engine and MCP
+type CustomComponentSpec struct {
+	// Name identifies the component. Its Deployment and Service are named
+	// <skyfloai name>-<name>. It must not end in -v2 or name another object
+	// the controller generates, such as engine-metrics.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Port is the port the component container listens on. The component
+	// Service exposes it on port 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	ComponentSpec `json:",inline"`
+}
+
+// ComponentSize is a t-shirt size that expands into predefined container
+// resource requests and limits
+// +kubebuilder:validation:Enum=small;medium;large
+type ComponentSize string
+
+const (
+	// ComponentSizeSmall requests 100m CPU / 128Mi memory, limited to 500m / 512Mi
+	ComponentSizeSmall ComponentSize = "small"
+	// ComponentSizeMedium requests 250m CPU / 512Mi memory, limited to 1 / 1Gi
+	ComponentSizeMedium ComponentSize = "medium"
+	// ComponentSizeLarge requests 500m CPU / 1Gi memory, limited to 2 / 4Gi
+	ComponentSizeLarge ComponentSize = "large"
+)
+
+// SpotTerminationGracePeriodSeconds is the longest termination grace period
+// of spot component pods, within the shortest reclamation notice of the
+// common providers.
+const SpotTerminationGracePeriodSeconds = 25
+
 // DatabaseConfig defines PostgreSQL configuration
 type DatabaseConfig struct {
 	// Host is the database host
 	Host string `json:"host"`
 
 	// Port is the database port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
 	Port int32 `json:"port"`
 
 	// Database is the database name
 	Database string `json:"database"`
 
 	// SecretName is the name of the secret containing database credentials
+	// +kubebuilder:validation:MinLength=1
 	SecretName string `json:"secretName"`
+
+	// ConnectionPool configures the Engine's database connection pool,
+	// rendered as DB_POOL_MAX, DB_POOL_MIN and DB_POOL_MAX_IDLE_TIME
+	// +optional
+	ConnectionPool *PoolSpec `json:"connectionPool,omitempty"`
+}
+
+// PoolSpec defines database connection pool sizing
+type PoolSpec struct {
+	// MaxConnections is the maximum number of open connections per pod
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+
+	// MinConnections is the number of connections kept open per pod
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinConnections *int32 `json:"minConnections,omitempty"`
+
+	// MaxIdleTime is how long an idle connection is kept before it is closed
+	// +optional
+	MaxIdleTime *metav1.Duration `json:"maxIdleTime,omitempty"`
 }
 
 // RedisConfig defines Redis configuration
@@ -120,6 +846,8 @@ type RedisConfig struct {
 	Host string `json:"host"`
 
 	// Port is the Redis port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
 	Port int32 `json:"port"`
 
 	// SecretName is the name of the secret containing Redis credentials
@@ -127,6 +855,128 @@ type RedisConfig struct {
 	SecretName string `json:"secretName,omitempty"`
 }
 
+// InternalTLSSpec defines the internal CA and component certificates
+type InternalTLSSpec struct {
+	// Enabled generates the CA and certificates and mounts them into the
+	// component pods
+	Enabled bool `json:"enabled"`
+
+	// RotationDays is how many days a component certificate is used before it
+	// is reissued, rolling the component. Certificates stay valid for twice
+	// as long. Defaults to 30.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RotationDays int32 `json:"rotationDays,omitempty"`
+}
+
+// ImagePullSecretsTarget selects where image pull secrets are attached
+type ImagePullSecretsTarget string
+
+const (
+	// ImagePullSecretsTargetPod lists the secrets in the pod spec
+	ImagePullSecretsTargetPod ImagePullSecretsTarget = "Pod"
+	// ImagePullSecretsTargetServiceAccount links the secrets to the
+	// ServiceAccount the pods run as
+	ImagePullSecretsTargetServiceAccount ImagePullSecretsTarget = "ServiceAccount"
+	// ImagePullSecretsTargetPodAndServiceAccount does both
+	ImagePullSecretsTargetPodAndServiceAccount ImagePullSecretsTarget = "PodAndServiceAccount"
+)
+
+// ReplicaReconcilePolicy selects how the replica count of a component
+// Deployment is reconciled
+type ReplicaReconcilePolicy string
+
+const (
+	// ReplicaReconcilePolicyEnforce resets the replica count to the spec on
+	// every reconcile
+	ReplicaReconcilePolicyEnforce ReplicaReconcilePolicy = "Enforce"
+	// ReplicaReconcilePolicyIgnoreExternal keeps the replica count of an
+	// existing Deployment, however it was scaled
+	ReplicaReconcilePolicyIgnoreExternal ReplicaReconcilePolicy = "IgnoreExternal"
+)
+
+// PruningPolicy selects how resources that are no longer needed are pruned
+type PruningPolicy string
+
+const (
+	// PruningPolicyDelete deletes the resources
+	PruningPolicyDelete PruningPolicy = "Delete"
+	// PruningPolicyOrphan removes the SkyfloAI owner reference and leaves the
+	// resources in place
+	PruningPolicyOrphan PruningPolicy = "Orphan"
+)
+
+// PublishSpec configures the published endpoints ConfigMap
+type PublishSpec struct {
+	// Enabled creates the endpoints ConfigMap
+	Enabled bool `json:"enabled"`
+}
+
+// LimitRangeSpec defines the per-container defaults and bounds of a
+// namespace LimitRange
+type LimitRangeSpec struct {
+	// Default is the limit of containers that set none
+	// +optional
+	Default corev1.ResourceList `json:"default,omitempty"`
+
+	// DefaultRequest is the request of containers that set none
+	// +optional
+	DefaultRequest corev1.ResourceList `json:"defaultRequest,omitempty"`
+
+	// Max is the highest limit a container may set
+	// +optional
+	Max corev1.ResourceList `json:"max,omitempty"`
+}
+
+// MonitoringSpec defines monitoring integrations
+type MonitoringSpec struct {
+	// GrafanaDashboard configures the default Grafana dashboard ConfigMap
+	// +optional
+	GrafanaDashboard *DashboardSpec `json:"grafanaDashboard,omitempty"`
+
+	// PrometheusRules creates a PrometheusRule with default alerts for the
+	// stack when the Prometheus Operator CRDs are installed
+	// +optional
+	PrometheusRules bool `json:"prometheusRules,omitempty"`
+
+	// ServiceMonitor creates a ServiceMonitor scraping the Engine metrics port
+	// when the Prometheus Operator CRDs are installed
+	// +optional
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+
+	// MetricsPort is the Engine container port serving Prometheus metrics.
+	// Defaults to 9090.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	MetricsPort *int32 `json:"metricsPort,omitempty"`
+
+	// SeparateMetricsService exposes the metrics port on a dedicated
+	// <name>-engine-metrics Service targeted by the ServiceMonitor instead of
+	// on the main Engine Service
+	// +optional
+	SeparateMetricsService bool `json:"separateMetricsService,omitempty"`
+
+	// MonitoringNamespaceSelector selects the namespaces whose pods may scrape
+	// the Engine metrics port. When set alongside ServiceMonitor, a
+	// <name>-engine-metrics NetworkPolicy admits them to the metrics port and
+	// the stack's own pods to the Engine, so scraping keeps working under a
+	// default-deny ingress policy.
+	// +optional
+	MonitoringNamespaceSelector *metav1.LabelSelector `json:"monitoringNamespaceSelector,omitempty"`
+}
+
+// DashboardSpec defines a Grafana dashboard discovered by the Grafana sidecar
+type DashboardSpec struct {
+	// Enabled creates the dashboard ConfigMap
+	Enabled bool `json:"enabled"`
+
+	// FolderLabel is the Grafana folder the dashboard is placed in, set through
+	// the grafana_folder annotation
+	// +optional
+	FolderLabel string `json:"folderLabel,omitempty"`
+}
+
 // SkyfloAIStatus defines the observed state of SkyfloAI
 type SkyfloAIStatus struct {
 	// UIStatus defines the status of the UI component
@@ -138,11 +988,45 @@ type SkyfloAIStatus struct {
 	// MCPStatus defines the status of the MCP component
 	MCPStatus ComponentStatus `json:"mcpStatus"`
 
+	// ComponentStatuses reports the status of each entry in spec.components
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ComponentStatuses []NamedComponentStatus `json:"componentStatuses,omitempty"`
+
+	// AccessEndpoints lists the externally reachable URLs of the UI, resolved
+	// from the UI Ingress or LoadBalancer Service addresses
+	// +optional
+	AccessEndpoints []string `json:"accessEndpoints,omitempty"`
+
+	// AppliedSpecHash is the hash of the spec the controller last applied,
+	// combining the skyflo.ai/spec-hash annotations each generated
+	// Deployment carries for its resolved spec.
+	// +optional
+	AppliedSpecHash string `json:"appliedSpecHash,omitempty"`
+
+	// DiagnosticsRef points to the diagnostics bundle last collected through
+	// the skyflo.ai/collect-diagnostics annotation
+	// +optional
+	DiagnosticsRef *DiagnosticsReference `json:"diagnosticsRef,omitempty"`
+
 	// Conditions represent the latest available observations of the SkyfloAI state
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// DiagnosticsReference locates a diagnostics bundle
+type DiagnosticsReference struct {
+	// Name is the ConfigMap holding the bundle
+	Name string `json:"name"`
+
+	// Key is the ConfigMap key holding the JSON bundle
+	Key string `json:"key"`
+
+	// CollectedAt is when the bundle was collected
+	CollectedAt metav1.Time `json:"collectedAt"`
+}
+
 // ComponentStatus defines the status of a component
 type ComponentStatus struct {
 	// Phase is the current phase of the component
@@ -157,6 +1041,28 @@ type ComponentStatus struct {
 
 	// DesiredReplicas is the desired number of pods for this component
 	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// Nodes lists the nodes the component's pods are scheduled on
+	// +optional
+	Nodes []string `json:"nodes,omitempty"`
+
+	// RolledOutImage is the image, with its tracked digest if any, that every
+	// replica of the component last fully rolled out to
+	// +optional
+	RolledOutImage string `json:"rolledOutImage,omitempty"`
+
+	// LastRolloutTime is when the component last completed a rollout to a
+	// new image
+	// +optional
+	LastRolloutTime *metav1.Time `json:"lastRolloutTime,omitempty"`
+}
+
+// NamedComponentStatus is the status of an additional component
+type NamedComponentStatus struct {
+	// Name is the name of the component in spec.components
+	Name string `json:"name"`
+
+	ComponentStatus `json:",inline"`
 }
 
 //+kubebuilder:object:root=true
@@ -165,6 +1071,7 @@ type ComponentStatus struct {
 //+kubebuilder:printcolumn:name="UI Ready",type=string,JSONPath=`.status.uiStatus.phase`
 //+kubebuilder:printcolumn:name="Engine Ready",type=string,JSONPath=`.status.engineStatus.phase`
 //+kubebuilder:printcolumn:name="MCP Ready",type=string,JSONPath=`.status.mcpStatus.phase`
+//+kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // SkyfloAI is the Schema for the skyfloais API
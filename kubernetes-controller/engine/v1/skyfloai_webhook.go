@@ -0,0 +1,1001 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the SkyfloAI validating webhook with the
+// manager. requiredMetadata lists the keys every SkyfloAI must carry as a
+// label or an annotation.
+func (r *SkyfloAI) SetupWebhookWithManager(mgr ctrl.Manager, requiredMetadata []string) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&skyfloAIValidator{requiredMetadata: requiredMetadata}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-skyflo-ai-v1-skyfloai,mutating=false,failurePolicy=fail,sideEffects=None,groups=skyflo.ai,resources=skyfloais,verbs=create;update,versions=v1,name=vskyfloai.kb.io,admissionReviewVersions=v1
+
+// skyfloAIValidator validates SkyfloAI objects on admission.
+type skyfloAIValidator struct {
+	// requiredMetadata lists the keys every SkyfloAI must carry as a label
+	// or an annotation, e.g. owner or cost-center.
+	requiredMetadata []string
+}
+
+var _ webhook.CustomValidator = &skyfloAIValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *skyfloAIValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	skyflo, ok := obj.(*SkyfloAI)
+	if !ok {
+		return nil, fmt.Errorf("expected a SkyfloAI but got %T", obj)
+	}
+	return skyflo.warnings(), skyflo.validate(v.requiredMetadata, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *skyfloAIValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	skyflo, ok := newObj.(*SkyfloAI)
+	if !ok {
+		return nil, fmt.Errorf("expected a SkyfloAI but got %T", newObj)
+	}
+	old, ok := oldObj.(*SkyfloAI)
+	if !ok {
+		return nil, fmt.Errorf("expected a SkyfloAI but got %T", oldObj)
+	}
+	return skyflo.warnings(), skyflo.validate(v.requiredMetadata, old)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *skyfloAIValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the SkyfloAI; old is the object being updated, nil on
+// create.
+func (r *SkyfloAI) validate(requiredMetadata []string, old *SkyfloAI) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	allErrs = append(allErrs, validateRequiredMetadata(r, requiredMetadata)...)
+
+	// A rollout at 100% is complete and can no longer be paused, so the
+	// threshold stops at 99. A value accepted before the cap is kept on
+	// update.
+	if value, ok := r.Annotations["skyflo.ai/pause-rollout-at-percent"]; ok && (old == nil || old.Annotations["skyflo.ai/pause-rollout-at-percent"] != value) {
+		if percent, err := strconv.Atoi(value); err != nil || percent < 1 || percent > 99 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key("skyflo.ai/pause-rollout-at-percent"),
+				value, "must be an integer between 1 and 99"))
+		}
+	}
+
+	if version, ok := r.Annotations["skyflo.ai/selector-version"]; ok && version != "1" && version != "2" {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("metadata", "annotations").Key("skyflo.ai/selector-version"),
+			version, []string{"1", "2"}))
+	}
+
+	allErrs = append(allErrs, validateNameLength(r)...)
+	allErrs = append(allErrs, metav1validation.ValidateLabels(r.Spec.CommonLabels, specPath.Child("commonLabels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(r.Spec.CommonAnnotations, specPath.Child("commonAnnotations"))...)
+
+	reserved := vaultReservedEnv(r.Spec.Vault)
+	allErrs = append(allErrs, validateComponent(specPath.Child("ui"), &r.Spec.UI.ComponentSpec, reserved)...)
+	allErrs = append(allErrs, validateComponent(specPath.Child("engine"), &r.Spec.Engine.ComponentSpec, engineReservedEnv(&r.Spec.Engine, reserved))...)
+	allErrs = append(allErrs, validateComponent(specPath.Child("mcp"), &r.Spec.MCP.ComponentSpec, mcpReservedEnv(&r.Spec.MCP, reserved))...)
+	if rbac := r.Spec.MCP.ClusterRBAC; rbac != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabels(rbac.AggregationLabels, specPath.Child("mcp", "clusterRBAC", "aggregationLabels"))...)
+		if r.Spec.MCP.RBAC != nil && r.Spec.MCP.RBAC.Create {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("mcp", "rbac", "create"),
+				"may not be set together with clusterRBAC, whose aggregated ClusterRole has the same name"))
+		}
+	}
+	allErrs = append(allErrs, validateLoadBalancer(specPath.Child("ui", "loadBalancer"), r.Spec.UI.LoadBalancer)...)
+	if r.Spec.UI.LoadBalancer != nil && r.Spec.UI.ServiceType != "" && r.Spec.UI.ServiceType != corev1.ServiceTypeLoadBalancer {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("ui", "serviceType"), r.Spec.UI.ServiceType,
+			"must be LoadBalancer or unset when loadBalancer is set"))
+	}
+	allErrs = append(allErrs, validateSecurityHeaders(specPath.Child("ui", "securityHeaders"), r.Spec.UI.SecurityHeaders)...)
+	allErrs = append(allErrs, validateCustomComponents(specPath.Child("components"), r.Spec.Components, reserved)...)
+	allErrs = append(allErrs, validatePinnedReplicas(r, old)...)
+	if autoscaledConcurrency(r) && (old == nil || !autoscaledConcurrency(old)) {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("engine", "totalConcurrency"),
+			"may not be set while engine autoscaling is enabled, since splitting it across the autoscaler's "+
+				"replica count would restart every Engine pod on each scaling step; set WORKER_CONCURRENCY in engine.env instead"))
+	}
+
+	allErrs = append(allErrs, validateShutdown(specPath.Child("engine"), &r.Spec.Engine)...)
+	allErrs = append(allErrs, validateSpotShutdown(specPath.Child("engine"), &r.Spec.Engine)...)
+	allErrs = append(allErrs, validateSharedMemory(specPath.Child("engine"), &r.Spec.Engine)...)
+	allErrs = append(allErrs, validateQueues(specPath.Child("engine", "queues"), r.Spec.Engine.Queues)...)
+	if warmup := r.Spec.Engine.Warmup; warmup != nil {
+		allErrs = append(allErrs, validateWarmupPaths(specPath.Child("engine", "warmup", "paths"), warmup.Paths)...)
+	}
+
+	if monitoring := r.Spec.Monitoring; monitoring != nil {
+		if monitoring.MetricsPort != nil {
+			allErrs = append(allErrs, validatePort(specPath.Child("monitoring", "metricsPort"), *monitoring.MetricsPort)...)
+		}
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(monitoring.MonitoringNamespaceSelector,
+			metav1validation.LabelSelectorValidationOptions{}, specPath.Child("monitoring", "monitoringNamespaceSelector"))...)
+	}
+
+	allErrs = append(allErrs, validateDatabase(specPath.Child("engine", "databaseConfig"), r.Spec.Engine.DatabaseConfig)...)
+	if redis := r.Spec.Engine.RedisConfig; redis != nil {
+		allErrs = append(allErrs, validatePort(specPath.Child("engine", "redisConfig", "port"), redis.Port)...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("SkyfloAI").GroupKind(), r.Name, allErrs)
+}
+
+// validateRequiredMetadata checks that the SkyfloAI carries every required
+// key as a label or an annotation, listing all missing keys in one error.
+func validateRequiredMetadata(r *SkyfloAI, required []string) field.ErrorList {
+	var missing []string
+	for _, key := range required {
+		if _, ok := r.Labels[key]; ok {
+			continue
+		}
+		if _, ok := r.Annotations[key]; ok {
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return field.ErrorList{field.Required(field.NewPath("metadata", "labels"),
+		fmt.Sprintf("missing required labels or annotations: %s", strings.Join(missing, ", ")))}
+}
+
+func validateComponent(path *field.Path, spec *ComponentSpec, reserved map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.Image == "" {
+		allErrs = append(allErrs, field.Required(path.Child("image"), "a container image is required"))
+	}
+	if spec.Replicas != nil && *spec.Replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("replicas"), *spec.Replicas, "must not be negative"))
+	}
+	allErrs = append(allErrs, validateEnv(path.Child("env"), spec.Env, buildInfoReservedEnv(path.Child("buildInfo"), spec.BuildInfo, reserved))...)
+	allErrs = append(allErrs, validateSize(path.Child("size"), spec.Size)...)
+	if spec.TrackTag && spec.ImagePullPolicy != "" && spec.ImagePullPolicy != corev1.PullAlways {
+		allErrs = append(allErrs, field.Invalid(path.Child("imagePullPolicy"), spec.ImagePullPolicy,
+			"must be Always when trackTag is set, so pods pull the tracked digest"))
+	}
+	allErrs = append(allErrs, validateProbe(path.Child("livenessProbe"), spec.LivenessProbe)...)
+	allErrs = append(allErrs, validateProbe(path.Child("readinessProbe"), spec.ReadinessProbe)...)
+	if as := spec.Autoscaling; as != nil {
+		if as.MaxReplicas < 1 {
+			allErrs = append(allErrs, field.Invalid(path.Child("autoscaling", "maxReplicas"), as.MaxReplicas,
+				"must be at least 1"))
+		} else if as.MinReplicas != nil && *as.MinReplicas > as.MaxReplicas {
+			allErrs = append(allErrs, field.Invalid(path.Child("autoscaling", "minReplicas"), *as.MinReplicas,
+				fmt.Sprintf("must not exceed maxReplicas (%d)", as.MaxReplicas)))
+		}
+	}
+	allErrs = append(allErrs, validateRollingUpdate(path, spec.MaxSurge, spec.MaxUnavailable)...)
+	allErrs = append(allErrs, validateStrategy(path, spec)...)
+	allErrs = append(allErrs, validateZoneSpread(path, spec)...)
+	allErrs = append(allErrs, validateDisruptionBudget(path.Child("podDisruptionBudget"), spec.PodDisruptionBudget)...)
+	allErrs = append(allErrs, validateExtraPorts(path.Child("extraPorts"), spec.ExtraPorts)...)
+	allErrs = append(allErrs, validateBuildInfo(path.Child("buildInfo"), spec.BuildInfo, reserved)...)
+	allErrs = append(allErrs, metav1validation.ValidateLabels(spec.ServiceLabels, path.Child("serviceLabels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(spec.ServiceAnnotations, path.Child("serviceAnnotations"))...)
+	if spec.NodePort != 0 {
+		allErrs = append(allErrs, validatePort(path.Child("nodePort"), spec.NodePort)...)
+	}
+	if spec.NodePort != 0 && spec.ServiceType != corev1.ServiceTypeNodePort && spec.ServiceType != corev1.ServiceTypeLoadBalancer {
+		allErrs = append(allErrs, field.Invalid(path.Child("nodePort"), spec.NodePort,
+			"may only be set when serviceType is NodePort or LoadBalancer"))
+	}
+	if spec.CustomDNS != nil && len(spec.CustomDNS.Nameservers) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("customDNS", "nameservers"),
+			"at least one nameserver is required because dnsPolicy is None"))
+	}
+	return allErrs
+}
+
+// autoscaledConcurrency reports whether the Engine sets totalConcurrency
+// while autoscaling is enabled.
+func autoscaledConcurrency(r *SkyfloAI) bool {
+	as := r.Spec.Engine.Autoscaling
+	return r.Spec.Engine.TotalConcurrency != nil && as != nil && (as.Enabled == nil || *as.Enabled)
+}
+
+// replicasWithAutoscalingAnnotation, set to "true" on a SkyfloAI,
+// acknowledges that the autoscaler owns the replica count of components
+// setting replicas alongside enabled autoscaling, which then at most sizes a
+// newly created Deployment without minReplicas.
+const replicasWithAutoscalingAnnotation = "skyflo.ai/replicas-with-autoscaling"
+
+// validatePinnedReplicas rejects components setting replicas while
+// autoscaling is enabled, which leaves it unclear which one sets the replica
+// count, unless the SkyfloAI acknowledges it. On update, components that
+// already did so are let through, so existing SkyfloAIs can still be
+// updated.
+func validatePinnedReplicas(r *SkyfloAI, old *SkyfloAI) field.ErrorList {
+	if r.Annotations[replicasWithAutoscalingAnnotation] == "true" {
+		return nil
+	}
+	existing := map[string]bool{}
+	if old != nil {
+		for _, path := range pinnedReplicas(old) {
+			existing[path.String()] = true
+		}
+	}
+
+	var allErrs field.ErrorList
+	for _, path := range pinnedReplicas(r) {
+		if existing[path.String()] {
+			continue
+		}
+		allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf(
+			"may not be set while autoscaling is enabled, since the autoscaler owns the replica count; "+
+				"remove it, set autoscaling.enabled to false, or annotate the SkyfloAI with %s: \"true\" to keep it anyway",
+			replicasWithAutoscalingAnnotation)))
+	}
+	return allErrs
+}
+
+// pinnedReplicas returns the replicas fields of the components that set
+// replicas while autoscaling is enabled.
+func pinnedReplicas(r *SkyfloAI) []*field.Path {
+	specPath := field.NewPath("spec")
+	specs := map[*field.Path]*ComponentSpec{
+		specPath.Child("ui"):     &r.Spec.UI.ComponentSpec,
+		specPath.Child("engine"): &r.Spec.Engine.ComponentSpec,
+		specPath.Child("mcp"):    &r.Spec.MCP.ComponentSpec,
+	}
+	for i := range r.Spec.Components {
+		specs[specPath.Child("components").Index(i)] = &r.Spec.Components[i].ComponentSpec
+	}
+
+	var paths []*field.Path
+	for path, spec := range specs {
+		as := spec.Autoscaling
+		if spec.Replicas != nil && as != nil && (as.Enabled == nil || *as.Enabled) {
+			paths = append(paths, path.Child("replicas"))
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].String() < paths[j].String() })
+	return paths
+}
+
+// validateZoneSpread checks that minDomains is only combined with
+// DoNotSchedule and that the component runs enough replicas to span
+// minDomains zones: at least minReplicas when autoscaled.
+func validateZoneSpread(path *field.Path, spec *ComponentSpec) field.ErrorList {
+	spread := spec.ZoneSpread
+	if spread == nil || spread.MinDomains == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	minDomainsPath := path.Child("zoneSpread", "minDomains")
+	if spread.WhenUnsatisfiable == corev1.ScheduleAnyway {
+		allErrs = append(allErrs, field.Invalid(minDomainsPath, *spread.MinDomains,
+			"requires whenUnsatisfiable DoNotSchedule"))
+	}
+
+	replicasPath, replicas := minimumReplicas(path, spec)
+	if replicas < *spread.MinDomains {
+		allErrs = append(allErrs, field.Invalid(replicasPath, replicas,
+			fmt.Sprintf("must be at least zoneSpread.minDomains (%d)", *spread.MinDomains)))
+	}
+	return allErrs
+}
+
+// minimumReplicas returns the fewest replicas the component runs, from
+// replicas or, when autoscaled, autoscaling.minReplicas, and the field
+// setting it.
+func minimumReplicas(path *field.Path, spec *ComponentSpec) (*field.Path, int32) {
+	if as := spec.Autoscaling; as != nil && (as.Enabled == nil || *as.Enabled) {
+		if as.MinReplicas != nil {
+			return path.Child("autoscaling", "minReplicas"), *as.MinReplicas
+		}
+		return path.Child("autoscaling", "minReplicas"), 1
+	}
+	if spec.Replicas != nil {
+		return path.Child("replicas"), *spec.Replicas
+	}
+	return path.Child("replicas"), 1
+}
+
+// validateDisruptionBudget checks the PodDisruptionBudget bounds, of which
+// at most one may be set.
+func validateDisruptionBudget(path *field.Path, pdb *PodDisruptionBudgetSpec) field.ErrorList {
+	if pdb == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if _, err := rolloutBound(path.Child("minAvailable"), pdb.MinAvailable); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if _, err := rolloutBound(path.Child("maxUnavailable"), pdb.MaxUnavailable); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("maxUnavailable"), "may not be set together with minAvailable"))
+	}
+	return allErrs
+}
+
+// childSuffixes are the suffixes appended to the SkyfloAI name for the
+// Services and app labels of the built-in components, which are limited to a
+// DNS label.
+var childSuffixes = []string{"-ui", "-engine", "-mcp", "-engine-metrics"}
+
+// validateNameLength rejects names that would push a Service name or app
+// label of a component past the 63 character DNS label limit, which would
+// leave the component failing to create.
+func validateNameLength(r *SkyfloAI) field.ErrorList {
+	longest := ""
+	for _, suffix := range childSuffixes {
+		if len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	for _, component := range r.Spec.Components {
+		if suffix := "-" + component.Name; len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+
+	maxLength := validation.DNS1035LabelMaxLength - len(longest)
+	if len(r.Name) <= maxLength {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("metadata", "name"), r.Name,
+		fmt.Sprintf("must be at most %d characters so that the child name %q fits in %d characters",
+			maxLength, r.Name+longest, validation.DNS1035LabelMaxLength))}
+}
+
+// validateEnv checks that environment variable names are valid and unique,
+// and do not collide with variables the operator injects. reserved maps each
+// injected name to the field it is derived from.
+func validateEnv(path *field.Path, env []corev1.EnvVar, reserved map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(env))
+	for i, e := range env {
+		namePath := path.Index(i).Child("name")
+		if msgs := validation.IsEnvVarName(e.Name); len(msgs) > 0 {
+			allErrs = append(allErrs, field.Invalid(namePath, e.Name, strings.Join(msgs, "; ")))
+			continue
+		}
+		if seen[e.Name] {
+			allErrs = append(allErrs, field.Duplicate(namePath, e.Name))
+		}
+		seen[e.Name] = true
+		if source, ok := reserved[e.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(namePath, e.Name, fmt.Sprintf("is set by the operator from %s", source)))
+		}
+	}
+	return allErrs
+}
+
+// vaultReservedEnv returns the variables the operator injects into every
+// component for Vault secrets.
+func vaultReservedEnv(vault *VaultSpec) map[string]string {
+	reserved := map[string]string{}
+	if vault == nil {
+		return reserved
+	}
+	for i, secret := range vault.Secrets {
+		if secret.Env != "" {
+			reserved[secret.Env] = fmt.Sprintf("spec.vault.secrets[%d].env", i)
+		}
+	}
+	return reserved
+}
+
+// mcpReservedEnv adds the variables the operator derives from the MCP spec
+// to the reserved names of every component.
+func mcpReservedEnv(mcp *MCPSpec, common map[string]string) map[string]string {
+	if mcp.KubeconfigSecret == "" {
+		return common
+	}
+	reserved := make(map[string]string, len(common)+1)
+	for name, source := range common {
+		reserved[name] = source
+	}
+	reserved["KUBECONFIG"] = "spec.mcp.kubeconfigSecret"
+	return reserved
+}
+
+// engineReservedEnv adds the variables the operator derives from the Engine
+// spec to the reserved names of every component.
+func engineReservedEnv(engine *EngineSpec, common map[string]string) map[string]string {
+	reserved := make(map[string]string, len(common))
+	for name, source := range common {
+		reserved[name] = source
+	}
+	if engine.TotalConcurrency != nil {
+		reserved["WORKER_CONCURRENCY"] = "spec.engine.totalConcurrency"
+	}
+	if db := engine.DatabaseConfig; db != nil {
+		if db.Host != "" {
+			reserved["DB_HOST"] = "spec.engine.databaseConfig.host"
+		}
+		if db.Port != 0 {
+			reserved["DB_PORT"] = "spec.engine.databaseConfig.port"
+		}
+		if db.Database != "" {
+			reserved["DB_NAME"] = "spec.engine.databaseConfig.database"
+		}
+		if db.SecretName != "" {
+			reserved["DB_USER"] = "spec.engine.databaseConfig.secretName"
+			reserved["DB_PASSWORD"] = "spec.engine.databaseConfig.secretName"
+		}
+	}
+	if db := engine.DatabaseConfig; db != nil && db.ConnectionPool != nil {
+		pool := db.ConnectionPool
+		if pool.MaxConnections != nil {
+			reserved["DB_POOL_MAX"] = "spec.engine.databaseConfig.connectionPool.maxConnections"
+		}
+		if pool.MinConnections != nil {
+			reserved["DB_POOL_MIN"] = "spec.engine.databaseConfig.connectionPool.minConnections"
+		}
+		if pool.MaxIdleTime != nil {
+			reserved["DB_POOL_MAX_IDLE_TIME"] = "spec.engine.databaseConfig.connectionPool.maxIdleTime"
+		}
+	}
+	if len(engine.Queues) > 0 {
+		reserved["QUEUES"] = "spec.engine.queues"
+	}
+	if engine.DeadlockDetection != nil {
+		reserved["DEADLOCK_SENTINEL_FILE"] = "spec.engine.deadlockDetection"
+	}
+	if engine.StopSignal != "" {
+		reserved["STOP_SIGNAL"] = "spec.engine.stopSignal"
+	}
+	if engine.ShutdownTimeout != nil {
+		reserved["SHUTDOWN_TIMEOUT"] = "spec.engine.shutdownTimeout"
+	}
+	return reserved
+}
+
+// buildInfoNamePattern matches build info names, which must be valid
+// environment variable names that start and end with a letter or digit, so
+// the label name derived from them does too.
+var buildInfoNamePattern = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_]*[A-Za-z0-9])?$`)
+
+// validateBuildInfo checks that build info names are environment variable
+// names short enough to name a label, and that they do not collide with
+// variables the operator injects, which they would replace. Values need no
+// check: labels get a sanitized copy.
+func validateBuildInfo(path *field.Path, info map[string]string, reserved map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+	for name := range info {
+		switch {
+		case !buildInfoNamePattern.MatchString(name):
+			allErrs = append(allErrs, field.Invalid(path.Key(name), name, "must be a valid environment variable name starting and ending with a letter or digit"))
+		case len(name) > validation.LabelValueMaxLength:
+			allErrs = append(allErrs, field.TooLong(path.Key(name), name, validation.LabelValueMaxLength))
+		}
+		if source, ok := reserved[name]; ok {
+			allErrs = append(allErrs, field.Invalid(path.Key(name), name, fmt.Sprintf("is set by the operator from %s", source)))
+		}
+	}
+	return allErrs
+}
+
+// buildInfoReservedEnv adds the variables a component's build info injects
+// to its reserved names, so env entries cannot replace them.
+func buildInfoReservedEnv(path *field.Path, info map[string]string, common map[string]string) map[string]string {
+	if len(info) == 0 {
+		return common
+	}
+	reserved := make(map[string]string, len(common)+len(info))
+	for name, source := range common {
+		reserved[name] = source
+	}
+	for name := range info {
+		reserved[name] = path.Key(name).String()
+	}
+	return reserved
+}
+
+// reservedPortNames are the port names the controller gives the ports it
+// generates.
+var reservedPortNames = map[string]bool{"http": true, "http-proxy": true, "metrics": true}
+
+// validateSharedMemory checks that the memory-backed /dev/shm fits within
+// the Engine's explicit memory limit, which it counts against.
+func validateSharedMemory(path *field.Path, engine *EngineSpec) field.ErrorList {
+	if engine.SharedMemory == nil {
+		return nil
+	}
+	limit, ok := engine.Resources.Limits[corev1.ResourceMemory]
+	if !ok || engine.SharedMemory.Cmp(limit) <= 0 {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path.Child("sharedMemory"), engine.SharedMemory.String(),
+		fmt.Sprintf("must not exceed the memory limit %s, which shared memory counts against", limit.String()))}
+}
+
+// validateQueues checks that queue names are non-empty, unique and free of
+// the commas separating them in QUEUES.
+func validateQueues(path *field.Path, queues []string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(queues))
+	for i, queue := range queues {
+		switch {
+		case strings.TrimSpace(queue) == "":
+			allErrs = append(allErrs, field.Required(path.Index(i), "queue names must not be empty"))
+		case strings.Contains(queue, ","):
+			allErrs = append(allErrs, field.Invalid(path.Index(i), queue, "must not contain ','"))
+		case seen[queue]:
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), queue))
+		}
+		seen[queue] = true
+	}
+	return allErrs
+}
+
+// validateWarmupPaths checks that warmup paths are absolute and free of
+// whitespace, so each is requested as one URL.
+func validateWarmupPaths(path *field.Path, paths []string) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, p := range paths {
+		switch {
+		case !strings.HasPrefix(p, "/"):
+			allErrs = append(allErrs, field.Invalid(path.Index(i), p, "must start with '/'"))
+		case strings.ContainsAny(p, " \t\r\n"):
+			allErrs = append(allErrs, field.Invalid(path.Index(i), p, "must not contain whitespace"))
+		}
+	}
+	return allErrs
+}
+
+// validateStrategy checks that a strategy replacing the Deployment one is
+// not combined with maxSurge or maxUnavailable, and that only rolling
+// updates carry rolling update bounds.
+func validateStrategy(path *field.Path, spec *ComponentSpec) field.ErrorList {
+	strategy := spec.Strategy
+	if strategy == nil {
+		return nil
+	}
+	strategyPath := path.Child("strategy")
+	if spec.MaxSurge != nil || spec.MaxUnavailable != nil {
+		return field.ErrorList{field.Forbidden(strategyPath, "may not be combined with maxSurge or maxUnavailable")}
+	}
+
+	switch strategy.Type {
+	case "", appsv1.RollingUpdateDeploymentStrategyType:
+		if update := strategy.RollingUpdate; update != nil {
+			return validateRollingUpdate(strategyPath.Child("rollingUpdate"), update.MaxSurge, update.MaxUnavailable)
+		}
+	case appsv1.RecreateDeploymentStrategyType:
+		if strategy.RollingUpdate != nil {
+			return field.ErrorList{field.Forbidden(strategyPath.Child("rollingUpdate"), "may not be set when type is Recreate")}
+		}
+	default:
+		return field.ErrorList{field.NotSupported(strategyPath.Child("type"), strategy.Type, []string{
+			string(appsv1.RollingUpdateDeploymentStrategyType), string(appsv1.RecreateDeploymentStrategyType),
+		})}
+	}
+	return nil
+}
+
+// validateRollingUpdate checks that surge and unavailability are
+// non-negative numbers or percentages of at most 100%, and that they are not
+// both zero, which would block rollouts.
+func validateRollingUpdate(path *field.Path, maxSurge, maxUnavailable *intstr.IntOrString) field.ErrorList {
+	var allErrs field.ErrorList
+	surge, surgeErr := rolloutBound(path.Child("maxSurge"), maxSurge)
+	if surgeErr != nil {
+		allErrs = append(allErrs, surgeErr)
+	}
+	unavailable, unavailableErr := rolloutBound(path.Child("maxUnavailable"), maxUnavailable)
+	if unavailableErr != nil {
+		allErrs = append(allErrs, unavailableErr)
+	}
+	if len(allErrs) == 0 && maxSurge != nil && maxUnavailable != nil && surge == 0 && unavailable == 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxUnavailable"), maxUnavailable.String(),
+			"may not be 0 when maxSurge is 0"))
+	}
+	return allErrs
+}
+
+// rolloutBound returns the number or percentage of a rollout bound.
+func rolloutBound(path *field.Path, value *intstr.IntOrString) (int, *field.Error) {
+	if value == nil {
+		return 0, nil
+	}
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return 0, field.Invalid(path, value.IntVal, "must not be negative")
+		}
+		return int(value.IntVal), nil
+	}
+	percent, ok := strings.CutSuffix(value.StrVal, "%")
+	n, err := strconv.Atoi(percent)
+	if !ok || err != nil || n < 0 || n > 100 {
+		return 0, field.Invalid(path, value.StrVal, "must be a number or a percentage between 0% and 100%")
+	}
+	return n, nil
+}
+
+// validateExtraPorts checks that extra ports are named and that neither
+// names nor numbers repeat or collide with the primary Service port 80.
+func validateExtraPorts(path *field.Path, ports []corev1.ContainerPort) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]bool, len(ports))
+	numbers := make(map[int32]bool, len(ports))
+	for i, port := range ports {
+		portPath := path.Index(i)
+		switch {
+		case port.Name == "":
+			allErrs = append(allErrs, field.Required(portPath.Child("name"), "extra ports are exposed by the Service and must be named"))
+		case reservedPortNames[port.Name]:
+			allErrs = append(allErrs, field.Invalid(portPath.Child("name"), port.Name, "is reserved for a port generated by the controller"))
+		case names[port.Name]:
+			allErrs = append(allErrs, field.Duplicate(portPath.Child("name"), port.Name))
+		}
+		names[port.Name] = true
+
+		allErrs = append(allErrs, validatePort(portPath.Child("containerPort"), port.ContainerPort)...)
+		switch {
+		case port.ContainerPort == 80:
+			allErrs = append(allErrs, field.Invalid(portPath.Child("containerPort"), port.ContainerPort, "is the primary Service port"))
+		case numbers[port.ContainerPort]:
+			allErrs = append(allErrs, field.Duplicate(portPath.Child("containerPort"), port.ContainerPort))
+		}
+		numbers[port.ContainerPort] = true
+	}
+	return allErrs
+}
+
+// generatedChildSuffixes are the name suffixes, after <skyfloai>-, of the
+// objects the controller generates besides the component Deployments and
+// Services. A custom component of the same name would write its Deployment or
+// Service over them, or theirs over its own.
+var generatedChildSuffixes = map[string]bool{
+	"alerts":              true,
+	"diagnostics":         true,
+	"endpoints":           true,
+	"engine-data":         true,
+	"engine-metrics":      true,
+	"engine-warmup":       true,
+	"grafana-dashboard":   true,
+	"internal-ca":         true,
+	"limits":              true,
+	"ui-security-headers": true,
+}
+
+// validateCustomComponents checks that additional components have unique
+// names that do not collide with the built-in components or with other
+// generated objects. Names ending in -v2 are reserved for the Deployments of
+// the selector version 2 migration.
+func validateCustomComponents(path *field.Path, components []CustomComponentSpec, reserved map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(components))
+	for i := range components {
+		component := &components[i]
+		namePath := path.Index(i).Child("name")
+		switch {
+		case component.Name == "ui" || component.Name == "engine" || component.Name == "mcp":
+			allErrs = append(allErrs, field.Invalid(namePath, component.Name, "must not be the name of a built-in component"))
+		case generatedChildSuffixes[component.Name]:
+			allErrs = append(allErrs, field.Invalid(namePath, component.Name,
+				fmt.Sprintf("must not be %q, the name of an object the controller generates", component.Name)))
+		case strings.HasSuffix(component.Name, "-v2"):
+			allErrs = append(allErrs, field.Invalid(namePath, component.Name,
+				"must not end in -v2, which names component Deployments under selector version 2"))
+		case seen[component.Name]:
+			allErrs = append(allErrs, field.Duplicate(namePath, component.Name))
+		}
+		seen[component.Name] = true
+
+		allErrs = append(allErrs, validatePort(path.Index(i).Child("port"), component.Port)...)
+		allErrs = append(allErrs, validateComponent(path.Index(i), &component.ComponentSpec, reserved)...)
+	}
+	return allErrs
+}
+
+// validateDatabase checks that the Engine database is reachable on a valid
+// port with credentials, which the Engine reads from the username and
+// password keys of secretName.
+func validateDatabase(path *field.Path, db *DatabaseConfig) field.ErrorList {
+	if db == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validatePort(path.Child("port"), db.Port)...)
+	if db.SecretName == "" {
+		allErrs = append(allErrs, field.Required(path.Child("secretName"),
+			"the Secret holding the database username and password keys is required"))
+	}
+	if db.ConnectionPool != nil {
+		allErrs = append(allErrs, validatePool(path.Child("connectionPool"), db.ConnectionPool)...)
+	}
+	return allErrs
+}
+
+// validatePort checks that port is a valid TCP port number.
+func validatePort(path *field.Path, port int32) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsValidPortNum(int(port)) {
+		allErrs = append(allErrs, field.Invalid(path, port, msg))
+	}
+	return allErrs
+}
+
+// validateLoadBalancer checks that a health check node port is only pinned
+// where the cluster allocates one, i.e. with a Local traffic policy.
+func validateLoadBalancer(path *field.Path, lb *LoadBalancerSpec) field.ErrorList {
+	if lb == nil || lb.HealthCheckNodePort == nil {
+		return nil
+	}
+	if lb.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+		return validatePort(path.Child("healthCheckNodePort"), *lb.HealthCheckNodePort)
+	}
+	return field.ErrorList{field.Invalid(path.Child("healthCheckNodePort"), *lb.HealthCheckNodePort,
+		"may only be set when externalTrafficPolicy is Local")}
+}
+
+// validateSecurityHeaders checks that header names are HTTP tokens and that
+// values fit on a single line without nginx variable references.
+func validateSecurityHeaders(path *field.Path, headers map[string]string) field.ErrorList {
+	var allErrs field.ErrorList
+	for name, value := range headers {
+		if !headerNamePattern.MatchString(name) {
+			allErrs = append(allErrs, field.Invalid(path.Key(name), name, "must be a valid HTTP header name"))
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			allErrs = append(allErrs, field.Invalid(path.Key(name), value, "must not contain line breaks"))
+		}
+	}
+	return allErrs
+}
+
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func validateSize(path *field.Path, size ComponentSize) field.ErrorList {
+	switch size {
+	case "", ComponentSizeSmall, ComponentSizeMedium, ComponentSizeLarge:
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(path, size, []string{
+		string(ComponentSizeSmall), string(ComponentSizeMedium), string(ComponentSizeLarge),
+	})}
+}
+
+// validateProbe checks that a probe sets exactly one well-formed handler.
+func validateProbe(path *field.Path, probe *corev1.Probe) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	handlers := 0
+	handler := probe.ProbeHandler
+
+	if handler.Exec != nil {
+		handlers++
+		if len(handler.Exec.Command) == 0 {
+			allErrs = append(allErrs, field.Required(path.Child("exec", "command"), "exec probes must specify a command"))
+		}
+	}
+	if handler.HTTPGet != nil {
+		handlers++
+		allErrs = append(allErrs, validateProbePort(path.Child("httpGet", "port"), handler.HTTPGet.Port)...)
+	}
+	if handler.TCPSocket != nil {
+		handlers++
+		allErrs = append(allErrs, validateProbePort(path.Child("tcpSocket", "port"), handler.TCPSocket.Port)...)
+	}
+	if handler.GRPC != nil {
+		handlers++
+		if handler.GRPC.Port < 1 || handler.GRPC.Port > 65535 {
+			allErrs = append(allErrs, field.Invalid(path.Child("grpc", "port"), handler.GRPC.Port, "must be between 1 and 65535"))
+		}
+	}
+
+	switch {
+	case handlers == 0:
+		allErrs = append(allErrs, field.Required(path, "must specify one of exec, httpGet, tcpSocket or grpc"))
+	case handlers > 1:
+		allErrs = append(allErrs, field.Forbidden(path, "may not specify more than one handler"))
+	}
+	return allErrs
+}
+
+func validateProbePort(path *field.Path, port intstr.IntOrString) field.ErrorList {
+	if port.Type == intstr.String {
+		if port.StrVal == "" {
+			return field.ErrorList{field.Required(path, "must specify a port number or name")}
+		}
+		return nil
+	}
+	if port.IntVal < 1 || port.IntVal > 65535 {
+		return field.ErrorList{field.Invalid(path, port.IntVal, "must be between 1 and 65535")}
+	}
+	return nil
+}
+
+// validateShutdown checks that the Engine pods get at least as long to
+// terminate as the Engine is asked to drain.
+func validateShutdown(path *field.Path, engine *EngineSpec) field.ErrorList {
+	if engine.ShutdownTimeout != nil && engine.ShutdownTimeout.Duration < 0 {
+		return field.ErrorList{field.Invalid(path.Child("shutdownTimeout"), engine.ShutdownTimeout.Duration.String(), "must not be negative")}
+	}
+	if engine.ShutdownTimeout == nil || engine.TerminationGracePeriodSeconds == nil {
+		return nil
+	}
+	grace := time.Duration(*engine.TerminationGracePeriodSeconds) * time.Second
+	if grace < engine.ShutdownTimeout.Duration {
+		return field.ErrorList{field.Invalid(path.Child("terminationGracePeriodSeconds"), *engine.TerminationGracePeriodSeconds,
+			fmt.Sprintf("must not be shorter than shutdownTimeout (%s)", engine.ShutdownTimeout.Duration))}
+	}
+	return nil
+}
+
+// validateSpotShutdown checks that a spot Engine drains within the spot
+// termination grace period, which its pods are capped at.
+func validateSpotShutdown(path *field.Path, engine *EngineSpec) field.ErrorList {
+	if !engine.Spot {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if grace := engine.TerminationGracePeriodSeconds; grace != nil && *grace > SpotTerminationGracePeriodSeconds {
+		allErrs = append(allErrs, field.Invalid(path.Child("terminationGracePeriodSeconds"), *grace,
+			fmt.Sprintf("must not exceed %d seconds when spot is set", SpotTerminationGracePeriodSeconds)))
+	}
+	if timeout := engine.ShutdownTimeout; timeout != nil && timeout.Duration > SpotTerminationGracePeriodSeconds*time.Second {
+		allErrs = append(allErrs, field.Invalid(path.Child("shutdownTimeout"), timeout.Duration.String(),
+			fmt.Sprintf("must not exceed the %ds spot termination grace period", SpotTerminationGracePeriodSeconds)))
+	}
+	return allErrs
+}
+
+func validatePool(path *field.Path, pool *PoolSpec) field.ErrorList {
+	if pool.MinConnections == nil || pool.MaxConnections == nil {
+		return nil
+	}
+	if *pool.MinConnections > *pool.MaxConnections {
+		return field.ErrorList{field.Invalid(path.Child("minConnections"), *pool.MinConnections,
+			fmt.Sprintf("must not exceed maxConnections (%d)", *pool.MaxConnections))}
+	}
+	return nil
+}
+
+// defaultRolloutMaxUnavailable is the Deployment default rollout bound.
+var defaultRolloutMaxUnavailable = intstr.FromString("25%")
+
+// warnings reports settings that are accepted but put the components at
+// risk of disruption.
+func (r *SkyfloAI) warnings() admission.Warnings {
+	specPath := field.NewPath("spec")
+	var warnings admission.Warnings
+	warnings = append(warnings, disruptionWarnings(specPath.Child("ui"), &r.Spec.UI.ComponentSpec)...)
+	warnings = append(warnings, disruptionWarnings(specPath.Child("engine"), &r.Spec.Engine.ComponentSpec)...)
+	warnings = append(warnings, disruptionWarnings(specPath.Child("mcp"), &r.Spec.MCP.ComponentSpec)...)
+	for i := range r.Spec.Components {
+		warnings = append(warnings, disruptionWarnings(specPath.Child("components").Index(i), &r.Spec.Components[i].ComponentSpec)...)
+	}
+	warnings = append(warnings, storageWarnings(specPath.Child("engine"), &r.Spec.Engine)...)
+	if monitoring := r.Spec.Monitoring; monitoring != nil && monitoring.MonitoringNamespaceSelector != nil && !monitoring.ServiceMonitor {
+		warnings = append(warnings, fmt.Sprintf("%s has no effect unless %s is enabled",
+			specPath.Child("monitoring", "monitoringNamespaceSelector"), specPath.Child("monitoring", "serviceMonitor")))
+	}
+	return warnings
+}
+
+// storageWarnings checks the Engine rollout against its ReadWriteOnce claim,
+// which only pods on a single node can mount. Running more than one replica
+// is unsupported, and a rolling update whose new pod lands on another node
+// waits on the volume the old pod still holds.
+func storageWarnings(path *field.Path, engine *EngineSpec) []string {
+	if engine.Storage == nil {
+		return nil
+	}
+	var warnings []string
+	replicasPath, replicas := path.Child("replicas"), int32(1)
+	if engine.Replicas != nil {
+		replicas = *engine.Replicas
+	}
+	if as := engine.Autoscaling; as != nil && (as.Enabled == nil || *as.Enabled) {
+		replicasPath, replicas = path.Child("autoscaling", "maxReplicas"), as.MaxReplicas
+	}
+	if replicas > 1 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s allows %d replicas, but the ReadWriteOnce %s claim is shared by every Engine pod and can only be mounted on one node; running more than one Engine replica with storage is unsupported",
+			replicasPath, replicas, path.Child("storage")))
+	}
+	if engine.Strategy == nil || engine.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s is a ReadWriteOnce claim, so a rolling update whose new pod is scheduled onto another node waits until the old pod releases it; set %s to Recreate",
+			path.Child("storage"), path.Child("strategy", "type")))
+	}
+	return warnings
+}
+
+// disruptionWarnings checks a component's PodDisruptionBudget together with
+// its rollout settings. A budget that allows no disruption blocks node
+// drains. And since the budget counts pods as healthy once they are Ready,
+// without waiting out minReadySeconds, a rollout that takes pods down while
+// a drain evicts others can leave fewer pods available than the budget
+// promises; surge-only rollouts leave that headroom.
+func disruptionWarnings(path *field.Path, spec *ComponentSpec) []string {
+	pdb := spec.PodDisruptionBudget
+	if pdb == nil || (pdb.Enabled != nil && !*pdb.Enabled) {
+		return nil
+	}
+	_, replicas := minimumReplicas(path, spec)
+
+	var warnings []string
+	if allowed, ok := disruptionsAllowed(pdb, int(replicas)); ok && allowed <= 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s allows no voluntary disruption of %d replicas, so node drains block until replicas are added or the budget is relaxed",
+			path.Child("podDisruptionBudget"), replicas))
+	}
+
+	if spec.MinReadySeconds > 0 {
+		unavailable, err := rolloutMaxUnavailable(spec, int(replicas))
+		if err == nil && unavailable > 0 {
+			bound := path.Child("maxUnavailable")
+			if spec.Strategy != nil {
+				bound = path.Child("strategy", "rollingUpdate", "maxUnavailable")
+			}
+			warnings = append(warnings, fmt.Sprintf(
+				"%s counts pods as healthy once Ready, before %s elapses, so a node drain during a rollout that takes %d pods down can disrupt the component; set %s to 0 so rollouts only surge",
+				path.Child("podDisruptionBudget"), path.Child("minReadySeconds"), unavailable, bound))
+		}
+	}
+	return warnings
+}
+
+// rolloutMaxUnavailable returns how many of the replicas a rollout of the
+// component may take down at once: all of them with the Recreate strategy.
+func rolloutMaxUnavailable(spec *ComponentSpec, replicas int) (int, error) {
+	maxUnavailable := spec.MaxUnavailable
+	if strategy := spec.Strategy; strategy != nil {
+		if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+			return replicas, nil
+		}
+		if strategy.RollingUpdate != nil {
+			maxUnavailable = strategy.RollingUpdate.MaxUnavailable
+		}
+	}
+	if maxUnavailable == nil {
+		maxUnavailable = &defaultRolloutMaxUnavailable
+	}
+	return intstr.GetScaledValueFromIntOrPercent(maxUnavailable, replicas, false)
+}
+
+// disruptionsAllowed returns how many of the replicas the budget lets be
+// disrupted at once, rounding percentages up as the disruption controller
+// does.
+func disruptionsAllowed(pdb *PodDisruptionBudgetSpec, replicas int) (int, bool) {
+	if pdb.MinAvailable != nil {
+		minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.MinAvailable, replicas, true)
+		return replicas - minAvailable, err == nil
+	}
+	if pdb.MaxUnavailable != nil {
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.MaxUnavailable, replicas, true)
+		return maxUnavailable, err == nil
+	}
+	return 1, true
+}
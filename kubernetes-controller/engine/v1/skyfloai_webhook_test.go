@@ -0,0 +1,980 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+// errorFields returns the paths of the errors in errs.
+func errorFields(errs field.ErrorList) []string {
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+// equalFields reports whether the error paths got are exactly want.
+func equalFields(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validSkyfloAI returns a SkyfloAI the webhook accepts.
+func validSkyfloAI() *SkyfloAI {
+	return &SkyfloAI{
+		ObjectMeta: metav1.ObjectMeta{Name: "skyflo", Namespace: "default"},
+		Spec: SkyfloAISpec{
+			UI:     UISpec{ComponentSpec: ComponentSpec{Image: "skyflo/ui:test"}},
+			Engine: EngineSpec{ComponentSpec: ComponentSpec{Image: "skyflo/engine:test"}},
+			MCP:    MCPSpec{ComponentSpec: ComponentSpec{Image: "skyflo/mcp:test"}},
+		},
+	}
+}
+
+func TestValidateShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout *time.Duration
+		grace   *int64
+		want    []string
+	}{
+		{name: "unset"},
+		{name: "timeout only", timeout: ptr.To(30 * time.Second)},
+		{name: "grace covers timeout", timeout: ptr.To(30 * time.Second), grace: ptr.To[int64](30)},
+		{name: "grace shorter than timeout", timeout: ptr.To(30 * time.Second), grace: ptr.To[int64](20), want: []string{"spec.engine.terminationGracePeriodSeconds"}},
+		{name: "negative timeout", timeout: ptr.To(-time.Second), want: []string{"spec.engine.shutdownTimeout"}},
+		{name: "zero timeout", timeout: ptr.To(time.Duration(0)), grace: ptr.To[int64](0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &EngineSpec{TerminationGracePeriodSeconds: tt.grace}
+			if tt.timeout != nil {
+				engine.ShutdownTimeout = &metav1.Duration{Duration: *tt.timeout}
+			}
+			got := errorFields(validateShutdown(field.NewPath("spec", "engine"), engine))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSpotShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		spot    bool
+		timeout *time.Duration
+		grace   *int64
+		want    []string
+	}{
+		{name: "not spot", timeout: ptr.To(time.Minute), grace: ptr.To[int64](70)},
+		{name: "spot defaults", spot: true},
+		{name: "spot within the cap", spot: true, timeout: ptr.To(20 * time.Second), grace: ptr.To[int64](25)},
+		{name: "spot grace over the cap", spot: true, grace: ptr.To[int64](30), want: []string{"spec.engine.terminationGracePeriodSeconds"}},
+		{name: "spot timeout over the cap", spot: true, timeout: ptr.To(30 * time.Second), want: []string{"spec.engine.shutdownTimeout"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &EngineSpec{ComponentSpec: ComponentSpec{Spot: tt.spot}, TerminationGracePeriodSeconds: tt.grace}
+			if tt.timeout != nil {
+				engine.ShutdownTimeout = &metav1.Duration{Duration: *tt.timeout}
+			}
+			got := errorFields(validateSpotShutdown(field.NewPath("spec", "engine"), engine))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePool(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max *int32
+		want     []string
+	}{
+		{name: "unset"},
+		{name: "min only", min: ptr.To[int32](5)},
+		{name: "equal", min: ptr.To[int32](5), max: ptr.To[int32](5)},
+		{name: "ordered", min: ptr.To[int32](1), max: ptr.To[int32](5)},
+		{name: "inverted", min: ptr.To[int32](6), max: ptr.To[int32](5), want: []string{"spec.engine.databaseConfig.connectionPool.minConnections"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &PoolSpec{MinConnections: tt.min, MaxConnections: tt.max}
+			got := errorFields(validatePool(field.NewPath("spec", "engine", "databaseConfig", "connectionPool"), pool))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    []string
+	}{
+		{name: "valid", headers: map[string]string{"Content-Security-Policy": "default-src 'self'"}},
+		{name: "dollar", headers: map[string]string{"X-Test": "a$b"}},
+		{name: "invalid name", headers: map[string]string{"X Test": "a"}, want: []string{"spec.ui.securityHeaders[X Test]"}},
+		{name: "line break", headers: map[string]string{"X-Test": "a\r\nSet-Cookie: b"}, want: []string{"spec.ui.securityHeaders[X-Test]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateSecurityHeaders(field.NewPath("spec", "ui", "securityHeaders"), tt.headers))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSize(t *testing.T) {
+	tests := []struct {
+		size ComponentSize
+		want []string
+	}{
+		{size: ""},
+		{size: ComponentSizeSmall},
+		{size: ComponentSizeMedium},
+		{size: ComponentSizeLarge},
+		{size: "xlarge", want: []string{"spec.ui.size"}},
+	}
+	for _, tt := range tests {
+		got := errorFields(validateSize(field.NewPath("spec", "ui", "size"), tt.size))
+		if !equalFields(got, tt.want) {
+			t.Errorf("size %q: errors on %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestValidateProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler corev1.ProbeHandler
+		want    []string
+	}{
+		{name: "exec", handler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"true"}}}},
+		{name: "exec without command", handler: corev1.ProbeHandler{Exec: &corev1.ExecAction{}}, want: []string{"spec.mcp.livenessProbe.exec.command"}},
+		{name: "http", handler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromString("http")}}},
+		{name: "tcp", handler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(8080)}}},
+		{name: "tcp without port", handler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{}}, want: []string{"spec.mcp.livenessProbe.tcpSocket.port"}},
+		{name: "grpc", handler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 8081}}},
+		{name: "grpc out of range", handler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 70000}}, want: []string{"spec.mcp.livenessProbe.grpc.port"}},
+		{name: "no handler", want: []string{"spec.mcp.livenessProbe"}},
+		{
+			name: "two handlers",
+			handler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"true"}},
+				GRPC: &corev1.GRPCAction{Port: 8081},
+			},
+			want: []string{"spec.mcp.livenessProbe"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := &corev1.Probe{ProbeHandler: tt.handler}
+			got := errorFields(validateProbe(field.NewPath("spec", "mcp", "livenessProbe"), probe))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCustomComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "gateway"},
+		{name: "engine", want: []string{"spec.components[0].name"}},
+		{name: "engine-metrics", want: []string{"spec.components[0].name"}},
+		{name: "gateway-v2", want: []string{"spec.components[0].name"}},
+	}
+	for _, tt := range tests {
+		components := []CustomComponentSpec{{Name: tt.name, Port: 9000, ComponentSpec: ComponentSpec{Image: "gateway"}}}
+		got := errorFields(validateCustomComponents(field.NewPath("spec", "components"), components, nil))
+		if !equalFields(got, tt.want) {
+			t.Errorf("name %q: errors on %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	duplicate := []CustomComponentSpec{
+		{Name: "gateway", Port: 9000, ComponentSpec: ComponentSpec{Image: "gateway"}},
+		{Name: "gateway", Port: 9001, ComponentSpec: ComponentSpec{Image: "gateway"}},
+	}
+	got := errorFields(validateCustomComponents(field.NewPath("spec", "components"), duplicate, nil))
+	if want := []string{"spec.components[1].name"}; !equalFields(got, want) {
+		t.Errorf("duplicate names: errors on %v, want %v", got, want)
+	}
+}
+
+func TestValidateCustomDNS(t *testing.T) {
+	tests := []struct {
+		name string
+		dns  *CustomDNSSpec
+		want []string
+	}{
+		{name: "unset"},
+		{name: "nameserver", dns: &CustomDNSSpec{Nameservers: []string{"10.0.0.53"}}},
+		{name: "no nameservers", dns: &CustomDNSSpec{Searches: []string{"corp.internal"}}, want: []string{"spec.engine.customDNS.nameservers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &ComponentSpec{Image: "skyflo/engine:test", CustomDNS: tt.dns}
+			got := errorFields(validateComponent(field.NewPath("spec", "engine"), spec, nil))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNameLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		length     int
+		components []CustomComponentSpec
+		wantMax    int
+	}{
+		{name: "longest acceptable", length: 48},
+		{name: "too long", length: 49, wantMax: 48},
+		{name: "custom component within limit", length: 40, components: []CustomComponentSpec{{Name: "gateway"}}},
+		{name: "custom component suffix", length: 40, components: []CustomComponentSpec{{Name: "long-running-gateway-proxy"}}, wantMax: 36},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SkyfloAI{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", tt.length)},
+				Spec:       SkyfloAISpec{Components: tt.components},
+			}
+			errs := validateNameLength(r)
+			if tt.wantMax == 0 {
+				if len(errs) > 0 {
+					t.Errorf("rejected: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != "metadata.name" {
+				t.Fatalf("errors = %v, want one on metadata.name", errs)
+			}
+			if want := fmt.Sprintf("must be at most %d characters", tt.wantMax); !strings.Contains(errs[0].Detail, want) {
+				t.Errorf("detail = %q, want it to state %q", errs[0].Detail, want)
+			}
+		})
+	}
+}
+
+func TestValidateLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name string
+		lb   *LoadBalancerSpec
+		want []string
+	}{
+		{name: "unset"},
+		{name: "local", lb: &LoadBalancerSpec{ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal, HealthCheckNodePort: ptr.To[int32](32000)}},
+		{name: "cluster", lb: &LoadBalancerSpec{ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster, HealthCheckNodePort: ptr.To[int32](32000)}, want: []string{"spec.ui.loadBalancer.healthCheckNodePort"}},
+		{name: "default policy", lb: &LoadBalancerSpec{HealthCheckNodePort: ptr.To[int32](32000)}, want: []string{"spec.ui.loadBalancer.healthCheckNodePort"}},
+		{name: "unpinned", lb: &LoadBalancerSpec{ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeCluster}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateLoadBalancer(field.NewPath("spec", "ui", "loadBalancer"), tt.lb))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAutoscaledConcurrency(t *testing.T) {
+	skyfloAI := func(concurrency bool, enabled *bool) *SkyfloAI {
+		r := validSkyfloAI()
+		r.Spec.Engine.Autoscaling = &AutoscalingSpec{Enabled: enabled, MaxReplicas: 5}
+		if concurrency {
+			r.Spec.Engine.TotalConcurrency = ptr.To[int32](40)
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		r, old  *SkyfloAI
+		wantErr bool
+	}{
+		{name: "autoscaling only", r: skyfloAI(false, nil)},
+		{name: "autoscaling disabled", r: skyfloAI(true, ptr.To(false))},
+		{name: "autoscaled concurrency", r: skyfloAI(true, nil), wantErr: true},
+		{name: "newly autoscaled concurrency", r: skyfloAI(true, nil), old: skyfloAI(true, ptr.To(false)), wantErr: true},
+		{name: "already autoscaled concurrency", r: skyfloAI(true, nil), old: skyfloAI(true, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.validate(nil, tt.old)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "spec.engine.totalConcurrency") {
+				t.Errorf("error = %v, want one on spec.engine.totalConcurrency", err)
+			}
+		})
+	}
+}
+
+func TestValidateAutoscalingRange(t *testing.T) {
+	tests := []struct {
+		name string
+		as   *AutoscalingSpec
+		want string
+	}{
+		{name: "valid", as: &AutoscalingSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 5}},
+		{name: "equal bounds", as: &AutoscalingSpec{MinReplicas: ptr.To[int32](3), MaxReplicas: 3}},
+		{name: "inverted", as: &AutoscalingSpec{MinReplicas: ptr.To[int32](6), MaxReplicas: 5}, want: "spec.engine.autoscaling.minReplicas"},
+		{name: "no maxReplicas", as: &AutoscalingSpec{MinReplicas: ptr.To[int32](2)}, want: "spec.engine.autoscaling.maxReplicas"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validSkyfloAI()
+			r.Spec.Engine.Autoscaling = tt.as
+			err := r.validate(nil, nil)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one on %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePinnedReplicas(t *testing.T) {
+	skyfloAI := func(replicas bool, enabled *bool) *SkyfloAI {
+		r := validSkyfloAI()
+		r.Spec.Engine.Autoscaling = &AutoscalingSpec{Enabled: enabled, MaxReplicas: 5}
+		if replicas {
+			r.Spec.Engine.Replicas = ptr.To[int32](2)
+		}
+		return r
+	}
+	acknowledged := skyfloAI(true, nil)
+	acknowledged.Annotations = map[string]string{replicasWithAutoscalingAnnotation: "true"}
+	tests := []struct {
+		name    string
+		r, old  *SkyfloAI
+		wantErr bool
+	}{
+		{name: "autoscaling only", r: skyfloAI(false, nil)},
+		{name: "autoscaling disabled", r: skyfloAI(true, ptr.To(false))},
+		{name: "replicas with autoscaling", r: skyfloAI(true, nil), wantErr: true},
+		{name: "acknowledged", r: acknowledged},
+		{name: "newly pinned", r: skyfloAI(true, nil), old: skyfloAI(false, nil), wantErr: true},
+		{name: "newly autoscaled", r: skyfloAI(true, nil), old: skyfloAI(true, ptr.To(false)), wantErr: true},
+		{name: "already pinned", r: skyfloAI(true, nil), old: skyfloAI(true, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.validate(nil, tt.old)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "spec.engine.replicas") {
+				t.Errorf("error = %v, want one on spec.engine.replicas", err)
+			}
+		})
+	}
+}
+
+func TestValidateExtraPorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports []corev1.ContainerPort
+		want  []string
+	}{
+		{name: "unset"},
+		{name: "distinct", ports: []corev1.ContainerPort{{Name: "admin", ContainerPort: 9000}, {Name: "debug", ContainerPort: 9229}}},
+		{name: "unnamed", ports: []corev1.ContainerPort{{ContainerPort: 9000}}, want: []string{"spec.engine.extraPorts[0].name"}},
+		{name: "reserved name", ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9000}}, want: []string{"spec.engine.extraPorts[0].name"}},
+		{name: "duplicate name", ports: []corev1.ContainerPort{{Name: "admin", ContainerPort: 9000}, {Name: "admin", ContainerPort: 9001}}, want: []string{"spec.engine.extraPorts[1].name"}},
+		{name: "duplicate number", ports: []corev1.ContainerPort{{Name: "admin", ContainerPort: 9000}, {Name: "debug", ContainerPort: 9000}}, want: []string{"spec.engine.extraPorts[1].containerPort"}},
+		{name: "primary Service port", ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 80}}, want: []string{"spec.engine.extraPorts[0].containerPort"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateExtraPorts(field.NewPath("spec", "engine", "extraPorts"), tt.ports))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBuildInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info map[string]string
+		want []string
+	}{
+		{name: "unset"},
+		{name: "env names", info: map[string]string{"GIT_SHA": "3f2a9c1", "BUILD_ID": "ci/build #42"}},
+		{name: "not an env name", info: map[string]string{"GIT-SHA": "3f2a9c1"}, want: []string{"spec.engine.buildInfo[GIT-SHA]"}},
+		{name: "leading underscore", info: map[string]string{"_SHA": "3f2a9c1"}, want: []string{"spec.engine.buildInfo[_SHA]"}},
+		{name: "trailing underscore", info: map[string]string{"SHA_": "3f2a9c1"}, want: []string{"spec.engine.buildInfo[SHA_]"}},
+		{name: "too long", info: map[string]string{strings.Repeat("A", 64): "x"}, want: []string{"spec.engine.buildInfo[" + strings.Repeat("A", 64) + "]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateBuildInfo(field.NewPath("spec", "engine", "buildInfo"), tt.info, nil))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBuildInfoCollisions(t *testing.T) {
+	r := validSkyfloAI()
+	r.Spec.Engine.TotalConcurrency = ptr.To[int32](8)
+	r.Spec.Engine.BuildInfo = map[string]string{"GIT_SHA": "3f2a9c1"}
+	r.Spec.UI.BuildInfo = map[string]string{"WORKER_CONCURRENCY": "1"}
+	r.Spec.MCP.Env = []corev1.EnvVar{{Name: "GIT_SHA", Value: "mcp"}}
+	if err := r.validate(nil, nil); err != nil {
+		t.Fatalf("rejected names reserved only on other components: %v", err)
+	}
+
+	r.Spec.Engine.BuildInfo["WORKER_CONCURRENCY"] = "1"
+	r.Spec.Engine.Env = []corev1.EnvVar{{Name: "GIT_SHA", Value: "dirty"}}
+	err := r.validate(nil, nil)
+	for _, want := range []string{
+		"spec.engine.buildInfo[WORKER_CONCURRENCY]", "spec.engine.totalConcurrency",
+		"spec.engine.env[0].name", "from spec.engine.buildInfo[GIT_SHA]",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	reserved := map[string]string{"WORKER_CONCURRENCY": "spec.engine.totalConcurrency"}
+	tests := []struct {
+		name string
+		env  []corev1.EnvVar
+		want []string
+	}{
+		{name: "unset"},
+		{name: "valid", env: []corev1.EnvVar{{Name: "LOG_LEVEL"}, {Name: "app.mode"}}},
+		{name: "space", env: []corev1.EnvVar{{Name: "LOG LEVEL"}}, want: []string{"spec.engine.env[0].name"}},
+		{name: "leading digit", env: []corev1.EnvVar{{Name: "LOG_LEVEL"}, {Name: "1LEVEL"}}, want: []string{"spec.engine.env[1].name"}},
+		{name: "duplicate", env: []corev1.EnvVar{{Name: "LOG_LEVEL"}, {Name: "MODE"}, {Name: "LOG_LEVEL"}}, want: []string{"spec.engine.env[2].name"}},
+		{name: "reserved", env: []corev1.EnvVar{{Name: "WORKER_CONCURRENCY", Value: "8"}}, want: []string{"spec.engine.env[0].name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateEnv(field.NewPath("spec", "engine", "env"), tt.env, reserved))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReservedEnv(t *testing.T) {
+	r := validSkyfloAI()
+	r.Spec.Vault = &VaultSpec{Role: "skyflo", Secrets: []VaultSecret{{Name: "db", Path: "secret/db", Env: "DB_PASSWORD"}}}
+	r.Spec.Engine.TotalConcurrency = ptr.To[int32](8)
+	r.Spec.UI.Env = []corev1.EnvVar{{Name: "WORKER_CONCURRENCY", Value: "8"}}
+	if err := r.validate(nil, nil); err != nil {
+		t.Fatalf("UI env WORKER_CONCURRENCY rejected, want it reserved only on the Engine: %v", err)
+	}
+
+	r.Spec.Engine.Env = []corev1.EnvVar{{Name: "WORKER_CONCURRENCY", Value: "8"}}
+	r.Spec.MCP.Env = []corev1.EnvVar{{Name: "DB_PASSWORD", Value: "secret"}}
+	err := r.validate(nil, nil)
+	for _, want := range []string{
+		"spec.engine.env[0].name", "spec.engine.totalConcurrency",
+		"spec.mcp.env[0].name", "spec.vault.secrets[0].env",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestValidateDatabaseEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		db   *DatabaseConfig
+		env  string
+		want string
+	}{
+		{name: "no database", env: "DB_HOST"},
+		{name: "host", db: &DatabaseConfig{Host: "postgres"}, env: "DB_HOST", want: "spec.engine.databaseConfig.host"},
+		{name: "port", db: &DatabaseConfig{Port: 5432}, env: "DB_PORT", want: "spec.engine.databaseConfig.port"},
+		{name: "database", db: &DatabaseConfig{Database: "skyflo"}, env: "DB_NAME", want: "spec.engine.databaseConfig.database"},
+		{name: "user", db: &DatabaseConfig{SecretName: "db"}, env: "DB_USER", want: "spec.engine.databaseConfig.secretName"},
+		{name: "password", db: &DatabaseConfig{SecretName: "db"}, env: "DB_PASSWORD", want: "spec.engine.databaseConfig.secretName"},
+		{name: "field unset", db: &DatabaseConfig{Port: 5432, Database: "skyflo", SecretName: "db"}, env: "DB_HOST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validSkyfloAI()
+			r.Spec.Engine.DatabaseConfig = tt.db
+			r.Spec.Engine.Env = []corev1.EnvVar{{Name: tt.env, Value: "user"}}
+			// Only the Engine receives the database variables.
+			r.Spec.UI.Env = []corev1.EnvVar{{Name: tt.env, Value: "user"}}
+			err := r.validate(nil, nil)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "spec.engine.env[0].name") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want spec.engine.env[0].name reserved by %s", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "spec.ui.env") {
+				t.Errorf("error = %v, want the UI env allowed", err)
+			}
+		})
+	}
+}
+
+func TestValidateQueues(t *testing.T) {
+	tests := []struct {
+		name   string
+		queues []string
+		want   []string
+	}{
+		{name: "unset"},
+		{name: "unique", queues: []string{"urgent", "default", "batch"}},
+		{name: "empty", queues: []string{"default", " "}, want: []string{"spec.engine.queues[1]"}},
+		{name: "duplicate", queues: []string{"default", "batch", "default"}, want: []string{"spec.engine.queues[2]"}},
+		{name: "comma", queues: []string{"a,b"}, want: []string{"spec.engine.queues[0]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateQueues(field.NewPath("spec", "engine", "queues"), tt.queues))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r := validSkyfloAI()
+	r.Spec.Engine.Queues = []string{"default", "default"}
+	r.Spec.Engine.Env = []corev1.EnvVar{{Name: "QUEUES", Value: "other"}}
+	err := r.validate(nil, nil)
+	for _, want := range []string{"spec.engine.queues[1]", "spec.engine.env[0].name"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to name %s", err, want)
+		}
+	}
+}
+
+func TestValidateRequiredMetadata(t *testing.T) {
+	required := []string{"owner", "cost-center"}
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		wantMissing string
+	}{
+		{name: "both labels", labels: map[string]string{"owner": "ml", "cost-center": "42"}},
+		{name: "label and annotation", labels: map[string]string{"owner": "ml"}, annotations: map[string]string{"cost-center": "42"}},
+		{name: "one missing", labels: map[string]string{"owner": "ml"}, wantMissing: "cost-center"},
+		{name: "none", wantMissing: "owner, cost-center"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validSkyfloAI()
+			r.Labels = tt.labels
+			r.Annotations = tt.annotations
+			_, err := (&skyfloAIValidator{requiredMetadata: required}).ValidateCreate(context.Background(), r)
+			if tt.wantMissing == "" {
+				if err != nil {
+					t.Errorf("ValidateCreate: %v", err)
+				}
+				return
+			}
+			if want := "missing required labels or annotations: " + tt.wantMissing; err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("error = %v, want it to contain %q", err, want)
+			}
+		})
+	}
+
+	if _, err := (&skyfloAIValidator{}).ValidateCreate(context.Background(), validSkyfloAI()); err != nil {
+		t.Errorf("ValidateCreate without required keys: %v", err)
+	}
+	_, err := (&skyfloAIValidator{requiredMetadata: required}).ValidateUpdate(context.Background(), validSkyfloAI(), validSkyfloAI())
+	if err == nil || !strings.Contains(err.Error(), "owner, cost-center") {
+		t.Errorf("ValidateUpdate error = %v, want the missing keys", err)
+	}
+}
+
+func TestValidateRollingUpdate(t *testing.T) {
+	tests := []struct {
+		name               string
+		surge, unavailable *intstr.IntOrString
+		want               []string
+	}{
+		{name: "unset"},
+		{name: "percentages", surge: ptr.To(intstr.FromString("50%")), unavailable: ptr.To(intstr.FromString("10%"))},
+		{name: "numbers", surge: ptr.To(intstr.FromInt32(3)), unavailable: ptr.To(intstr.FromInt32(0))},
+		{name: "full surge", surge: ptr.To(intstr.FromString("100%"))},
+		{name: "over 100%", surge: ptr.To(intstr.FromString("150%")), want: []string{"spec.engine.maxSurge"}},
+		{name: "no percent sign", unavailable: ptr.To(intstr.FromString("25")), want: []string{"spec.engine.maxUnavailable"}},
+		{name: "not a number", unavailable: ptr.To(intstr.FromString("a%")), want: []string{"spec.engine.maxUnavailable"}},
+		{name: "negative", surge: ptr.To(intstr.FromInt32(-1)), want: []string{"spec.engine.maxSurge"}},
+		{name: "both zero", surge: ptr.To(intstr.FromString("0%")), unavailable: ptr.To(intstr.FromInt32(0)), want: []string{"spec.engine.maxUnavailable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateRollingUpdate(field.NewPath("spec", "engine"), tt.surge, tt.unavailable))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSharedMemory(t *testing.T) {
+	tests := []struct {
+		name        string
+		shm, memory string
+		want        []string
+	}{
+		{name: "unset"},
+		{name: "no memory limit", shm: "2Gi"},
+		{name: "within the limit", shm: "1Gi", memory: "2Gi"},
+		{name: "equal to the limit", shm: "2Gi", memory: "2Gi"},
+		{name: "over the limit", shm: "3Gi", memory: "2Gi", want: []string{"spec.engine.sharedMemory"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &EngineSpec{}
+			if tt.shm != "" {
+				engine.SharedMemory = ptr.To(resource.MustParse(tt.shm))
+			}
+			if tt.memory != "" {
+				engine.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(tt.memory)}
+			}
+			got := errorFields(validateSharedMemory(field.NewPath("spec", "engine"), engine))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMCPClusterRBAC(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster *MCPClusterRBACSpec
+		rbac    *MCPRBACSpec
+		want    string
+	}{
+		{name: "default labels", cluster: &MCPClusterRBACSpec{}},
+		{name: "custom labels", cluster: &MCPClusterRBACSpec{AggregationLabels: map[string]string{"rbac.example.com/mcp": "true"}}},
+		{name: "invalid label", cluster: &MCPClusterRBACSpec{AggregationLabels: map[string]string{"rbac.example.com/mcp": "not valid"}}, want: "spec.mcp.clusterRBAC.aggregationLabels"},
+		{name: "with explicit rules", cluster: &MCPClusterRBACSpec{}, rbac: &MCPRBACSpec{Create: true}, want: "spec.mcp.rbac.create"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validSkyfloAI()
+			r.Spec.MCP.ClusterRBAC = tt.cluster
+			r.Spec.MCP.RBAC = tt.rbac
+			err := r.validate(nil, nil)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one on %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateZoneSpread(t *testing.T) {
+	tests := []struct {
+		name string
+		spec ComponentSpec
+		want []string
+	}{
+		{name: "unset", spec: ComponentSpec{}},
+		{name: "no minDomains", spec: ComponentSpec{ZoneSpread: &ZoneSpreadSpec{MaxSkew: ptr.To[int32](2)}}},
+		{
+			name: "enough replicas",
+			spec: ComponentSpec{Replicas: ptr.To[int32](3), ZoneSpread: &ZoneSpreadSpec{MinDomains: ptr.To[int32](3)}},
+		},
+		{
+			name: "too few replicas",
+			spec: ComponentSpec{Replicas: ptr.To[int32](2), ZoneSpread: &ZoneSpreadSpec{MinDomains: ptr.To[int32](3)}},
+			want: []string{"spec.engine.replicas"},
+		},
+		{
+			name: "default replicas",
+			spec: ComponentSpec{ZoneSpread: &ZoneSpreadSpec{MinDomains: ptr.To[int32](3)}},
+			want: []string{"spec.engine.replicas"},
+		},
+		{
+			name: "enough minReplicas",
+			spec: ComponentSpec{
+				Replicas:    ptr.To[int32](1),
+				Autoscaling: &AutoscalingSpec{MinReplicas: ptr.To[int32](3), MaxReplicas: 6},
+				ZoneSpread:  &ZoneSpreadSpec{MinDomains: ptr.To[int32](3)},
+			},
+		},
+		{
+			name: "too few minReplicas",
+			spec: ComponentSpec{
+				Replicas:    ptr.To[int32](3),
+				Autoscaling: &AutoscalingSpec{MinReplicas: ptr.To[int32](2), MaxReplicas: 6},
+				ZoneSpread:  &ZoneSpreadSpec{MinDomains: ptr.To[int32](3)},
+			},
+			want: []string{"spec.engine.autoscaling.minReplicas"},
+		},
+		{
+			name: "schedule anyway",
+			spec: ComponentSpec{
+				Replicas:   ptr.To[int32](3),
+				ZoneSpread: &ZoneSpreadSpec{MinDomains: ptr.To[int32](3), WhenUnsatisfiable: corev1.ScheduleAnyway},
+			},
+			want: []string{"spec.engine.zoneSpread.minDomains"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateZoneSpread(field.NewPath("spec", "engine"), &tt.spec))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisruptionWarnings(t *testing.T) {
+	const (
+		blocksDrains = "allows no voluntary disruption"
+		rollout      = "counts pods as healthy once Ready"
+	)
+	tests := []struct {
+		name string
+		spec ComponentSpec
+		want []string
+	}{
+		{name: "no budget", spec: ComponentSpec{MinReadySeconds: 30}},
+		{
+			name: "default budget with headroom",
+			spec: ComponentSpec{Replicas: ptr.To[int32](2), PodDisruptionBudget: &PodDisruptionBudgetSpec{}},
+		},
+		{
+			name: "default budget on a single replica",
+			spec: ComponentSpec{PodDisruptionBudget: &PodDisruptionBudgetSpec{}},
+		},
+		{
+			name: "no pod may be unavailable",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](2),
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{MaxUnavailable: ptr.To(intstr.FromInt32(0))},
+			},
+			want: []string{blocksDrains},
+		},
+		{
+			name: "minAvailable of every replica",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](3),
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromString("100%"))},
+			},
+			want: []string{blocksDrains},
+		},
+		{
+			name: "autoscaled down to one replica",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](3),
+				Autoscaling:         &AutoscalingSpec{MaxReplicas: 5},
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromInt32(1))},
+			},
+			want: []string{blocksDrains},
+		},
+		{
+			name: "minReadySeconds with a rollout taking pods down",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](4),
+				MinReadySeconds:     30,
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{MaxUnavailable: ptr.To(intstr.FromInt32(1))},
+			},
+			want: []string{rollout},
+		},
+		{
+			name: "minReadySeconds with an explicit maxUnavailable",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](2),
+				MinReadySeconds:     30,
+				MaxUnavailable:      ptr.To(intstr.FromInt32(1)),
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{},
+			},
+			want: []string{rollout},
+		},
+		{
+			name: "minReadySeconds with surge-only rollouts",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](4),
+				MinReadySeconds:     30,
+				MaxSurge:            ptr.To(intstr.FromInt32(1)),
+				MaxUnavailable:      ptr.To(intstr.FromInt32(0)),
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{MaxUnavailable: ptr.To(intstr.FromInt32(1))},
+			},
+		},
+		{
+			name: "minReadySeconds with the default rollout rounding to no pods down",
+			spec: ComponentSpec{
+				Replicas:            ptr.To[int32](3),
+				MinReadySeconds:     30,
+				PodDisruptionBudget: &PodDisruptionBudgetSpec{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := disruptionWarnings(field.NewPath("spec", "engine"), &tt.spec)
+			if len(got) != len(tt.want) {
+				t.Fatalf("warnings = %q, want %d", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("warning %q, want it to contain %q", got[i], want)
+				}
+			}
+		})
+	}
+
+	skyflo := validSkyfloAI()
+	skyflo.Spec.Engine.MinReadySeconds = 30
+	skyflo.Spec.Engine.Replicas = ptr.To[int32](4)
+	skyflo.Spec.Engine.PodDisruptionBudget = &PodDisruptionBudgetSpec{}
+	warnings, err := (&skyfloAIValidator{}).ValidateCreate(context.Background(), skyflo)
+	if err != nil {
+		t.Fatalf("ValidateCreate: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "spec.engine.minReadySeconds") {
+		t.Errorf("admission warnings = %q, want the spec.engine rollout warning", warnings)
+	}
+}
+
+func TestStorageWarnings(t *testing.T) {
+	const (
+		replicas = "running more than one Engine replica with storage is unsupported"
+		rollout  = "set spec.engine.strategy.type to Recreate"
+	)
+	recreate := &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	storage := &StorageSpec{Size: resource.MustParse("1Gi")}
+	tests := []struct {
+		name   string
+		engine EngineSpec
+		want   []string
+	}{
+		{name: "no storage", engine: EngineSpec{ComponentSpec: ComponentSpec{Replicas: ptr.To[int32](3)}}},
+		{name: "single replica recreated", engine: EngineSpec{ComponentSpec: ComponentSpec{Strategy: recreate}, Storage: storage}},
+		{name: "rolling update", engine: EngineSpec{Storage: storage}, want: []string{rollout}},
+		{
+			name:   "several replicas",
+			engine: EngineSpec{ComponentSpec: ComponentSpec{Replicas: ptr.To[int32](2), Strategy: recreate}, Storage: storage},
+			want:   []string{"spec.engine.replicas allows 2 replicas", replicas},
+		},
+		{
+			name: "autoscaled",
+			engine: EngineSpec{
+				ComponentSpec: ComponentSpec{Autoscaling: &AutoscalingSpec{MaxReplicas: 4}, Strategy: recreate},
+				Storage:       storage,
+			},
+			want: []string{"spec.engine.autoscaling.maxReplicas allows 4 replicas"},
+		},
+		{
+			name: "autoscaling disabled",
+			engine: EngineSpec{
+				ComponentSpec: ComponentSpec{Autoscaling: &AutoscalingSpec{Enabled: ptr.To(false), MaxReplicas: 4}, Strategy: recreate},
+				Storage:       storage,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(storageWarnings(field.NewPath("spec", "engine"), &tt.engine), "\n")
+			if len(tt.want) == 0 && got != "" {
+				t.Errorf("warnings = %q, want none", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("warnings = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name string
+		pdb  *PodDisruptionBudgetSpec
+		want []string
+	}{
+		{name: "unset"},
+		{name: "default", pdb: &PodDisruptionBudgetSpec{}},
+		{name: "minAvailable", pdb: &PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromString("50%"))}},
+		{name: "maxUnavailable", pdb: &PodDisruptionBudgetSpec{MaxUnavailable: ptr.To(intstr.FromInt32(1))}},
+		{
+			name: "both",
+			pdb: &PodDisruptionBudgetSpec{
+				MinAvailable:   ptr.To(intstr.FromInt32(1)),
+				MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			},
+			want: []string{"spec.engine.podDisruptionBudget.maxUnavailable"},
+		},
+		{
+			name: "invalid percentage",
+			pdb:  &PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromString("half"))},
+			want: []string{"spec.engine.podDisruptionBudget.minAvailable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorFields(validateDisruptionBudget(field.NewPath("spec", "engine", "podDisruptionBudget"), tt.pdb))
+			if !equalFields(got, tt.want) {
+				t.Errorf("errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}